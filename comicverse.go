@@ -161,6 +161,16 @@ func (app *app) setup() error {
 		return fmt.Errorf("app: failed to start permission repository: %w", err)
 	}
 
+	pageRepository, err := repository.NewPage(app.ctx, app.db, app.logger.WithGroup("repository.page"), app.assert)
+	if err != nil {
+		return fmt.Errorf("app: failed to start page repository: %w", err)
+	}
+
+	audioRepository, err := repository.NewAudio(app.ctx, app.db, app.logger.WithGroup("repository.audio"), app.assert)
+	if err != nil {
+		return fmt.Errorf("app: failed to start audio repository: %w", err)
+	}
+
 	userService := service.NewUser(userRepository, app.logger.WithGroup("service.user"), app.assert)
 	tokenService := service.NewToken(service.TokenConfig{
 		PrivateKey: app.privateKey,
@@ -169,7 +179,18 @@ func (app *app) setup() error {
 		Logger:     app.logger.WithGroup("service.token"),
 		Assertions: app.assert,
 	})
-	projectService := service.NewProject(projectRepository, permissionRepository, app.logger.WithGroup("service.project"), app.assert)
+	projectService := service.NewProject(service.ProjectConfig{
+		ProjectRepository:    projectRepository,
+		PermissionRepository: permissionRepository,
+		PageRepository:       pageRepository,
+		AudioRepository:      audioRepository,
+		UserRepository:       userRepository,
+		Storage:              app.s3,
+		Bucket:               app.bucket,
+		Context:              app.ctx,
+		Logger:               app.logger.WithGroup("service.project"),
+		Assertions:           app.assert,
+	})
 
 	app.handler, err = router.New(router.Config{
 		UserService:    userService,
@@ -10,6 +10,7 @@ require (
 	github.com/google/uuid v1.6.0
 	github.com/tursodatabase/go-libsql v0.0.0-20241221181756-6121e81fbf92
 	golang.org/x/crypto v0.38.0
+	golang.org/x/image v0.25.0
 )
 
 require (
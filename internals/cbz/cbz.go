@@ -0,0 +1,205 @@
+// Package cbz reads comic book archives (CBZ), zip files with the pages of a comic
+// as images and an optional ComicInfo.xml metadata file, as defined by the
+// Anansi Project (https://anansi-project.github.io/docs/comicinfo/intro).
+package cbz
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+)
+
+const (
+	MimeType      = "application/vnd.comicbook+zip"
+	ComicInfoFile = "ComicInfo.xml"
+)
+
+var ErrNoPages = errors.New("cbz: archive has no pages")
+
+// ComicInfo is the metadata of a comic stored in the ComicInfo.xml file. Fields
+// with multiple people, such as Writer, are separated by commas.
+type ComicInfo struct {
+	XMLName     xml.Name `xml:"ComicInfo"`
+	Title       string   `xml:"Title,omitempty"`
+	Series      string   `xml:"Series,omitempty"`
+	Number      string   `xml:"Number,omitempty"`
+	Summary     string   `xml:"Summary,omitempty"`
+	Writer      string   `xml:"Writer,omitempty"`
+	Penciller   string   `xml:"Penciller,omitempty"`
+	Colorist    string   `xml:"Colorist,omitempty"`
+	Letterer    string   `xml:"Letterer,omitempty"`
+	CoverArtist string   `xml:"CoverArtist,omitempty"`
+	Editor      string   `xml:"Editor,omitempty"`
+	Translator  string   `xml:"Translator,omitempty"`
+	Publisher   string   `xml:"Publisher,omitempty"`
+	PageCount   int      `xml:"PageCount,omitempty"`
+	LanguageISO string   `xml:"LanguageISO,omitempty"`
+	Manga       Manga    `xml:"Manga,omitempty"`
+	AgeRating   string   `xml:"AgeRating,omitempty"`
+	GTIN        string   `xml:"GTIN,omitempty"` // Such as the ISBN of the comic
+}
+
+// Manga indicates if the comic is a manga, and if so, if it is read from right to left.
+type Manga string
+
+const (
+	MangaUnknown           Manga = "Unknown"
+	MangaNo                Manga = "No"
+	MangaYes               Manga = "Yes"
+	MangaYesAndRightToLeft Manga = "YesAndRightToLeft"
+)
+
+type Reader struct {
+	Info  *ComicInfo // Nil if the archive doesn't have a ComicInfo.xml file
+	Pages []Page     // Pages of the archive, in reading order
+}
+
+type Page struct {
+	Name        string // Path of the page's image inside the archive
+	ContentType string
+	Size        uint64 // Uncompressed size as declared by the archive, which may be wrong
+
+	file *zip.File
+}
+
+func (p Page) Open() (io.ReadCloser, error) {
+	return p.file.Open()
+}
+
+// NewReader reads the CBZ archive in r. Images are sorted by their names in natural
+// order (so "2.png" comes before "10.png"), files which aren't images are ignored.
+//
+// Pages are read directly from r, so it must remain valid while they are in use.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("cbz: failed to open zip archive: %w", err)
+	}
+
+	cbz := &Reader{}
+
+	for _, f := range z.File {
+		if f.FileInfo().IsDir() || isHidden(f.Name) {
+			continue
+		}
+
+		if strings.EqualFold(path.Base(f.Name), ComicInfoFile) {
+			info, err := readComicInfo(f)
+			if err != nil {
+				return nil, err
+			}
+			cbz.Info = info
+			continue
+		}
+
+		t, ok := ContentTypes[strings.ToLower(path.Ext(f.Name))]
+		if !ok {
+			continue
+		}
+
+		cbz.Pages = append(cbz.Pages, Page{
+			Name:        f.Name,
+			ContentType: t,
+			Size:        f.UncompressedSize64,
+			file:        f,
+		})
+	}
+
+	if len(cbz.Pages) == 0 {
+		return nil, ErrNoPages
+	}
+
+	slices.SortStableFunc(cbz.Pages, func(a, b Page) int {
+		return compareNatural(a.Name, b.Name)
+	})
+
+	return cbz, nil
+}
+
+// ContentTypes maps the file extensions of supported page images to their MIME types.
+var ContentTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+}
+
+const maxComicInfoSize = 1 << 20 // 1 MiB
+
+func readComicInfo(f *zip.File) (*ComicInfo, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("cbz: failed to open %s: %w", ComicInfoFile, err)
+	}
+	defer r.Close()
+
+	// The metadata file is small, the limit keeps a malicious archive from
+	// decompressing a huge one.
+	var info ComicInfo
+	if err := xml.NewDecoder(io.LimitReader(r, maxComicInfoSize)).Decode(&info); err != nil {
+		return nil, fmt.Errorf("cbz: failed to decode %s: %w", ComicInfoFile, err)
+	}
+
+	return &info, nil
+}
+
+// isHidden reports if the file is metadata added by operating systems or archivers,
+// such as "__MACOSX/" directories or dotfiles.
+func isHidden(name string) bool {
+	for _, p := range strings.Split(name, "/") {
+		if strings.HasPrefix(p, ".") || p == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
+
+// compareNatural compares a and b, treating runs of digits as numbers.
+func compareNatural(a, b string) int {
+	for a != "" && b != "" {
+		ad, bd := isDigit(a[0]), isDigit(b[0])
+
+		if ad && bd {
+			an, ar := splitDigits(a)
+			bn, br := splitDigits(b)
+
+			tan, tbn := strings.TrimLeft(an, "0"), strings.TrimLeft(bn, "0")
+			if len(tan) != len(tbn) {
+				return len(tan) - len(tbn)
+			}
+			if c := strings.Compare(tan, tbn); c != 0 {
+				return c
+			}
+			if len(an) != len(bn) {
+				return len(an) - len(bn)
+			}
+
+			a, b = ar, br
+			continue
+		}
+
+		if c := strings.Compare(strings.ToLower(a[:1]), strings.ToLower(b[:1])); c != 0 {
+			return c
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) - len(b)
+}
+
+func splitDigits(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
@@ -0,0 +1,147 @@
+package cbz_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"forge.capytal.company/capytalcode/project-comicverse/internals/cbz"
+	"forge.capytal.company/loreddev/x/tinyssert"
+)
+
+func TestNewReader(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	b := newArchive(t, map[string]string{
+		"comic/page10.jpg":           "10",
+		"comic/page2.png":            "2",
+		"comic/page1.JPG":            "1",
+		"comic/notes.txt":            "notes",
+		"__MACOSX/comic/._page1.jpg": "metadata",
+		"ComicInfo.xml": `<?xml version="1.0"?>
+			<ComicInfo>
+				<Title>The Beginning</Title>
+				<Series>Comicverse</Series>
+				<Number>1</Number>
+				<Writer>Jane Doe</Writer>
+				<Summary>It all starts here.</Summary>
+			</ComicInfo>`,
+	})
+
+	r, err := cbz.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotNil(r.Info)
+	assert.Equal("The Beginning", r.Info.Title)
+	assert.Equal("Comicverse", r.Info.Series)
+	assert.Equal("1", r.Info.Number)
+	assert.Equal("Jane Doe", r.Info.Writer)
+	assert.Equal("It all starts here.", r.Info.Summary)
+
+	assert.Equal(3, len(r.Pages))
+	assert.Equal("comic/page1.JPG", r.Pages[0].Name)
+	assert.Equal("image/jpeg", r.Pages[0].ContentType)
+	assert.Equal("comic/page2.png", r.Pages[1].Name)
+	assert.Equal("image/png", r.Pages[1].ContentType)
+	assert.Equal("comic/page10.jpg", r.Pages[2].Name)
+	assert.Equal(uint64(2), r.Pages[2].Size)
+
+	f, err := r.Pages[2].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	c, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal("10", string(c))
+}
+
+func TestNewReaderNoPages(t *testing.T) {
+	b := newArchive(t, map[string]string{"notes.txt": "notes"})
+
+	_, err := cbz.NewReader(bytes.NewReader(b), int64(len(b)))
+	if !errors.Is(err, cbz.ErrNoPages) {
+		t.Errorf("expected error %q, got %v", cbz.ErrNoPages, err)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	var b bytes.Buffer
+	w, err := cbz.NewWriter(&b, &cbz.ComicInfo{
+		Title:     "The Beginning",
+		Writer:    "Jane Doe, John Doe",
+		PageCount: 11,
+		Manga:     cbz.MangaYesAndRightToLeft,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range 11 {
+		if err := w.AddPage("image/jpeg", strings.NewReader(strconv.Itoa(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.AddPage("text/plain", strings.NewReader("")); err == nil {
+		t.Error("expected error when adding page with unsupported content type")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := cbz.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotNil(r.Info)
+	assert.Equal("The Beginning", r.Info.Title)
+	assert.Equal("Jane Doe, John Doe", r.Info.Writer)
+	assert.Equal(11, r.Info.PageCount)
+	assert.Equal(cbz.MangaYesAndRightToLeft, r.Info.Manga)
+
+	assert.Equal(11, len(r.Pages))
+	for i, p := range r.Pages {
+		assert.Equal(fmt.Sprintf("%04d.jpg", i+1), p.Name)
+
+		f, err := p.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := io.ReadAll(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = f.Close()
+		assert.Equal(strconv.Itoa(i), string(c))
+	}
+}
+
+func newArchive(t *testing.T, files map[string]string) []byte {
+	var b bytes.Buffer
+	z := zip.NewWriter(&b)
+	for n, c := range files {
+		w, err := z.Create(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(w, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
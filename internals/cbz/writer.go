@@ -0,0 +1,77 @@
+package cbz
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// Writer streams a CBZ archive into an io.Writer, pages are written directly to
+// the output as they are added, so they don't need to be buffered in memory.
+type Writer struct {
+	z     *zip.Writer
+	pages int
+}
+
+// NewWriter creates a new archive in w and writes info to it as ComicInfo.xml.
+// If info is nil, the archive will not have a ComicInfo.xml file.
+func NewWriter(w io.Writer, info *ComicInfo) (*Writer, error) {
+	z := zip.NewWriter(w)
+
+	if info != nil {
+		f, err := z.CreateHeader(&zip.FileHeader{Name: ComicInfoFile, Method: zip.Deflate})
+		if err != nil {
+			return nil, fmt.Errorf("cbz: failed to create %s: %w", ComicInfoFile, err)
+		}
+
+		if _, err := io.WriteString(f, xml.Header); err != nil {
+			return nil, fmt.Errorf("cbz: failed to write %s: %w", ComicInfoFile, err)
+		}
+
+		e := xml.NewEncoder(f)
+		e.Indent("", "  ")
+		if err := e.Encode(info); err != nil {
+			return nil, fmt.Errorf("cbz: failed to write %s: %w", ComicInfoFile, err)
+		}
+	}
+
+	return &Writer{z: z}, nil
+}
+
+// AddPage copies the image in r as the next page of the archive. Pages must be added
+// in reading order, even if the comic is read from right to left.
+func (w *Writer) AddPage(contentType string, r io.Reader) error {
+	var ext string
+	for e, t := range ContentTypes {
+		if t == contentType && (ext == "" || len(e) < len(ext)) {
+			ext = e
+		}
+	}
+	if ext == "" {
+		return fmt.Errorf("cbz: unsupported page content type %q", contentType)
+	}
+
+	w.pages++
+	name := fmt.Sprintf("%04d%s", w.pages, ext)
+
+	// Images are already compressed, so there's no reason to compress them again.
+	f, err := w.z.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+	if err != nil {
+		return fmt.Errorf("cbz: failed to create page %q: %w", name, err)
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		return fmt.Errorf("cbz: failed to write page %q: %w", name, err)
+	}
+
+	return nil
+}
+
+// Close finishes the archive. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if err := w.z.Close(); err != nil {
+		return fmt.Errorf("cbz: failed to close zip archive: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,514 @@
+package ast
+
+import (
+	"encoding/xml"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
+)
+
+// Trigger is the event which starts an [Animation].
+type Trigger string
+
+const (
+	// TriggerEnter starts the animation when its target comes into view.
+	TriggerEnter Trigger = "enter"
+	// TriggerTap starts the animation when its target is tapped or clicked.
+	TriggerTap Trigger = "tap"
+)
+
+func (Trigger) Values() []Trigger {
+	return []Trigger{TriggerEnter, TriggerTap}
+}
+
+// Easing is the timing function used to interpolate between two keyframes,
+// following the CSS keywords of the same name.
+type Easing string
+
+const (
+	EasingLinear    Easing = "linear"
+	EasingEase      Easing = "ease"
+	EasingEaseIn    Easing = "ease-in"
+	EasingEaseOut   Easing = "ease-out"
+	EasingEaseInOut Easing = "ease-in-out"
+)
+
+// Control points of the cubic Bézier curve of each easing, as defined by CSS.
+var easingCurves = map[Easing][4]float64{
+	EasingEase:      {0.25, 0.1, 0.25, 1},
+	EasingEaseIn:    {0.42, 0, 1, 1},
+	EasingEaseOut:   {0, 0, 0.58, 1},
+	EasingEaseInOut: {0.42, 0, 0.58, 1},
+}
+
+func (Easing) Values() []Easing {
+	return []Easing{EasingLinear, EasingEase, EasingEaseIn, EasingEaseOut, EasingEaseInOut}
+}
+
+// Apply returns the eased progress of p, from 0 to 1. Unknown easings are linear.
+func (e Easing) Apply(p float64) float64 {
+	c, ok := easingCurves[e]
+	if !ok || p <= 0 || p >= 1 {
+		return p
+	}
+
+	bezier := func(a, b, t float64) float64 {
+		return 3*a*t*(1-t)*(1-t) + 3*b*t*t*(1-t) + t*t*t
+	}
+
+	// The curve's x is monotonic, so the t for which x(t) = p is found by bisection.
+	lo, hi := 0.0, 1.0
+	t := p
+	for range 50 {
+		x := bezier(c[0], c[2], t)
+		if math.Abs(x-p) < 1e-7 {
+			break
+		}
+		if x < p {
+			lo = t
+		} else {
+			hi = t
+		}
+		t = (lo + hi) / 2
+	}
+
+	return bezier(c[1], c[3], t)
+}
+
+// Transform is the state of the target of an [Animation] at a point in time.
+type Transform struct {
+	// X and Y are the offset of the target from its place, as percentages of the
+	// page.
+	X, Y float64
+	// Scale is the size of the target relative to its original size.
+	Scale float64
+	// Opacity is how visible the target is, from 0 (invisible) to 1.
+	Opacity float64
+}
+
+// IdentityTransform is the state of a target which is not animated.
+var IdentityTransform = Transform{Scale: 1, Opacity: 1}
+
+// Animation animates its parent, which must be a [Panel] or a [Layer], through
+// its [Keyframe] children.
+//
+// Following CSS, properties which are not set by the first or last keyframes are
+// animated from and to their value in [IdentityTransform], and the target keeps
+// the state of the first keyframe before the animation starts and of the last one
+// after it ends.
+type Animation struct {
+	trigger    Trigger
+	duration   time.Duration
+	delay      time.Duration
+	iterations int
+	easing     Easing
+
+	BaseNode
+}
+
+var KindAnimation = NewNodeKind("animation", &Animation{})
+
+func (e Animation) Kind() NodeKind {
+	return KindAnimation
+}
+
+// Target returns the panel or layer animated, or nil if the parent of the
+// animation is neither.
+func (e *Animation) Target() Node {
+	switch p := e.Parent().(type) {
+	case *Panel, *Layer:
+		return p
+	default:
+		return nil
+	}
+}
+
+// Trigger returns the event which starts the animation. It is [TriggerEnter] if
+// not set.
+func (e Animation) Trigger() Trigger {
+	if e.trigger == "" {
+		return TriggerEnter
+	}
+	return e.trigger
+}
+
+func (e *Animation) SetTrigger(t Trigger) {
+	e.trigger = t
+}
+
+// Duration returns how long one iteration of the animation takes.
+func (e Animation) Duration() time.Duration {
+	return e.duration
+}
+
+func (e *Animation) SetDuration(d time.Duration) {
+	e.duration = d
+}
+
+// Delay returns how long after being triggered the animation starts.
+func (e Animation) Delay() time.Duration {
+	return e.delay
+}
+
+func (e *Animation) SetDelay(d time.Duration) {
+	e.delay = d
+}
+
+// InfiniteIterations is the number of iterations of an animation which repeats
+// forever.
+const InfiniteIterations = -1
+
+// Iterations returns how many times the animation is played. It is 1 if not set,
+// and [InfiniteIterations] if it repeats forever.
+func (e Animation) Iterations() int {
+	if e.iterations == 0 {
+		return 1
+	}
+	return e.iterations
+}
+
+func (e *Animation) SetIterations(i int) {
+	e.iterations = i
+}
+
+// Easing returns the timing function used between keyframes which don't set
+// their own. It is [EasingLinear] if not set.
+func (e Animation) Easing() Easing {
+	if e.easing == "" {
+		return EasingLinear
+	}
+	return e.easing
+}
+
+func (e *Animation) SetEasing(v Easing) {
+	e.easing = v
+}
+
+// Keyframes returns the keyframes of the animation, sorted by their offset.
+func (e *Animation) Keyframes() []*Keyframe {
+	ks := []*Keyframe{}
+	for c := e.FirstChild(); c != nil; c = c.NextSibling() {
+		if k, ok := c.(*Keyframe); ok {
+			ks = append(ks, k)
+		}
+	}
+	slices.SortStableFunc(ks, func(a, b *Keyframe) int {
+		switch {
+		case a.offset < b.offset:
+			return -1
+		case a.offset > b.offset:
+			return 1
+		default:
+			return 0
+		}
+	})
+	return ks
+}
+
+// Progress returns how far into its current iteration the animation is, from 0
+// to 1, t after being triggered.
+func (e *Animation) Progress(t time.Duration) float64 {
+	t -= e.delay
+	if t <= 0 {
+		return 0
+	}
+	if e.duration <= 0 {
+		return 1
+	}
+
+	if i := e.Iterations(); i != InfiniteIterations && t >= e.duration*time.Duration(i) {
+		return 1
+	}
+
+	return float64(t%e.duration) / float64(e.duration)
+}
+
+// At returns the state of the target t after the animation was triggered.
+func (e *Animation) At(t time.Duration) Transform {
+	p := e.Progress(t) * 100
+	ks := e.Keyframes()
+
+	s := IdentityTransform
+	s.X = e.interpolate(ks, p, IdentityTransform.X, func(k *Keyframe) (float64, bool) {
+		v, ok := k.Translate()
+		return v.X, ok
+	})
+	s.Y = e.interpolate(ks, p, IdentityTransform.Y, func(k *Keyframe) (float64, bool) {
+		v, ok := k.Translate()
+		return v.Y, ok
+	})
+	s.Scale = e.interpolate(ks, p, IdentityTransform.Scale, (*Keyframe).Scale)
+	s.Opacity = e.interpolate(ks, p, IdentityTransform.Opacity, (*Keyframe).Opacity)
+
+	return s
+}
+
+// interpolate returns the value of a property at the offset p, between the
+// keyframes before and after it which set the property.
+func (e *Animation) interpolate(ks []*Keyframe, p, initial float64, value func(k *Keyframe) (float64, bool)) float64 {
+	fromOffset, from, easing := 0.0, initial, e.Easing()
+	toOffset, to := 100.0, initial
+
+	for _, k := range ks {
+		v, ok := value(k)
+		if !ok {
+			continue
+		}
+		if k.offset <= p {
+			fromOffset, from, easing = k.offset, v, k.Easing()
+			if easing == "" {
+				easing = e.Easing()
+			}
+			continue
+		}
+		toOffset, to = k.offset, v
+		break
+	}
+
+	if toOffset <= fromOffset {
+		return from
+	}
+
+	progress := easing.Apply((p - fromOffset) / (toOffset - fromOffset))
+	return from + (to-from)*progress
+}
+
+func (e *Animation) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	return marshalNode(enc, e, "div")
+}
+
+func (e *Animation) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return unmarshalNode(d, e, start)
+}
+
+var (
+	animationTriggerAttrName    = xml.Name{Local: "data-ipub-trigger"}
+	animationDurationAttrName   = xml.Name{Local: "data-ipub-duration"}
+	animationDelayAttrName      = xml.Name{Local: "data-ipub-delay"}
+	animationIterationsAttrName = xml.Name{Local: "data-ipub-iterations"}
+	easingAttrName              = xml.Name{Local: "data-ipub-easing"}
+)
+
+func (e Animation) MarshalXMLAttrs() ([]xml.Attr, error) {
+	attrs := []xml.Attr{}
+	if e.trigger != "" {
+		attrs = append(attrs, xml.Attr{Name: animationTriggerAttrName, Value: string(e.trigger)})
+	}
+	attrs = append(attrs, xml.Attr{Name: animationDurationAttrName, Value: e.duration.String()})
+	if e.delay != 0 {
+		attrs = append(attrs, xml.Attr{Name: animationDelayAttrName, Value: e.delay.String()})
+	}
+	if e.iterations == InfiniteIterations {
+		attrs = append(attrs, xml.Attr{Name: animationIterationsAttrName, Value: "infinite"})
+	} else if e.iterations > 1 {
+		attrs = append(attrs, xml.Attr{Name: animationIterationsAttrName, Value: strconv.Itoa(e.iterations)})
+	}
+	if e.easing != "" {
+		attrs = append(attrs, xml.Attr{Name: easingAttrName, Value: string(e.easing)})
+	}
+	return attrs, nil
+}
+
+func (e *Animation) UnmarshalXMLAttrs(attrs []xml.Attr) error {
+	for _, a := range attrs {
+		switch a.Name {
+		case animationTriggerAttrName:
+			var v attr.Enum[Trigger]
+			if err := v.UnmarshalXMLAttr(a); err != nil {
+				return err
+			}
+			e.trigger = v.Value
+
+		case animationDurationAttrName, animationDelayAttrName:
+			var d attr.Duration
+			if err := d.UnmarshalXMLAttr(a); err != nil {
+				return err
+			}
+			if a.Name == animationDurationAttrName {
+				e.duration = time.Duration(d)
+			} else {
+				e.delay = time.Duration(d)
+			}
+
+		case animationIterationsAttrName:
+			if a.Value == "infinite" {
+				e.iterations = InfiniteIterations
+				continue
+			}
+			i, err := strconv.Atoi(a.Value)
+			if err != nil || i < 1 {
+				return attr.ErrInvalidValue{Attr: a, Message: `must be a positive integer or "infinite"`}
+			}
+			e.iterations = i
+
+		case easingAttrName:
+			v, err := unmarshalEasing(a)
+			if err != nil {
+				return err
+			}
+			e.easing = v
+		}
+	}
+	return nil
+}
+
+func unmarshalEasing(a xml.Attr) (Easing, error) {
+	var v attr.Enum[Easing]
+	if err := v.UnmarshalXMLAttr(a); err != nil {
+		return "", err
+	}
+	return v.Value, nil
+}
+
+// Keyframe is the state of the target of its parent [Animation] at an offset of
+// the animation's duration. Properties which are not set are interpolated from
+// the keyframes around it.
+type Keyframe struct {
+	offset float64
+
+	translate    Point
+	translateSet bool
+	scale        float64
+	scaleSet     bool
+	opacity      float64
+	opacitySet   bool
+
+	easing Easing
+
+	BaseNode
+}
+
+var KindKeyframe = NewNodeKind("keyframe", &Keyframe{})
+
+func (e Keyframe) Kind() NodeKind {
+	return KindKeyframe
+}
+
+// Offset returns the point of the animation the keyframe is at, as a percentage
+// of its duration.
+func (e Keyframe) Offset() float64 {
+	return e.offset
+}
+
+func (e *Keyframe) SetOffset(v float64) {
+	e.offset = v
+}
+
+// Translate returns the offset of the target from its place, as percentages of
+// the page, and whether it is set.
+func (e Keyframe) Translate() (Point, bool) {
+	return e.translate, e.translateSet
+}
+
+func (e *Keyframe) SetTranslate(p Point) {
+	e.translate = p
+	e.translateSet = true
+}
+
+// Scale returns the size of the target relative to its original size, and
+// whether it is set.
+func (e Keyframe) Scale() (float64, bool) {
+	return e.scale, e.scaleSet
+}
+
+func (e *Keyframe) SetScale(v float64) {
+	e.scale = v
+	e.scaleSet = true
+}
+
+// Opacity returns how visible the target is, from 0 to 1, and whether it is set.
+func (e Keyframe) Opacity() (float64, bool) {
+	return e.opacity, e.opacitySet
+}
+
+func (e *Keyframe) SetOpacity(v float64) {
+	e.opacity = v
+	e.opacitySet = true
+}
+
+// Easing returns the timing function used from this keyframe to the next one, or
+// an empty string to use the easing of the animation.
+func (e Keyframe) Easing() Easing {
+	return e.easing
+}
+
+func (e *Keyframe) SetEasing(v Easing) {
+	e.easing = v
+}
+
+func (e *Keyframe) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	return marshalNode(enc, e, "div")
+}
+
+func (e *Keyframe) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return unmarshalNode(d, e, start)
+}
+
+var (
+	keyframeOffsetAttrName    = xml.Name{Local: "data-ipub-offset"}
+	keyframeTranslateAttrName = xml.Name{Local: "data-ipub-translate"}
+	keyframeScaleAttrName     = xml.Name{Local: "data-ipub-scale"}
+	keyframeOpacityAttrName   = xml.Name{Local: "data-ipub-opacity"}
+)
+
+func (e Keyframe) MarshalXMLAttrs() ([]xml.Attr, error) {
+	attrs := []xml.Attr{{Name: keyframeOffsetAttrName, Value: formatFloat(e.offset)}}
+	if e.translateSet {
+		attrs = append(attrs, xml.Attr{Name: keyframeTranslateAttrName, Value: formatPoint(e.translate)})
+	}
+	if e.scaleSet {
+		attrs = append(attrs, xml.Attr{Name: keyframeScaleAttrName, Value: formatFloat(e.scale)})
+	}
+	if e.opacitySet {
+		attrs = append(attrs, xml.Attr{Name: keyframeOpacityAttrName, Value: formatFloat(e.opacity)})
+	}
+	if e.easing != "" {
+		attrs = append(attrs, xml.Attr{Name: easingAttrName, Value: string(e.easing)})
+	}
+	return attrs, nil
+}
+
+func (e *Keyframe) UnmarshalXMLAttrs(attrs []xml.Attr) error {
+	for _, a := range attrs {
+		switch a.Name {
+		case keyframeOffsetAttrName:
+			v, err := parseFloat(a.Value)
+			if err != nil || v < 0 || v > 100 {
+				return attr.ErrInvalidValue{Attr: a, Message: "must be a percentage between 0 and 100"}
+			}
+			e.offset = v
+
+		case keyframeTranslateAttrName:
+			p, err := parsePoint(a.Value)
+			if err != nil {
+				return attr.ErrInvalidValue{Attr: a, Message: err.Error()}
+			}
+			e.SetTranslate(p)
+
+		case keyframeScaleAttrName:
+			v, err := parseFloat(a.Value)
+			if err != nil || v < 0 {
+				return attr.ErrInvalidValue{Attr: a, Message: "must be a positive number"}
+			}
+			e.SetScale(v)
+
+		case keyframeOpacityAttrName:
+			v, err := parseFloat(a.Value)
+			if err != nil || v < 0 || v > 1 {
+				return attr.ErrInvalidValue{Attr: a, Message: "must be a number between 0 and 1"}
+			}
+			e.SetOpacity(v)
+
+		case easingAttrName:
+			v, err := unmarshalEasing(a)
+			if err != nil {
+				return err
+			}
+			e.easing = v
+		}
+	}
+	return nil
+}
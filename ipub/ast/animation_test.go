@@ -0,0 +1,100 @@
+package ast_test
+
+import (
+	"encoding/xml"
+	"math"
+	"testing"
+	"time"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/loreddev/x/tinyssert"
+)
+
+func TestAnimation(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	s := []byte(`
+	<div data-ipub-element="panel" data-ipub-order="1" data-ipub-bounds="0 0 100 50">
+		<div data-ipub-element="animation" data-ipub-duration="1s" data-ipub-delay="500ms">
+			<div data-ipub-element="keyframe" data-ipub-offset="100" data-ipub-translate="0,0" data-ipub-opacity="1"></div>
+			<div data-ipub-element="keyframe" data-ipub-offset="0" data-ipub-translate="-10,0" data-ipub-opacity="0"></div>
+		</div>
+	</div>
+	`)
+
+	var p ast.Panel
+	if err := xml.Unmarshal(s, &p); err != nil {
+		t.Fatal(err)
+	}
+
+	a, ok := ast.FindFirst(&p, ast.KindAnimation).(*ast.Animation)
+	assert.Equal(true, ok)
+	assert.Equal(ast.Node(&p), a.Target())
+	assert.Equal(ast.TriggerEnter, a.Trigger())
+	assert.Equal(1, a.Iterations())
+	assert.Equal(ast.EasingLinear, a.Easing())
+
+	ks := a.Keyframes()
+	assert.Equal(2, len(ks))
+	assert.Equal(float64(0), ks[0].Offset())
+
+	assert.Equal(ast.Transform{X: -10, Y: 0, Scale: 1, Opacity: 0}, a.At(0))
+	assert.Equal(ast.Transform{X: -10, Y: 0, Scale: 1, Opacity: 0}, a.At(500*time.Millisecond))
+	assert.Equal(ast.Transform{X: -5, Y: 0, Scale: 1, Opacity: 0.5}, a.At(time.Second))
+	assert.Equal(ast.IdentityTransform, a.At(1500*time.Millisecond))
+	assert.Equal(ast.IdentityTransform, a.At(time.Hour))
+}
+
+func TestAnimationImplicitKeyframes(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	a := &ast.Animation{}
+	a.SetDuration(time.Second)
+	a.SetIterations(ast.InfiniteIterations)
+
+	k := &ast.Keyframe{}
+	k.SetOffset(50)
+	k.SetScale(2)
+	a.AppendChild(a, k)
+
+	assert.Equal(float64(1), a.At(0).Scale)
+	assert.Equal(1.5, a.At(250*time.Millisecond).Scale)
+	assert.Equal(float64(2), a.At(500*time.Millisecond).Scale)
+	assert.Equal(1.5, a.At(750*time.Millisecond).Scale)
+	assert.Equal(1.5, a.At(10*time.Second+250*time.Millisecond).Scale)
+
+	assert.Equal(nil, a.Target())
+}
+
+func TestEasing(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	assert.Equal(0.25, ast.EasingLinear.Apply(0.25))
+	assert.Equal(float64(0), ast.EasingEaseIn.Apply(0))
+	assert.Equal(float64(1), ast.EasingEaseIn.Apply(1))
+
+	assert.Equal(true, math.Abs(ast.EasingEaseInOut.Apply(0.5)-0.5) < 1e-6)
+	assert.Equal(true, ast.EasingEaseIn.Apply(0.5) < 0.5)
+	assert.Equal(true, ast.EasingEaseOut.Apply(0.5) > 0.5)
+}
+
+func TestAnimationInvalid(t *testing.T) {
+	for _, s := range []string{
+		`<div data-ipub-element="animation" data-ipub-trigger="hover"></div>`,
+		`<div data-ipub-element="animation" data-ipub-duration="1"></div>`,
+		`<div data-ipub-element="animation" data-ipub-delay="-1s"></div>`,
+		`<div data-ipub-element="animation" data-ipub-iterations="0"></div>`,
+		`<div data-ipub-element="animation" data-ipub-easing="bounce"></div>`,
+		`<div data-ipub-element="animation"><div data-ipub-element="keyframe" data-ipub-offset="150"></div></div>`,
+		`<div data-ipub-element="animation"><div data-ipub-element="keyframe" data-ipub-opacity="2"></div></div>`,
+		`<div data-ipub-element="animation"><div data-ipub-element="keyframe" data-ipub-offset="NaN"></div></div>`,
+		`<div data-ipub-element="animation"><div data-ipub-element="keyframe" data-ipub-opacity="nan"></div></div>`,
+		`<div data-ipub-element="animation"><div data-ipub-element="keyframe" data-ipub-scale="+Inf"></div></div>`,
+		`<div data-ipub-element="animation"><div data-ipub-element="keyframe" data-ipub-translate="NaN,0"></div></div>`,
+		`<div data-ipub-element="animation"><div data-ipub-element="keyframe" data-ipub-translate="0,-Inf"></div></div>`,
+	} {
+		if err := xml.Unmarshal([]byte(s), &ast.Animation{}); err == nil {
+			t.Errorf("expected error unmarshalling %s", s)
+		}
+	}
+}
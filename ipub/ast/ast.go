@@ -30,6 +30,34 @@ type Node interface {
 
 	InsertBefore(self, v1, insertee Node)
 	InsertAfter(self, v1, insertee Node)
+
+	Position() Position
+	SetPosition(Position)
+
+	// ID returns the stable identifier of the node, or an empty string if it
+	// doesn't have one. IDs are unique inside a section, see [Ref].
+	ID() string
+	SetID(string)
+
+	// Classes returns the style classes of the node, which are used by the rules
+	// of the stylesheets of its section to style it, see [Section.Stylesheets].
+	Classes() []string
+	SetClasses([]string)
+}
+
+// Position is the location of a node in the document it was decoded from. Nodes
+// created in code have the zero value, which is not valid.
+type Position struct {
+	Line   int
+	Column int
+}
+
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
 }
 
 type BaseNode struct {
@@ -39,6 +67,33 @@ type BaseNode struct {
 	fisrtChild Node
 	lastChild  Node
 	childCount uint
+	pos        Position
+	id         string
+	classes    []string
+}
+
+func (e *BaseNode) Position() Position {
+	return e.pos
+}
+
+func (e *BaseNode) SetPosition(p Position) {
+	e.pos = p
+}
+
+func (e *BaseNode) ID() string {
+	return e.id
+}
+
+func (e *BaseNode) SetID(id string) {
+	e.id = id
+}
+
+func (e *BaseNode) Classes() []string {
+	return e.classes
+}
+
+func (e *BaseNode) SetClasses(cs []string) {
+	e.classes = cs
 }
 
 func (e *BaseNode) NextSibling() Node {
@@ -104,7 +159,7 @@ func (e *BaseNode) RemoveChild(self, v Node) {
 		return
 	}
 
-	if e.childCount <= 0 {
+	if e.childCount > 0 {
 		e.childCount--
 	}
 
@@ -118,7 +173,7 @@ func (e *BaseNode) RemoveChild(self, v Node) {
 	}
 
 	if next != nil {
-		next.SetNextSibling(prev)
+		next.SetPreviousSibling(prev)
 	} else {
 		e.lastChild = prev
 	}
@@ -151,7 +206,6 @@ func (e *BaseNode) InsertAfter(self, v1, insertee Node) {
 }
 
 func (e *BaseNode) InsertBefore(self, v1, insertee Node) {
-	e.childCount++
 	if v1 == nil {
 		e.AppendChild(self, insertee)
 		return
@@ -160,6 +214,7 @@ func (e *BaseNode) InsertBefore(self, v1, insertee Node) {
 	ensureIsolated(insertee)
 
 	if v1.Parent() == self {
+		e.childCount++
 		c := v1
 		prev := c.PreviousSibling()
 		if prev != nil {
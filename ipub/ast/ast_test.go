@@ -1,12 +1,15 @@
 package ast_test
 
 import (
+	"bytes"
 	_ "embed"
 	"encoding/xml"
+	"errors"
 	"io"
 	"testing"
 
 	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
 	"forge.capytal.company/loreddev/x/tinyssert"
 )
 
@@ -21,9 +24,8 @@ func TestMarshal(t *testing.T) {
 	c.AppendChild(c, i)
 	b.AppendChild(b, c)
 
-	s := ast.Section{
-		Body: b,
-	}
+	s := ast.Section{}
+	s.SetBody(b)
 	by, err := xml.Marshal(s)
 
 	if err != nil && err != io.EOF {
@@ -31,7 +33,7 @@ func TestMarshal(t *testing.T) {
 		t.FailNow()
 	}
 
-	// t.Logf("%#v", s.Body)
+	// t.Logf("%#v", s.Body())
 	//
 	// t.Logf("%#v", f)
 
@@ -59,7 +61,7 @@ func TestUnmarshal(t *testing.T) {
 		t.FailNow()
 	}
 
-	body := data.Body
+	body := data.Body()
 	assert.Equal(ast.KindBody, body.Kind())
 
 	t.Logf("%#v", body)
@@ -75,3 +77,156 @@ func TestUnmarshal(t *testing.T) {
 
 	t.Logf("%#v", img)
 }
+
+func TestGolden(t *testing.T) {
+	var s ast.Section
+	if err := xml.Unmarshal(test, &s); err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	e := xml.NewEncoder(&b)
+	e.Indent("", "\t")
+	if err := e.Encode(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := string(bytes.TrimSpace(test)); b.String() != expected {
+		t.Errorf("round-trip of test.xml does not match golden file\nexpected:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
+
+func TestMarshalNodes(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	i := &ast.Image{}
+	i.SetSource("images/001.png")
+
+	c := &ast.Content{}
+	c.AppendChild(c, i)
+
+	b, err := xml.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(
+		`<section data-ipub-element="content"><img data-ipub-element="image" src="images/001.png"></img></section>`,
+		string(b),
+	)
+
+	var uc ast.Content
+	if err := xml.Unmarshal(b, &uc); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(uint(1), uc.ChildCount())
+	assert.Equal("images/001.png", uc.FirstChild().(*ast.Image).Source())
+	assert.Equal(ast.Node(&uc), uc.FirstChild().Parent())
+}
+
+func TestUnmarshalInvalidKind(t *testing.T) {
+	var b ast.Body
+	if err := xml.Unmarshal([]byte(`<body data-ipub-element="body"><div></div></body>`), &b); err == nil {
+		t.Error("expected error for missing kind, got nil")
+	}
+
+	// Unknown kinds are kept as unknown nodes, see TestUnknown.
+	b = ast.Body{}
+	if err := xml.Unmarshal([]byte(`<body data-ipub-element="body"><div data-ipub-element="unknown"></div></body>`), &b); err != nil {
+		t.Fatal(err)
+	}
+
+	var aerr attr.ErrInvalidValue
+	if err := ast.CheckKinds(&b); !errors.As(err, &aerr) {
+		t.Errorf("expected error to be attr.ErrInvalidValue, got %T", err)
+	}
+}
+
+func TestRemoveChild(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	c := &ast.Content{}
+	a, b, d := &ast.Image{}, &ast.Image{}, &ast.Image{}
+	c.AppendChild(c, a)
+	c.AppendChild(c, b)
+	c.AppendChild(c, d)
+
+	c.RemoveChild(c, b)
+	assert.Equal(uint(2), c.ChildCount())
+	assert.Equal(ast.Node(d), a.NextSibling())
+	assert.Equal(ast.Node(a), d.PreviousSibling())
+	assert.Equal(nil, b.Parent())
+	assert.Equal(nil, b.NextSibling())
+	assert.Equal(nil, b.PreviousSibling())
+
+	c.RemoveChild(c, a)
+	assert.Equal(uint(1), c.ChildCount())
+	assert.Equal(ast.Node(d), c.FirstChild())
+	assert.Equal(nil, d.PreviousSibling())
+
+	c.RemoveChild(c, d)
+	assert.Equal(uint(0), c.ChildCount())
+	assert.Equal(false, c.HasChildren())
+	assert.Equal(nil, c.LastChild())
+
+	// Nodes which are not children are ignored.
+	c.RemoveChild(c, d)
+	assert.Equal(uint(0), c.ChildCount())
+}
+
+func TestInsertBefore(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	c := &ast.Content{}
+	a, b, d := &ast.Image{}, &ast.Image{}, &ast.Image{}
+	c.InsertBefore(c, nil, d)
+	assert.Equal(uint(1), c.ChildCount())
+
+	c.InsertBefore(c, d, a)
+	assert.Equal(uint(2), c.ChildCount())
+	assert.Equal(ast.Node(a), c.FirstChild())
+
+	c.InsertAfter(c, a, b)
+	assert.Equal(uint(3), c.ChildCount())
+	assert.Equal(ast.Node(b), a.NextSibling())
+	assert.Equal(ast.Node(b), d.PreviousSibling())
+	assert.Equal(ast.Node(d), c.LastChild())
+
+	// Moving a child keeps the count of its parent.
+	c.InsertBefore(c, a, d)
+	assert.Equal(uint(3), c.ChildCount())
+	assert.Equal(ast.Node(d), c.FirstChild())
+	assert.Equal(ast.Node(b), c.LastChild())
+	assert.Equal(nil, b.NextSibling())
+
+	e := &ast.Image{}
+	c.ReplaceChild(c, a, e)
+	assert.Equal(uint(3), c.ChildCount())
+	assert.Equal(ast.Node(e), d.NextSibling())
+	assert.Equal(ast.Node(e), b.PreviousSibling())
+	assert.Equal(nil, a.Parent())
+
+	count := uint(0)
+	for n := c.FirstChild(); n != nil; n = n.NextSibling() {
+		count++
+	}
+	assert.Equal(c.ChildCount(), count)
+}
+
+func TestImageInvalid(t *testing.T) {
+	tests := map[string]string{
+		"src":             `src="javascript:alert(1)"`,
+		"empty src":       `src=""`,
+		"description ref": `src="001.png" data-ipub-description-ref="data:text/html,hi"`,
+		"lang":            `src="001.png" xml:lang="pt BR"`,
+	}
+
+	for name, a := range tests {
+		t.Run(name, func(t *testing.T) {
+			var i ast.Image
+			err := xml.Unmarshal([]byte(`<img data-ipub-element="image" `+a+`/>`), &i)
+			if err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
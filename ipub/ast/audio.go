@@ -0,0 +1,194 @@
+package ast
+
+import (
+	"encoding/xml"
+	"strconv"
+	"time"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
+)
+
+// AudioClip holds the data shared by all audio nodes. It is embedded by each audio
+// kind and can't be used as a node by itself.
+type AudioClip struct {
+	src string
+
+	volume    float64
+	volumeSet bool
+
+	fadeIn  time.Duration
+	fadeOut time.Duration
+
+	BaseNode
+}
+
+// Source returns the href of the audio file, usually a resource of the package.
+func (e AudioClip) Source() string {
+	return e.src
+}
+
+func (e *AudioClip) SetSource(src string) {
+	e.src = src
+}
+
+// Volume returns the volume which the audio is played at, from 0 (muted) to 1
+// (the volume of the file). It is 1 if not set.
+func (e AudioClip) Volume() float64 {
+	if !e.volumeSet {
+		return 1
+	}
+	return e.volume
+}
+
+func (e *AudioClip) SetVolume(v float64) {
+	e.volume = v
+	e.volumeSet = true
+}
+
+// FadeIn returns how long the audio takes to go from silence to its volume when
+// it starts playing.
+func (e AudioClip) FadeIn() time.Duration {
+	return e.fadeIn
+}
+
+func (e *AudioClip) SetFadeIn(d time.Duration) {
+	e.fadeIn = d
+}
+
+// FadeOut returns how long the audio takes to go from its volume to silence when
+// it stops playing.
+func (e AudioClip) FadeOut() time.Duration {
+	return e.fadeOut
+}
+
+func (e *AudioClip) SetFadeOut(d time.Duration) {
+	e.fadeOut = d
+}
+
+var (
+	audioVolumeAttrName  = xml.Name{Local: "data-ipub-volume"}
+	audioFadeInAttrName  = xml.Name{Local: "data-ipub-fade-in"}
+	audioFadeOutAttrName = xml.Name{Local: "data-ipub-fade-out"}
+)
+
+func (e AudioClip) MarshalXMLAttrs() ([]xml.Attr, error) {
+	attrs := []xml.Attr{}
+	if e.src != "" {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "src"}, Value: e.src})
+	}
+	if e.volumeSet {
+		attrs = append(attrs, xml.Attr{Name: audioVolumeAttrName, Value: formatFloat(e.volume)})
+	}
+	if e.fadeIn != 0 {
+		attrs = append(attrs, xml.Attr{Name: audioFadeInAttrName, Value: e.fadeIn.String()})
+	}
+	if e.fadeOut != 0 {
+		attrs = append(attrs, xml.Attr{Name: audioFadeOutAttrName, Value: e.fadeOut.String()})
+	}
+	return attrs, nil
+}
+
+func (e *AudioClip) UnmarshalXMLAttrs(attrs []xml.Attr) error {
+	for _, a := range attrs {
+		switch a.Name {
+		case xml.Name{Local: "src"}:
+			var u attr.URL
+			if err := u.UnmarshalXMLAttr(a); err != nil {
+				return err
+			}
+			e.src = u.String()
+
+		case audioVolumeAttrName:
+			v, err := strconv.ParseFloat(a.Value, 64)
+			if err != nil || v < 0 || v > 1 {
+				return attr.ErrInvalidValue{Attr: a, Message: "must be a number between 0 and 1"}
+			}
+			e.SetVolume(v)
+
+		case audioFadeInAttrName, audioFadeOutAttrName:
+			var d attr.Duration
+			if err := d.UnmarshalXMLAttr(a); err != nil {
+				return err
+			}
+			if a.Name == audioFadeInAttrName {
+				e.fadeIn = time.Duration(d)
+			} else {
+				e.fadeOut = time.Duration(d)
+			}
+		}
+	}
+	return nil
+}
+
+// Soundtrack is background audio. Inside a [Body], it plays through the whole
+// section. Inside a [Content], it starts when the page comes into view, replacing
+// the soundtrack which was playing before.
+type Soundtrack struct {
+	loop bool
+
+	AudioClip
+}
+
+var KindSoundtrack = NewNodeKind("soundtrack", &Soundtrack{})
+
+func (e Soundtrack) Kind() NodeKind {
+	return KindSoundtrack
+}
+
+// Loop reports whether the soundtrack starts again when it ends.
+func (e Soundtrack) Loop() bool {
+	return e.loop
+}
+
+func (e *Soundtrack) SetLoop(v bool) {
+	e.loop = v
+}
+
+func (e *Soundtrack) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	return marshalNode(enc, e, "audio")
+}
+
+func (e *Soundtrack) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return unmarshalNode(d, e, start)
+}
+
+func (e Soundtrack) MarshalXMLAttrs() ([]xml.Attr, error) {
+	attrs, err := e.AudioClip.MarshalXMLAttrs()
+	if err != nil {
+		return nil, err
+	}
+	if e.loop {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "loop"}, Value: "loop"})
+	}
+	return attrs, nil
+}
+
+func (e *Soundtrack) UnmarshalXMLAttrs(attrs []xml.Attr) error {
+	for _, a := range attrs {
+		if a.Name == (xml.Name{Local: "loop"}) {
+			// Following HTML, the presence of the attribute means it is true.
+			e.loop = true
+		}
+	}
+	return e.AudioClip.UnmarshalXMLAttrs(attrs)
+}
+
+// Sound is a one-shot sound effect, played once when its parent [Panel] or
+// [Content] comes into view.
+type Sound struct {
+	AudioClip
+}
+
+var KindSound = NewNodeKind("sound", &Sound{})
+
+func (e Sound) Kind() NodeKind {
+	return KindSound
+}
+
+func (e *Sound) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	return marshalNode(enc, e, "audio")
+}
+
+func (e *Sound) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return unmarshalNode(d, e, start)
+}
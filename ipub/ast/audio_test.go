@@ -0,0 +1,64 @@
+package ast_test
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/loreddev/x/tinyssert"
+)
+
+func TestAudio(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	s := []byte(`
+	<body data-ipub-element="body">
+		<audio data-ipub-element="soundtrack" src="audio/rain.ogg" data-ipub-volume="0.5" data-ipub-fade-in="2s" loop=""></audio>
+		<section data-ipub-element="content">
+			<div data-ipub-element="panel" data-ipub-bounds="0 0 100 100">
+				<audio data-ipub-element="sound" src="audio/thunder.ogg" data-ipub-fade-out="1.5s"></audio>
+			</div>
+		</section>
+	</body>
+	`)
+
+	var b ast.Body
+	if err := xml.Unmarshal(s, &b); err != nil {
+		t.Fatal(err)
+	}
+
+	st := ast.FindFirst(&b, ast.KindSoundtrack).(*ast.Soundtrack)
+	assert.Equal("audio/rain.ogg", st.Source())
+	assert.Equal(0.5, st.Volume())
+	assert.Equal(2*time.Second, st.FadeIn())
+	assert.Equal(time.Duration(0), st.FadeOut())
+	assert.Equal(true, st.Loop())
+
+	sfx := ast.FindFirst(&b, ast.KindSound).(*ast.Sound)
+	assert.Equal("audio/thunder.ogg", sfx.Source())
+	assert.Equal(1.0, sfx.Volume())
+	assert.Equal(1500*time.Millisecond, sfx.FadeOut())
+	assert.Equal(ast.KindPanel, sfx.Parent().Kind())
+}
+
+func TestAudioInvalid(t *testing.T) {
+	tests := map[string]string{
+		"volume":          `src="a.ogg" data-ipub-volume="1.5"`,
+		"fade in":         `src="a.ogg" data-ipub-fade-in="fast"`,
+		"fade out":        `src="a.ogg" data-ipub-fade-out="-1s"`,
+		"empty source":    `src=""`,
+		"unsafe source":   `src="javascript:alert(1)"`,
+		"absolute source": `src="/a.ogg"`,
+	}
+
+	for name, a := range tests {
+		t.Run(name, func(t *testing.T) {
+			var s ast.Sound
+			err := xml.Unmarshal([]byte(`<audio data-ipub-element="sound" `+a+`></audio>`), &s)
+			if err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
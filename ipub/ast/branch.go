@@ -0,0 +1,379 @@
+package ast
+
+import (
+	"encoding/xml"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
+)
+
+// State holds the values of the variables of a branching story while it is read.
+// Variables which were never set are zero.
+type State map[string]int
+
+// Key returns a string which is equal for states with the same values, so states
+// can be compared and used as map keys.
+func (s State) Key() string {
+	ks := slices.Sorted(maps.Keys(s))
+	parts := make([]string, 0, len(ks))
+	for _, k := range ks {
+		if s[k] != 0 {
+			parts = append(parts, fmt.Sprintf("%s=%d", k, s[k]))
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// Operator compares the value of a variable in a [Clause].
+type Operator string
+
+const (
+	OpEqual        Operator = "=="
+	OpNotEqual     Operator = "!="
+	OpLess         Operator = "<"
+	OpLessEqual    Operator = "<="
+	OpGreater      Operator = ">"
+	OpGreaterEqual Operator = ">="
+)
+
+// Operators with two characters need to come first, so "<=" is not parsed as "<".
+var operators = []Operator{OpEqual, OpNotEqual, OpLessEqual, OpGreaterEqual, OpLess, OpGreater}
+
+// Clause compares a variable of the state with a constant value.
+type Clause struct {
+	Var   string
+	Op    Operator
+	Value int
+}
+
+func (c Clause) Eval(s State) bool {
+	v := s[c.Var]
+	switch c.Op {
+	case OpEqual:
+		return v == c.Value
+	case OpNotEqual:
+		return v != c.Value
+	case OpLess:
+		return v < c.Value
+	case OpLessEqual:
+		return v <= c.Value
+	case OpGreater:
+		return v > c.Value
+	case OpGreaterEqual:
+		return v >= c.Value
+	default:
+		return false
+	}
+}
+
+func (c Clause) String() string {
+	switch {
+	case c.Op == OpNotEqual && c.Value == 0:
+		return c.Var
+	case c.Op == OpEqual && c.Value == 0:
+		return "!" + c.Var
+	default:
+		return fmt.Sprintf("%s %s %d", c.Var, c.Op, c.Value)
+	}
+}
+
+// Condition is a list of clauses which must all be true, written as clauses
+// separated by "&&", such as "met_ana && coins >= 3". A clause with just the name
+// of a variable is true if it is not zero, and one prefixed with "!" if it is
+// zero. An empty condition is always true.
+type Condition []Clause
+
+func ParseCondition(s string) (Condition, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(s, "&&")
+	c := make(Condition, len(parts))
+
+	for i, p := range parts {
+		p = strings.TrimSpace(p)
+
+		if name, ok := strings.CutPrefix(p, "!"); ok && !strings.HasPrefix(name, "=") {
+			name = strings.TrimSpace(name)
+			if !isVarName(name) {
+				return nil, fmt.Errorf("invalid variable name %q", name)
+			}
+			c[i] = Clause{Var: name, Op: OpEqual, Value: 0}
+			continue
+		}
+
+		var clause *Clause
+		for _, op := range operators {
+			name, value, ok := strings.Cut(p, string(op))
+			if !ok {
+				continue
+			}
+			name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+			if !isVarName(name) {
+				return nil, fmt.Errorf("invalid variable name %q", name)
+			}
+			v, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("value %q of %q is not an integer", value, name)
+			}
+			clause = &Clause{Var: name, Op: op, Value: v}
+			break
+		}
+
+		if clause == nil {
+			if !isVarName(p) {
+				return nil, fmt.Errorf("invalid clause %q", p)
+			}
+			clause = &Clause{Var: p, Op: OpNotEqual, Value: 0}
+		}
+
+		c[i] = *clause
+	}
+
+	return c, nil
+}
+
+// Eval reports whether all clauses are true in s.
+func (c Condition) Eval(s State) bool {
+	for _, cl := range c {
+		if !cl.Eval(s) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c Condition) String() string {
+	parts := make([]string, len(c))
+	for i, cl := range c {
+		parts[i] = cl.String()
+	}
+	return strings.Join(parts, " && ")
+}
+
+// Effect changes a variable of the state: "name = 1" sets it, while "name += 1"
+// and "name -= 1" add to and subtract from its current value.
+type Effect struct {
+	Var   string
+	Op    string // "=", "+=" or "-="
+	Value int
+}
+
+func (e Effect) String() string {
+	return fmt.Sprintf("%s %s %d", e.Var, e.Op, e.Value)
+}
+
+// Effects is a list of effects separated by ";", such as "met_ana = 1; coins -= 2",
+// applied in order.
+type Effects []Effect
+
+func ParseEffects(s string) (Effects, error) {
+	es := Effects{}
+	for _, p := range strings.Split(s, ";") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+
+		var e *Effect
+		for _, op := range []string{"+=", "-=", "="} {
+			name, value, ok := strings.Cut(p, op)
+			if !ok {
+				continue
+			}
+			name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+			if !isVarName(name) {
+				return nil, fmt.Errorf("invalid variable name %q", name)
+			}
+			v, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("value %q of %q is not an integer", value, name)
+			}
+			e = &Effect{Var: name, Op: op, Value: v}
+			break
+		}
+		if e == nil {
+			return nil, fmt.Errorf("invalid effect %q", p)
+		}
+
+		es = append(es, *e)
+	}
+	if len(es) == 0 {
+		return nil, nil
+	}
+	return es, nil
+}
+
+// Apply returns a copy of s with the effects applied.
+func (es Effects) Apply(s State) State {
+	n := maps.Clone(s)
+	if n == nil {
+		n = State{}
+	}
+	for _, e := range es {
+		switch e.Op {
+		case "=":
+			n[e.Var] = e.Value
+		case "+=":
+			n[e.Var] += e.Value
+		case "-=":
+			n[e.Var] -= e.Value
+		}
+	}
+	return n
+}
+
+func (es Effects) String() string {
+	parts := make([]string, len(es))
+	for i, e := range es {
+		parts[i] = e.String()
+	}
+	return strings.Join(parts, "; ")
+}
+
+func isVarName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '.' || r == '-'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Conditional is implemented by nodes which are only shown if their condition is
+// true, such as sections, panels and choices.
+type Conditional interface {
+	Node
+
+	Condition() Condition
+}
+
+var conditionAttrName = xml.Name{Local: "data-ipub-if"}
+
+func unmarshalCondition(a xml.Attr) (Condition, error) {
+	c, err := ParseCondition(a.Value)
+	if err != nil {
+		return nil, attr.ErrInvalidValue{Attr: a, Message: err.Error()}
+	}
+	return c, nil
+}
+
+// Visible reports whether n and all of its conditional ancestors are shown in s.
+func Visible(n Node, s State) bool {
+	for ; n != nil; n = n.Parent() {
+		if c, ok := n.(Conditional); ok && !c.Condition().Eval(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// Choice is an option given to the reader, which moves them to the target section
+// and applies its effects to the state. When a section has choices, the story
+// only continues through them instead of the next section in the spine. The text
+// of the choice is stored as [Text] runs.
+type Choice struct {
+	target    string
+	condition Condition
+	effects   Effects
+
+	BaseNode
+}
+
+var KindChoice = NewNodeKind("choice", &Choice{})
+
+func (e Choice) Kind() NodeKind {
+	return KindChoice
+}
+
+// Target returns the href of the section the choice leads to.
+func (e Choice) Target() string {
+	return e.target
+}
+
+func (e *Choice) SetTarget(href string) {
+	e.target = href
+}
+
+// Condition returns the condition for the choice to be shown.
+func (e Choice) Condition() Condition {
+	return e.condition
+}
+
+func (e *Choice) SetCondition(c Condition) {
+	e.condition = c
+}
+
+// Effects returns the changes made to the state when the choice is picked.
+func (e Choice) Effects() Effects {
+	return e.effects
+}
+
+func (e *Choice) SetEffects(es Effects) {
+	e.effects = es
+}
+
+func (e *Choice) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	return marshalNode(enc, e, "a")
+}
+
+func (e *Choice) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return unmarshalNode(d, e, start)
+}
+
+var (
+	choiceTargetAttrName  = xml.Name{Local: "data-ipub-target"}
+	choiceEffectsAttrName = xml.Name{Local: "data-ipub-set"}
+)
+
+func (e Choice) MarshalXMLAttrs() ([]xml.Attr, error) {
+	attrs := []xml.Attr{{Name: choiceTargetAttrName, Value: e.target}}
+	if len(e.condition) > 0 {
+		attrs = append(attrs, xml.Attr{Name: conditionAttrName, Value: e.condition.String()})
+	}
+	if len(e.effects) > 0 {
+		attrs = append(attrs, xml.Attr{Name: choiceEffectsAttrName, Value: e.effects.String()})
+	}
+	return attrs, nil
+}
+
+func (e *Choice) UnmarshalXMLAttrs(attrs []xml.Attr) error {
+	for _, a := range attrs {
+		switch a.Name {
+		case choiceTargetAttrName:
+			e.target = a.Value
+
+		case conditionAttrName:
+			c, err := unmarshalCondition(a)
+			if err != nil {
+				return err
+			}
+			e.condition = c
+
+		case choiceEffectsAttrName:
+			es, err := ParseEffects(a.Value)
+			if err != nil {
+				return attr.ErrInvalidValue{Attr: a, Message: err.Error()}
+			}
+			e.effects = es
+		}
+	}
+	return nil
+}
+
+var (
+	_ Conditional = (*Section)(nil)
+	_ Conditional = (*Panel)(nil)
+	_ Conditional = (*Choice)(nil)
+)
@@ -0,0 +1,117 @@
+package ast_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/loreddev/x/tinyssert"
+)
+
+func TestParseCondition(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	c, err := ast.ParseCondition("met_ana && !lost &&coins>=3 && day == 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(ast.Condition{
+		{Var: "met_ana", Op: ast.OpNotEqual, Value: 0},
+		{Var: "lost", Op: ast.OpEqual, Value: 0},
+		{Var: "coins", Op: ast.OpGreaterEqual, Value: 3},
+		{Var: "day", Op: ast.OpEqual, Value: 2},
+	}, c)
+	assert.Equal("met_ana && !lost && coins >= 3 && day == 2", c.String())
+
+	assert.Equal(true, c.Eval(ast.State{"met_ana": 1, "coins": 5, "day": 2}))
+	assert.Equal(false, c.Eval(ast.State{"met_ana": 1, "coins": 2, "day": 2}))
+	assert.Equal(false, c.Eval(ast.State{"met_ana": 1, "lost": 1, "coins": 5, "day": 2}))
+
+	c, err = ast.ParseCondition(" ")
+	assert.Equal(nil, err)
+	assert.Equal(true, c.Eval(nil))
+
+	for _, s := range []string{"coins >= many", "2 == day", "a &&", "a b"} {
+		if _, err := ast.ParseCondition(s); err == nil {
+			t.Errorf("expected error parsing condition %q", s)
+		}
+	}
+}
+
+func TestParseEffects(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	es, err := ast.ParseEffects("met_ana = 1; coins -= 2;coins+=5;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal("met_ana = 1; coins -= 2; coins += 5", es.String())
+
+	s := ast.State{"coins": 1}
+	assert.Equal(ast.State{"met_ana": 1, "coins": 4}, es.Apply(s))
+	assert.Equal(ast.State{"coins": 1}, s)
+
+	if _, err := ast.ParseEffects("coins ++"); err == nil {
+		t.Error("expected error parsing invalid effect")
+	}
+}
+
+func TestStateKey(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	assert.Equal("a=1;b=-2", ast.State{"b": -2, "a": 1, "c": 0}.Key())
+	assert.Equal("", ast.State{"c": 0}.Key())
+}
+
+func TestChoice(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	s := []byte(`
+	<section data-ipub-element="content">
+		<div data-ipub-element="panel" data-ipub-order="1" data-ipub-bounds="0 0 100 100" data-ipub-if="met_ana">
+			<a data-ipub-element="choice" data-ipub-target="sections/003.xhtml" data-ipub-if="coins &gt; 0" data-ipub-set="coins -= 1">
+				<span data-ipub-element="text">Pay Ana</span>
+			</a>
+		</div>
+	</section>
+	`)
+
+	var c ast.Content
+	if err := xml.Unmarshal(s, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	ch, ok := ast.FindFirst(&c, ast.KindChoice).(*ast.Choice)
+	assert.Equal(true, ok)
+	assert.Equal("sections/003.xhtml", ch.Target())
+	assert.Equal("coins > 0", ch.Condition().String())
+	assert.Equal("coins -= 1", ch.Effects().String())
+	assert.Equal("met_ana", c.Panels()[0].Condition().String())
+
+	assert.Equal(true, ast.Visible(ch, ast.State{"met_ana": 1, "coins": 1}))
+	assert.Equal(false, ast.Visible(ch, ast.State{"coins": 1}))
+	assert.Equal(false, ast.Visible(ch, ast.State{"met_ana": 1}))
+
+	err := xml.Unmarshal([]byte(`<a data-ipub-element="choice" data-ipub-if="coins &gt;"></a>`), &ast.Choice{})
+	assert.NotNil(err)
+}
+
+func TestSectionCondition(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	s := `<html xmlns="http://www.w3.org/1999/xhtml" data-ipub-if="!trusted_leo" data-ipub-ending="true"></html>`
+
+	var sec ast.Section
+	if err := xml.Unmarshal([]byte(s), &sec); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal("!trusted_leo", sec.Condition().String())
+	assert.Equal(true, sec.Ending)
+
+	var b bytes.Buffer
+	if err := xml.NewEncoder(&b).Encode(sec); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(s, b.String())
+}
@@ -1,6 +1,16 @@
 package ast
 
+import (
+	"cmp"
+	"encoding/xml"
+	"slices"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
+)
+
 type Content struct {
+	spread bool
+
 	BaseNode
 }
 
@@ -10,9 +20,68 @@ func (e Content) Kind() NodeKind {
 	return KindContent
 }
 
+func (e *Content) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	return marshalNode(enc, e, "section")
+}
+
+func (e *Content) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return unmarshalNode(d, e, start)
+}
+
+// Spread reports whether the content is a two-page spread, a single image drawn
+// across two pages. It is always shown alone, filling both pages when the layout
+// has [SpreadDouble], see [Spreads].
+func (e Content) Spread() bool {
+	return e.spread
+}
+
+func (e *Content) SetSpread(v bool) {
+	e.spread = v
+}
+
+var contentSpreadAttrName = xml.Name{Local: "data-ipub-spread"}
+
+func (e Content) MarshalXMLAttrs() ([]xml.Attr, error) {
+	attrs := []xml.Attr{}
+	if e.spread {
+		attrs = append(attrs, xml.Attr{Name: contentSpreadAttrName, Value: "true"})
+	}
+	return attrs, nil
+}
+
+func (e *Content) UnmarshalXMLAttrs(attrs []xml.Attr) error {
+	for _, a := range attrs {
+		if a.Name == contentSpreadAttrName {
+			e.spread = a.Value == "true"
+		}
+	}
+	return nil
+}
+
+// Panels returns all [Panel] children of the content, sorted by their reading
+// order. Panels with the same index are kept in document order.
+func (e *Content) Panels() []*Panel {
+	ps := []*Panel{}
+	for c := e.FirstChild(); c != nil; c = c.NextSibling() {
+		if p, ok := c.(*Panel); ok {
+			ps = append(ps, p)
+		}
+	}
+	slices.SortStableFunc(ps, func(a, b *Panel) int {
+		return cmp.Compare(a.order, b.order)
+	})
+	return ps
+}
+
 type Image struct {
 	src string
 
+	alt            string
+	description    string
+	descriptionRef string
+	decorative     bool
+	lang           string
+
 	BaseNode
 }
 
@@ -29,3 +98,186 @@ func (e Image) Source() string {
 func (e *Image) SetSource(src string) {
 	e.src = src
 }
+
+// Alt returns the text alternative of the image, read by screen readers and shown
+// when the image can't be displayed.
+func (e Image) Alt() string {
+	return e.alt
+}
+
+func (e *Image) SetAlt(alt string) {
+	e.alt = alt
+}
+
+// Description returns the long description of the image, for content which
+// doesn't fit in its alt text, such as the action of a whole page.
+func (e Image) Description() string {
+	return e.description
+}
+
+func (e *Image) SetDescription(d string) {
+	e.description = d
+}
+
+// DescriptionRef returns the reference to a long description of the image stored
+// elsewhere, such as a resource of the package or a fragment of the section.
+func (e Image) DescriptionRef() string {
+	return e.descriptionRef
+}
+
+func (e *Image) SetDescriptionRef(ref string) {
+	e.descriptionRef = ref
+}
+
+// Decorative reports whether the image has no meaning and should be ignored by
+// assistive technologies.
+func (e Image) Decorative() bool {
+	return e.decorative
+}
+
+func (e *Image) SetDecorative(v bool) {
+	e.decorative = v
+}
+
+// Lang returns the language tag of the alt text and description of the image. If
+// empty, the language of the section is used.
+func (e Image) Lang() string {
+	return e.lang
+}
+
+func (e *Image) SetLang(l string) {
+	e.lang = l
+}
+
+func (e *Image) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	return marshalNode(enc, e, "img")
+}
+
+func (e *Image) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return unmarshalNode(d, e, start)
+}
+
+func (e Image) MarshalXMLAttrs() ([]xml.Attr, error) {
+	attrs := []xml.Attr{}
+	if e.src != "" {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "src"}, Value: e.src})
+	}
+	if e.alt != "" {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "alt"}, Value: e.alt})
+	}
+	if e.description != "" {
+		attrs = append(attrs, xml.Attr{Name: imageDescriptionAttrName, Value: e.description})
+	}
+	if e.descriptionRef != "" {
+		attrs = append(attrs, xml.Attr{Name: imageDescriptionRefAttrName, Value: e.descriptionRef})
+	}
+	if e.decorative {
+		attrs = append(attrs, xml.Attr{Name: imageDecorativeAttrName, Value: "true"})
+	}
+	if e.lang != "" {
+		attrs = append(attrs, xml.Attr{Name: langAttrName, Value: e.lang})
+	}
+	return attrs, nil
+}
+
+func (e *Image) UnmarshalXMLAttrs(attrs []xml.Attr) error {
+	for _, a := range attrs {
+		switch a.Name {
+		case xml.Name{Local: "src"}:
+			var u attr.URL
+			if err := u.UnmarshalXMLAttr(a); err != nil {
+				return err
+			}
+			e.src = u.String()
+		case xml.Name{Local: "alt"}:
+			e.alt = a.Value
+		case imageDescriptionAttrName:
+			e.description = a.Value
+		case imageDescriptionRefAttrName:
+			var u attr.URL
+			if err := u.UnmarshalXMLAttr(a); err != nil {
+				return err
+			}
+			e.descriptionRef = u.String()
+		case imageDecorativeAttrName:
+			e.decorative = a.Value == "true"
+		case langAttrName:
+			var l attr.Lang
+			if err := l.UnmarshalXMLAttr(a); err != nil {
+				return err
+			}
+			e.lang = l.String()
+		}
+	}
+	return nil
+}
+
+var (
+	imageDescriptionAttrName    = xml.Name{Local: "data-ipub-description"}
+	imageDescriptionRefAttrName = xml.Name{Local: "data-ipub-description-ref"}
+	imageDecorativeAttrName     = xml.Name{Local: "data-ipub-decorative"}
+)
+
+// Layer is a group of nodes drawn over the page image of its parent [Content],
+// which can be initially hidden and shown by a [Reveal] interaction.
+type Layer struct {
+	name   string
+	hidden bool
+
+	BaseNode
+}
+
+var KindLayer = NewNodeKind("layer", &Layer{})
+
+func (e Layer) Kind() NodeKind {
+	return KindLayer
+}
+
+// Name returns the name used to reference the layer, unique in its section.
+func (e Layer) Name() string {
+	return e.name
+}
+
+func (e *Layer) SetName(name string) {
+	e.name = name
+}
+
+// Hidden reports whether the layer is hidden before being revealed.
+func (e Layer) Hidden() bool {
+	return e.hidden
+}
+
+func (e *Layer) SetHidden(v bool) {
+	e.hidden = v
+}
+
+func (e *Layer) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	return marshalNode(enc, e, "div")
+}
+
+func (e *Layer) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return unmarshalNode(d, e, start)
+}
+
+var layerNameAttrName = xml.Name{Local: "data-ipub-name"}
+
+func (e Layer) MarshalXMLAttrs() ([]xml.Attr, error) {
+	attrs := []xml.Attr{{Name: layerNameAttrName, Value: e.name}}
+	if e.hidden {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "hidden"}, Value: "hidden"})
+	}
+	return attrs, nil
+}
+
+func (e *Layer) UnmarshalXMLAttrs(attrs []xml.Attr) error {
+	for _, a := range attrs {
+		switch a.Name {
+		case layerNameAttrName:
+			e.name = a.Value
+		case xml.Name{Local: "hidden"}:
+			// Following HTML, the presence of the attribute means it is true.
+			e.hidden = true
+		}
+	}
+	return nil
+}
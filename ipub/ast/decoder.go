@@ -0,0 +1,321 @@
+package ast
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// Decoder reads a section document one node at a time, instead of decoding the
+// whole tree into memory like [Section.UnmarshalXML], which makes it possible to
+// index or validate sections with thousands of pages, such as vertical chapters.
+//
+// Each call to [Decoder.Next] decodes a single child of the body, such as a page,
+// with all of its children. So only one of them is held in memory at a time. For
+// pages which are too large for that, such as a long strip with thousands of
+// panels, [Decoder.Step] reads the nodes at any depth one by one, without their
+// children, so only the node being read and its ancestors are held in memory.
+// Both can be used together, Next decoding the next child of the node last
+// entered by Step.
+//
+// Reading can be stopped at any point by not calling Next or Step anymore, the
+// rest of the document is not read.
+type Decoder struct {
+	// Strict makes Next, Skip and Step return an error for nodes of unknown kinds,
+	// instead of decoding them as [Unknown] nodes. See [CheckKinds].
+	Strict bool
+
+	d *xml.Decoder
+
+	section *Section
+	done    bool
+	err     error
+
+	// Nodes entered by Step which were not left yet, from the outermost one.
+	stack []Node
+	// Node whose element was read until its end, which is left by the next Step.
+	leaving Node
+}
+
+// NewDecoder creates a new decoder reading the section document in r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{d: xml.NewDecoder(r)}
+}
+
+// Section returns the section of the document, with its title and attributes. Its
+// body has no children, they are returned by [Decoder.Next] instead, and it is nil
+// if the document has no body.
+func (d *Decoder) Section() (*Section, error) {
+	if d.section == nil && d.err == nil {
+		d.err = d.readSection()
+	}
+	return d.section, d.err
+}
+
+// readSection reads the document until the start of the body.
+func (d *Decoder) readSection() error {
+	s := &Section{}
+
+	for started := false; !started; {
+		t, err := d.d.Token()
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		} else if err != nil {
+			return err
+		}
+
+		if start, ok := t.(xml.StartElement); ok {
+			if start.Name.Local != "html" {
+				return fmt.Errorf("section root element must be %q, found %q", "html", start.Name.Local)
+			}
+			if err := s.unmarshalAttrs(start.Attr); err != nil {
+				return err
+			}
+			started = true
+		}
+	}
+
+	for {
+		line, col := d.d.InputPos()
+
+		t, err := d.d.Token()
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		} else if err != nil {
+			return err
+		}
+
+		switch t := t.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "head":
+				if err := s.unmarshalHead(d.d, t); err != nil {
+					return err
+				}
+
+			case "body":
+				b := &Body{}
+				b.SetPosition(Position{Line: line, Column: col})
+				unmarshalBaseAttrs(b, t)
+				s.SetBody(b)
+				d.section = s
+				return nil
+
+			default:
+				if err := d.d.Skip(); err != nil {
+					return err
+				}
+			}
+
+		case xml.EndElement:
+			d.section = s
+			d.done = true
+			return nil
+		}
+	}
+}
+
+// Next decodes the next child of the body, or of the node last entered by
+// [Decoder.Step]. The node has no parent, and it is not added to the body returned
+// by [Decoder.Section]. It returns [io.EOF] after the last child.
+func (d *Decoder) Next() (Node, error) {
+	if _, err := d.Section(); err != nil {
+		return nil, err
+	}
+	if d.done || d.leaving != nil {
+		return nil, io.EOF
+	}
+
+	for {
+		line, col := d.d.InputPos()
+
+		t, err := d.d.Token()
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			d.err = err
+			return nil, err
+		}
+
+		switch t := t.(type) {
+		case xml.StartElement:
+			n, err := newNodeFromElement(t)
+			if err != nil {
+				d.err = err
+				return nil, err
+			}
+			n.SetPosition(Position{Line: line, Column: col})
+
+			if err := d.d.DecodeElement(n, &t); err != nil {
+				d.err = err
+				return nil, err
+			}
+			if d.Strict {
+				if err := CheckKinds(n); err != nil {
+					d.err = err
+					return nil, err
+				}
+			}
+			return n, nil
+
+		case xml.EndElement:
+			d.end()
+			return nil, io.EOF
+		}
+	}
+}
+
+// Skip skips the next child of the body, or of the node last entered by
+// [Decoder.Step], without decoding it, returning its kind. It returns [io.EOF]
+// after the last child.
+func (d *Decoder) Skip() (NodeKind, error) {
+	if _, err := d.Section(); err != nil {
+		return "", err
+	}
+	if d.done || d.leaving != nil {
+		return "", io.EOF
+	}
+
+	for {
+		t, err := d.d.Token()
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			d.err = err
+			return "", err
+		}
+
+		switch t := t.(type) {
+		case xml.StartElement:
+			n, err := newNodeFromElement(t)
+			if err == nil && d.Strict {
+				err = CheckKinds(n)
+			}
+			if err != nil {
+				d.err = err
+				return "", err
+			}
+			if err := d.d.Skip(); err != nil {
+				d.err = err
+				return "", err
+			}
+			return n.Kind(), nil
+
+		case xml.EndElement:
+			d.end()
+			return "", io.EOF
+		}
+	}
+}
+
+// Step reads the next node at any depth of the body, reporting whether it was
+// entered or left, the same as the arguments of a [WalkFunc]. Nodes are entered
+// with their attributes but without their children, which are read by the next
+// calls, before the node is left. [Text] and [Unknown] nodes are decoded whole,
+// since their content is not made of nodes, and are left right after they are
+// entered. It returns [io.EOF] after the last child of the body.
+//
+// The nodes have no parent and no children, so nodes already read can be
+// released. Use [Decoder.Ancestors] to know the nodes a node is inside of.
+func (d *Decoder) Step() (n Node, entering bool, err error) {
+	if _, err := d.Section(); err != nil {
+		return nil, false, err
+	}
+	if d.leaving != nil {
+		n, d.leaving = d.leaving, nil
+		return n, false, nil
+	}
+	if d.done {
+		return nil, false, io.EOF
+	}
+
+	for {
+		line, col := d.d.InputPos()
+
+		t, err := d.d.Token()
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			d.err = err
+			return nil, false, err
+		}
+
+		switch t := t.(type) {
+		case xml.StartElement:
+			n, err := newNodeFromElement(t)
+			if err == nil && d.Strict {
+				err = CheckKinds(n)
+			}
+			if err != nil {
+				d.err = err
+				return nil, false, err
+			}
+			n.SetPosition(Position{Line: line, Column: col})
+
+			switch n.(type) {
+			case *Text, *Unknown:
+				err = d.d.DecodeElement(n, &t)
+				d.leaving = n
+			default:
+				err = unmarshalNodeAttrs(n, t)
+				d.stack = append(d.stack, n)
+			}
+			if err != nil {
+				d.err = err
+				return nil, false, err
+			}
+			return n, true, nil
+
+		case xml.EndElement:
+			if len(d.stack) == 0 {
+				d.done = true
+				return nil, false, io.EOF
+			}
+			n := d.stack[len(d.stack)-1]
+			d.stack = d.stack[:len(d.stack)-1]
+			return n, false, nil
+		}
+	}
+}
+
+// SkipChildren skips the children of the node last entered by [Decoder.Step]
+// which were not read yet, so the next call to Step leaves it.
+func (d *Decoder) SkipChildren() error {
+	if d.err != nil {
+		return d.err
+	}
+	if d.leaving != nil || len(d.stack) == 0 {
+		return nil
+	}
+	if err := d.d.Skip(); err != nil {
+		d.err = err
+		return err
+	}
+	d.end()
+	return nil
+}
+
+// Ancestors returns the nodes entered by [Decoder.Step] which were not left yet,
+// which are the ancestors of the next node read, from the innermost one to the
+// outermost one like [Ancestors]. The body is not included.
+func (d *Decoder) Ancestors() []Node {
+	ns := make([]Node, 0, len(d.stack))
+	for i := len(d.stack) - 1; i >= 0; i-- {
+		ns = append(ns, d.stack[i])
+	}
+	return ns
+}
+
+// end handles the end of the element of the node last entered by Step, or of the
+// body if there is none, after it was read.
+func (d *Decoder) end() {
+	if len(d.stack) == 0 {
+		d.done = true
+		return
+	}
+	d.leaving = d.stack[len(d.stack)-1]
+	d.stack = d.stack[:len(d.stack)-1]
+}
@@ -0,0 +1,326 @@
+package ast_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
+	"forge.capytal.company/loreddev/x/tinyssert"
+)
+
+func TestDecoder(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	var full ast.Section
+	if err := xml.Unmarshal(test, &full); err != nil {
+		t.Fatal(err)
+	}
+
+	d := ast.NewDecoder(bytes.NewReader(test))
+
+	s, err := d.Section()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal("Chapter 1", s.Title)
+	assert.Equal("en", s.Language)
+	assert.Equal(false, s.Body().HasChildren())
+
+	c := full.Body().FirstChild()
+	for {
+		n, err := d.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(nil, n.Parent())
+		assert.Equal(c.Position(), n.Position())
+
+		want, err := xml.Marshal(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := xml.Marshal(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(string(want), string(got))
+
+		c = c.NextSibling()
+	}
+	assert.Equal(nil, c)
+
+	_, err = d.Next()
+	assert.Equal(io.EOF, err)
+}
+
+func TestDecoderSkip(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	d := ast.NewDecoder(bytes.NewReader(test))
+
+	k, err := d.Skip()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(ast.KindSoundtrack, k)
+
+	n, err := d.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(ast.KindContent, n.Kind())
+	assert.Equal(true, ast.FindFirst(n, ast.KindPanel) != nil)
+
+	// Only the document up to the nodes read is needed.
+	i := bytes.Index(test, []byte(`data-ipub-name="note"`))
+	d = ast.NewDecoder(bytes.NewReader(test[:i]))
+	if _, err := d.Skip(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Next(); err == nil {
+		t.Error("expected error for node cut by the end of the document")
+	}
+}
+
+func TestDecoderInvalid(t *testing.T) {
+	d := ast.NewDecoder(bytes.NewReader([]byte(`<html><body data-ipub-element="body">
+		<section data-ipub-element="content"></section>
+		<section data-ipub-element="not-a-kind"></section>
+	</body></html>`)))
+
+	d.Strict = true
+
+	if _, err := d.Next(); err != nil {
+		t.Fatal(err)
+	}
+
+	var aerr attr.ErrInvalidValue
+	if _, err := d.Next(); !errors.As(err, &aerr) {
+		t.Errorf("expected error %T for unknown kind, got %v", aerr, err)
+	}
+
+	// Errors are kept by the decoder.
+	if _, err := d.Next(); !errors.As(err, &aerr) {
+		t.Errorf("expected error %T after failed read, got %v", aerr, err)
+	}
+
+	d = ast.NewDecoder(bytes.NewReader([]byte(`<body data-ipub-element="body"></body>`)))
+	if _, err := d.Section(); err == nil {
+		t.Error("expected error for document without html root")
+	}
+
+	d = ast.NewDecoder(bytes.NewReader([]byte(`<html><head><title>Empty</title></head></html>`)))
+	s, err := d.Section()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Body() != nil {
+		t.Error("expected no body for document without body")
+	}
+	if _, err := d.Next(); err != io.EOF {
+		t.Errorf("expected %q for document without body, got %v", io.EOF, err)
+	}
+}
+
+func TestDecoderStep(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	var full ast.Section
+	if err := xml.Unmarshal(test, &full); err != nil {
+		t.Fatal(err)
+	}
+
+	d := ast.NewDecoder(bytes.NewReader(test))
+	s, err := d.Section()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The tree is built back from the nodes, which are read without children.
+	stack := []ast.Node{s.Body()}
+	for {
+		n, entering, err := d.Step()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		if !entering {
+			assert.Equal(n, stack[len(stack)-1])
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		assert.Equal(nil, n.Parent())
+		if _, ok := n.(*ast.Text); !ok {
+			assert.Equal(false, n.HasChildren())
+		}
+
+		p := stack[len(stack)-1]
+		p.AppendChild(p, n)
+		stack = append(stack, n)
+	}
+	assert.Equal(1, len(stack))
+
+	want, err := xml.Marshal(full.Body())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := xml.Marshal(s.Body())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(string(want), string(got))
+	assert.Equal(ast.FindID(&full, "panel-1").Position(), ast.FindID(s, "panel-1").Position())
+
+	_, _, err = d.Step()
+	assert.Equal(io.EOF, err)
+}
+
+func TestDecoderStepNext(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	d := ast.NewDecoder(bytes.NewReader(test))
+
+	if _, err := d.Skip(); err != nil {
+		t.Fatal(err)
+	}
+
+	c, entering, err := d.Step()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(ast.KindContent, c.Kind())
+	assert.Equal(true, entering)
+
+	// Next reads the children of the node entered by Step.
+	n, err := d.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(ast.KindImage, n.Kind())
+
+	p, entering, err := d.Step()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(ast.KindPanel, p.Kind())
+	assert.Equal(true, entering)
+	assert.Equal([]ast.Node{p, c}, d.Ancestors())
+
+	if err := d.SkipChildren(); err != nil {
+		t.Fatal(err)
+	}
+	n, entering, err = d.Step()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(p, n)
+	assert.Equal(false, entering)
+	assert.Equal(false, p.HasChildren())
+
+	ks := []ast.NodeKind{}
+	for {
+		n, err := d.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		ks = append(ks, n.Kind())
+	}
+	assert.Equal([]ast.NodeKind{ast.KindPanel, ast.KindCaption, ast.KindSpeech, ast.KindThought, ast.KindSoundEffect}, ks)
+
+	// The content is left once all of its children were read.
+	n, entering, err = d.Step()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(c, n)
+	assert.Equal(false, entering)
+
+	n, err = d.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(ast.KindContent, n.Kind())
+}
+
+func TestDecoderStepLargePage(t *testing.T) {
+	const panels = 100_000
+
+	panel := []byte(`<div data-ipub-element="panel" data-ipub-order="1" data-ipub-bounds="0 0 100 10">` +
+		`<div data-ipub-element="panel" data-ipub-order="1" data-ipub-bounds="0 0 50 5"></div></div>`)
+	r := io.MultiReader(
+		strings.NewReader(`<html><body data-ipub-element="body"><section data-ipub-element="content">`),
+		&repeatReader{b: panel, n: panels},
+		strings.NewReader(`</section></body></html>`),
+	)
+
+	d := ast.NewDecoder(r)
+
+	var m runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&m)
+	before := m.HeapAlloc
+	peak := before
+
+	count := 0
+	for {
+		n, entering, err := d.Step()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if entering && n.Kind() == ast.KindPanel {
+			count++
+			if count%25_000 == 0 {
+				runtime.GC()
+				runtime.ReadMemStats(&m)
+				peak = max(peak, m.HeapAlloc)
+			}
+		}
+	}
+
+	if count != panels*2 {
+		t.Errorf("expected %d panels, got %d", panels*2, count)
+	}
+	// Decoding the whole page would keep all of the panels, hundreds of bytes each.
+	if grown := int64(peak) - int64(before); grown > 8<<20 {
+		t.Errorf("expected memory to not grow with the size of the page, grew %d bytes", grown)
+	}
+}
+
+// repeatReader reads b n times.
+type repeatReader struct {
+	b   []byte
+	n   int
+	off int
+}
+
+func (r *repeatReader) Read(p []byte) (int, error) {
+	if r.n == 0 {
+		return 0, io.EOF
+	}
+	c := copy(p, r.b[r.off:])
+	r.off += c
+	if r.off == len(r.b) {
+		r.off = 0
+		r.n--
+	}
+	return c, nil
+}
@@ -0,0 +1,352 @@
+package ast
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
+)
+
+// Dialog is implemented by all lettering nodes placed over a page: speech
+// balloons, captions, thought bubbles and sound effects. Their text is stored as
+// [Text] children, so it can be searched, translated and read aloud.
+type Dialog interface {
+	Node
+
+	Speaker() string
+	Anchor() Point
+	Tail() TailDirection
+	Style() string
+}
+
+// Balloon holds the data shared by all [Dialog] nodes. It is embedded by each
+// dialog kind and can't be used as a node by itself.
+type Balloon struct {
+	speaker string
+	anchor  Point
+	tail    TailDirection
+	style   string
+
+	BaseNode
+}
+
+// Speaker returns the reference to the character who is speaking, or an empty
+// string if the dialog doesn't have one (e.g. narration captions).
+func (e Balloon) Speaker() string {
+	return e.speaker
+}
+
+func (e *Balloon) SetSpeaker(s string) {
+	e.speaker = s
+}
+
+// Anchor returns the position of the balloon on the page image.
+func (e Balloon) Anchor() Point {
+	return e.anchor
+}
+
+func (e *Balloon) SetAnchor(p Point) {
+	e.anchor = p
+}
+
+// Tail returns the direction which the tail of the balloon points to, relative to
+// its anchor.
+func (e Balloon) Tail() TailDirection {
+	return e.tail
+}
+
+func (e *Balloon) SetTail(t TailDirection) {
+	e.tail = t
+}
+
+// Style returns the name of the balloon style, such as "shout" or "whisper". How
+// it is displayed depends on the reader.
+func (e Balloon) Style() string {
+	return e.style
+}
+
+func (e *Balloon) SetStyle(s string) {
+	e.style = s
+}
+
+var (
+	balloonSpeakerAttrName = xml.Name{Local: "data-ipub-speaker"}
+	balloonAnchorAttrName  = xml.Name{Local: "data-ipub-anchor"}
+	balloonTailAttrName    = xml.Name{Local: "data-ipub-tail"}
+	balloonStyleAttrName   = xml.Name{Local: "data-ipub-style"}
+)
+
+func (e Balloon) MarshalXMLAttrs() ([]xml.Attr, error) {
+	attrs := []xml.Attr{}
+	if e.speaker != "" {
+		attrs = append(attrs, xml.Attr{Name: balloonSpeakerAttrName, Value: e.speaker})
+	}
+	attrs = append(attrs, xml.Attr{Name: balloonAnchorAttrName, Value: formatPoint(e.anchor)})
+	if e.tail != TailNone {
+		attrs = append(attrs, xml.Attr{Name: balloonTailAttrName, Value: string(e.tail)})
+	}
+	if e.style != "" {
+		attrs = append(attrs, xml.Attr{Name: balloonStyleAttrName, Value: e.style})
+	}
+	return attrs, nil
+}
+
+func (e *Balloon) UnmarshalXMLAttrs(attrs []xml.Attr) error {
+	for _, a := range attrs {
+		switch a.Name {
+		case balloonSpeakerAttrName:
+			var r attr.IDRef
+			if err := r.UnmarshalXMLAttr(a); err != nil {
+				return err
+			}
+			e.speaker = r.String()
+
+		case balloonAnchorAttrName:
+			p, err := parsePoint(a.Value)
+			if err != nil {
+				return attr.ErrInvalidValue{Attr: a, Message: err.Error()}
+			}
+			e.anchor = p
+
+		case balloonTailAttrName:
+			if a.Value == string(TailNone) {
+				e.tail = TailNone
+				continue
+			}
+			var v attr.Enum[TailDirection]
+			if err := v.UnmarshalXMLAttr(a); err != nil {
+				return err
+			}
+			e.tail = v.Value
+
+		case balloonStyleAttrName:
+			e.style = a.Value
+		}
+	}
+	return nil
+}
+
+// TailDirection is the compass direction which the tail of a balloon points to.
+type TailDirection string
+
+const (
+	TailNone      TailDirection = ""
+	TailNorth     TailDirection = "n"
+	TailNorthEast TailDirection = "ne"
+	TailEast      TailDirection = "e"
+	TailSouthEast TailDirection = "se"
+	TailSouth     TailDirection = "s"
+	TailSouthWest TailDirection = "sw"
+	TailWest      TailDirection = "w"
+	TailNorthWest TailDirection = "nw"
+)
+
+func (TailDirection) Values() []TailDirection {
+	return []TailDirection{
+		TailNorth, TailNorthEast, TailEast, TailSouthEast,
+		TailSouth, TailSouthWest, TailWest, TailNorthWest,
+	}
+}
+
+// Speech is a speech balloon, with the lines said by its speaker.
+type Speech struct {
+	Balloon
+}
+
+var KindSpeech = NewNodeKind("speech", &Speech{})
+
+func (e Speech) Kind() NodeKind {
+	return KindSpeech
+}
+
+func (e *Speech) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	return marshalNode(enc, e, "p")
+}
+
+func (e *Speech) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return unmarshalNode(d, e, start)
+}
+
+// Caption is a narration box, usually without a speaker.
+type Caption struct {
+	Balloon
+}
+
+var KindCaption = NewNodeKind("caption", &Caption{})
+
+func (e Caption) Kind() NodeKind {
+	return KindCaption
+}
+
+func (e *Caption) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	return marshalNode(enc, e, "p")
+}
+
+func (e *Caption) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return unmarshalNode(d, e, start)
+}
+
+// Thought is a thought bubble of its speaker.
+type Thought struct {
+	Balloon
+}
+
+var KindThought = NewNodeKind("thought", &Thought{})
+
+func (e Thought) Kind() NodeKind {
+	return KindThought
+}
+
+func (e *Thought) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	return marshalNode(enc, e, "p")
+}
+
+func (e *Thought) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return unmarshalNode(d, e, start)
+}
+
+// SoundEffect is a lettered sound effect (SFX), such as onomatopoeia.
+type SoundEffect struct {
+	Balloon
+}
+
+var KindSoundEffect = NewNodeKind("sfx", &SoundEffect{})
+
+func (e SoundEffect) Kind() NodeKind {
+	return KindSoundEffect
+}
+
+func (e *SoundEffect) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	return marshalNode(enc, e, "p")
+}
+
+func (e *SoundEffect) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return unmarshalNode(d, e, start)
+}
+
+var (
+	_ Dialog = (*Speech)(nil)
+	_ Dialog = (*Caption)(nil)
+	_ Dialog = (*Thought)(nil)
+	_ Dialog = (*SoundEffect)(nil)
+)
+
+// DialogText returns the text of all [Text] runs inside of n, concatenated. If n
+// has alternates, the text of its source alternate is returned.
+func DialogText(n Node) string {
+	if a := SourceAlternate(n); a != nil {
+		n = a
+	}
+	return textRuns(n)
+}
+
+// textRuns returns the text of the [Text] children of n, concatenated.
+func textRuns(n Node) string {
+	var b strings.Builder
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		if t, ok := c.(*Text); ok {
+			b.WriteString(t.Value())
+		}
+	}
+	return b.String()
+}
+
+// Text is a run of text inside a dialog, with an optional emphasis. It can't
+// have children.
+type Text struct {
+	value    string
+	emphasis Emphasis
+
+	BaseNode
+}
+
+var KindText = NewNodeKind("text", &Text{})
+
+func (e Text) Kind() NodeKind {
+	return KindText
+}
+
+func (e Text) Value() string {
+	return e.value
+}
+
+func (e *Text) SetValue(v string) {
+	e.value = v
+}
+
+func (e Text) Emphasis() Emphasis {
+	return e.emphasis
+}
+
+func (e *Text) SetEmphasis(v Emphasis) {
+	e.emphasis = v
+}
+
+// Emphasis is how a [Text] run is stressed, in lettering usually done with bold
+// or italic letters.
+type Emphasis string
+
+const (
+	EmphasisNone   Emphasis = ""
+	EmphasisStrong Emphasis = "strong"
+	EmphasisItalic Emphasis = "italic"
+)
+
+func (Emphasis) Values() []Emphasis {
+	return []Emphasis{EmphasisStrong, EmphasisItalic}
+}
+
+var textEmphasisAttrName = xml.Name{Local: "data-ipub-emphasis"}
+
+func (e *Text) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	k, err := e.Kind().MarshalXMLAttr(NodeKindAttrName)
+	if err != nil {
+		return err
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: "span"}, Attr: append([]xml.Attr{k}, baseAttrs(e)...)}
+	if e.emphasis != EmphasisNone {
+		start.Attr = append(start.Attr, xml.Attr{Name: textEmphasisAttrName, Value: string(e.emphasis)})
+	}
+
+	return enc.EncodeElement(e.value, start)
+}
+
+func (e *Text) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	unmarshalBaseAttrs(e, start)
+
+	for _, a := range start.Attr {
+		if a.Name != textEmphasisAttrName {
+			continue
+		}
+		if a.Value == string(EmphasisNone) {
+			e.emphasis = EmphasisNone
+			continue
+		}
+		var v attr.Enum[Emphasis]
+		if err := v.UnmarshalXMLAttr(a); err != nil {
+			return err
+		}
+		e.emphasis = v.Value
+	}
+
+	var b strings.Builder
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := t.(type) {
+		case xml.CharData:
+			b.Write(t)
+		case xml.StartElement:
+			return fmt.Errorf("node of kind %q can't have children, found element %q",
+				e.Kind(), attr.FmtXMLName(t.Name))
+		case xml.EndElement:
+			e.value = b.String()
+			return nil
+		}
+	}
+}
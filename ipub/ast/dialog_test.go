@@ -0,0 +1,71 @@
+package ast_test
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/loreddev/x/tinyssert"
+)
+
+func TestDialog(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	s := []byte(`
+	<section data-ipub-element="content">
+		<p data-ipub-element="speech" data-ipub-speaker="ana" data-ipub-anchor="70,20.5" data-ipub-tail="sw" data-ipub-style="shout">
+			<span data-ipub-element="text">Get </span><span data-ipub-element="text" data-ipub-emphasis="strong">down</span><span data-ipub-element="text">!</span>
+		</p>
+		<p data-ipub-element="sfx" data-ipub-anchor="50,80"><span data-ipub-element="text">BLAM</span></p>
+	</section>
+	`)
+
+	var c ast.Content
+	if err := xml.Unmarshal(s, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	ds := []ast.Dialog{}
+	for n := c.FirstChild(); n != nil; n = n.NextSibling() {
+		if d, ok := n.(ast.Dialog); ok {
+			ds = append(ds, d)
+		}
+	}
+	assert.Equal(2, len(ds))
+
+	sp, ok := ds[0].(*ast.Speech)
+	assert.Equal(true, ok)
+	assert.Equal("ana", sp.Speaker())
+	assert.Equal(ast.Point{X: 70, Y: 20.5}, sp.Anchor())
+	assert.Equal(ast.TailSouthWest, sp.Tail())
+	assert.Equal("shout", sp.Style())
+	assert.Equal(uint(3), sp.ChildCount())
+	assert.Equal("Get down!", ast.DialogText(sp))
+	assert.Equal(ast.EmphasisStrong, sp.FirstChild().NextSibling().(*ast.Text).Emphasis())
+
+	sfx, ok := ds[1].(*ast.SoundEffect)
+	assert.Equal(true, ok)
+	assert.Equal("", sfx.Speaker())
+	assert.Equal(ast.TailNone, sfx.Tail())
+	assert.Equal("BLAM", ast.DialogText(sfx))
+}
+
+func TestDialogInvalid(t *testing.T) {
+	tests := map[string]string{
+		"anchor":   `<p data-ipub-element="speech" data-ipub-anchor="70"></p>`,
+		"speaker":  `<p data-ipub-element="speech" data-ipub-speaker="#ana"></p>`,
+		"tail":     `<p data-ipub-element="speech" data-ipub-tail="up"></p>`,
+		"emphasis": `<p data-ipub-element="speech"><span data-ipub-element="text" data-ipub-emphasis="loud">Hi</span></p>`,
+		"text children": `<p data-ipub-element="speech">` +
+			`<span data-ipub-element="text"><span data-ipub-element="text">Hi</span></span></p>`,
+	}
+
+	for name, s := range tests {
+		t.Run(name, func(t *testing.T) {
+			var d ast.Speech
+			if err := xml.Unmarshal([]byte(s), &d); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package ast
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Point is a position relative to a page image. Coordinates are percentages of
+// the image's width and height, so (0, 0) is the top-left corner and (100, 100)
+// the bottom-right one, independently of the image's resolution.
+type Point struct {
+	X, Y float64
+}
+
+// Rect is a rectangle relative to a page image, using the same coordinate
+// space as [Point].
+type Rect struct {
+	X, Y          float64
+	Width, Height float64
+}
+
+// PageRect is the rectangle that covers a whole page image.
+var PageRect = Rect{X: 0, Y: 0, Width: 100, Height: 100}
+
+// Contains reports whether p is inside of r, including its edges.
+func (r Rect) Contains(p Point) bool {
+	return p.X >= r.X && p.X <= r.X+r.Width && p.Y >= r.Y && p.Y <= r.Y+r.Height
+}
+
+// In reports whether r is completely inside of o.
+func (r Rect) In(o Rect) bool {
+	return o.Contains(Point{r.X, r.Y}) && o.Contains(Point{r.X + r.Width, r.Y + r.Height})
+}
+
+// Empty reports whether r has no area.
+func (r Rect) Empty() bool {
+	return r.Width <= 0 || r.Height <= 0
+}
+
+// Polygon is a closed shape relative to a page image, defined by its vertices.
+type Polygon []Point
+
+// Bounds returns the smallest rectangle containing all vertices of the polygon.
+func (p Polygon) Bounds() Rect {
+	if len(p) == 0 {
+		return Rect{}
+	}
+
+	minP, maxP := p[0], p[0]
+	for _, v := range p[1:] {
+		minP.X, minP.Y = min(minP.X, v.X), min(minP.Y, v.Y)
+		maxP.X, maxP.Y = max(maxP.X, v.X), max(maxP.Y, v.Y)
+	}
+
+	return Rect{X: minP.X, Y: minP.Y, Width: maxP.X - minP.X, Height: maxP.Y - minP.Y}
+}
+
+// The following functions encode geometry into attribute values. Rectangles are
+// written as "x y width height" and polygons as a list of "x,y" pairs separated
+// by spaces, the same as the "points" attribute of SVG polygons.
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// parseFloat parses s as a finite number, NaN and infinities are not valid values
+// of any attribute.
+func parseFloat(s string) (float64, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("value %q is not a finite number", s)
+	}
+	return f, nil
+}
+
+func formatRect(r Rect) string {
+	return strings.Join([]string{
+		formatFloat(r.X), formatFloat(r.Y), formatFloat(r.Width), formatFloat(r.Height),
+	}, " ")
+}
+
+func parseRect(s string) (Rect, error) {
+	f := strings.Fields(s)
+	if len(f) != 4 {
+		return Rect{}, fmt.Errorf("rectangle must have 4 values, found %d", len(f))
+	}
+
+	v := make([]float64, len(f))
+	for i, s := range f {
+		n, err := parseFloat(s)
+		if err != nil {
+			return Rect{}, fmt.Errorf("value %q is not a number", s)
+		}
+		v[i] = n
+	}
+
+	return Rect{X: v[0], Y: v[1], Width: v[2], Height: v[3]}, nil
+}
+
+func formatPoint(p Point) string {
+	return formatFloat(p.X) + "," + formatFloat(p.Y)
+}
+
+func parsePoint(s string) (Point, error) {
+	xs, ys, ok := strings.Cut(s, ",")
+	if !ok {
+		return Point{}, fmt.Errorf("point %q must be in the format \"x,y\"", s)
+	}
+
+	x, err := parseFloat(xs)
+	if err != nil {
+		return Point{}, fmt.Errorf("value %q is not a number", xs)
+	}
+	y, err := parseFloat(ys)
+	if err != nil {
+		return Point{}, fmt.Errorf("value %q is not a number", ys)
+	}
+
+	return Point{X: x, Y: y}, nil
+}
+
+func formatPolygon(p Polygon) string {
+	s := make([]string, len(p))
+	for i, v := range p {
+		s[i] = formatPoint(v)
+	}
+	return strings.Join(s, " ")
+}
+
+func parsePolygon(s string) (Polygon, error) {
+	f := strings.Fields(s)
+	if len(f) < 3 {
+		return nil, fmt.Errorf("polygon must have at least 3 points, found %d", len(f))
+	}
+
+	p := make(Polygon, len(f))
+	for i, s := range f {
+		v, err := parsePoint(s)
+		if err != nil {
+			return nil, err
+		}
+		p[i] = v
+	}
+
+	return p, nil
+}
+
+// Shape is an area of a page image, used to define where interactions are.
+// It is implemented by [Point], [Rect] and [Polygon].
+type Shape interface {
+	// Bounds returns the smallest rectangle containing the shape.
+	Bounds() Rect
+	// Contains reports whether p is inside the shape.
+	Contains(p Point) bool
+}
+
+var (
+	_ Shape = Point{}
+	_ Shape = Rect{}
+	_ Shape = Polygon{}
+)
+
+// Bounds returns a rectangle with no area at p.
+func (p Point) Bounds() Rect {
+	return Rect{X: p.X, Y: p.Y}
+}
+
+// Contains reports whether o is the same point as p.
+func (p Point) Contains(o Point) bool {
+	return p == o
+}
+
+// Bounds returns r itself.
+func (r Rect) Bounds() Rect {
+	return r
+}
+
+// Contains reports whether pt is inside of p, using the even-odd rule.
+func (p Polygon) Contains(pt Point) bool {
+	in := false
+	for i, j := 0, len(p)-1; i < len(p); j, i = i, i+1 {
+		a, b := p[i], p[j]
+		if (a.Y > pt.Y) != (b.Y > pt.Y) && pt.X < (b.X-a.X)*(pt.Y-a.Y)/(b.Y-a.Y)+a.X {
+			in = !in
+		}
+	}
+	return in
+}
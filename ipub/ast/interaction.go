@@ -0,0 +1,307 @@
+package ast
+
+import (
+	"encoding/xml"
+	"errors"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
+)
+
+// Interaction is implemented by the nodes which readers can interact with, such
+// as links, by tapping or clicking inside their shape on the page.
+type Interaction interface {
+	Node
+
+	Shape() Shape
+}
+
+// Hotspot holds the shape shared by all [Interaction] nodes. It is embedded by
+// each interaction kind and can't be used as a node by itself.
+type Hotspot struct {
+	shape Shape
+
+	BaseNode
+}
+
+// Shape returns the area of the page covered by the interaction, or nil if it
+// doesn't have one.
+func (e Hotspot) Shape() Shape {
+	return e.shape
+}
+
+func (e *Hotspot) SetShape(s Shape) {
+	e.shape = s
+}
+
+var hotspotPointAttrName = xml.Name{Local: "data-ipub-point"}
+
+func (e Hotspot) MarshalXMLAttrs() ([]xml.Attr, error) {
+	switch s := e.shape.(type) {
+	case nil:
+		return []xml.Attr{}, nil
+	case Point:
+		return []xml.Attr{{Name: hotspotPointAttrName, Value: formatPoint(s)}}, nil
+	case Rect:
+		return []xml.Attr{{Name: panelBoundsAttrName, Value: formatRect(s)}}, nil
+	case Polygon:
+		return []xml.Attr{{Name: panelPolygonAttrName, Value: formatPolygon(s)}}, nil
+	default:
+		return nil, errors.New("unsupported shape type")
+	}
+}
+
+func (e *Hotspot) UnmarshalXMLAttrs(attrs []xml.Attr) error {
+	for _, a := range attrs {
+		var s Shape
+		var err error
+
+		switch a.Name {
+		case hotspotPointAttrName:
+			s, err = parsePoint(a.Value)
+		case panelBoundsAttrName:
+			s, err = parseRect(a.Value)
+		case panelPolygonAttrName:
+			s, err = parsePolygon(a.Value)
+		default:
+			continue
+		}
+
+		if err != nil {
+			return attr.ErrInvalidValue{Attr: a, Message: err.Error()}
+		}
+		if e.shape != nil {
+			return attr.ErrInvalidValue{Attr: a, Message: "interaction can only have one shape"}
+		}
+		e.shape = s
+	}
+	return nil
+}
+
+// Link is an interaction that opens a URL, such as an external page or a
+// resource of the package. Only the URLs allowed by [attr.URL] can be decoded.
+type Link struct {
+	href string
+
+	Hotspot
+}
+
+var KindLink = NewNodeKind("link", &Link{})
+
+func (e Link) Kind() NodeKind {
+	return KindLink
+}
+
+func (e Link) Href() string {
+	return e.href
+}
+
+func (e *Link) SetHref(href string) {
+	e.href = href
+}
+
+func (e *Link) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	return marshalNode(enc, e, "a")
+}
+
+func (e *Link) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return unmarshalNode(d, e, start)
+}
+
+func (e Link) MarshalXMLAttrs() ([]xml.Attr, error) {
+	attrs, err := e.Hotspot.MarshalXMLAttrs()
+	if err != nil {
+		return nil, err
+	}
+	return append(attrs, xml.Attr{Name: xml.Name{Local: "href"}, Value: e.href}), nil
+}
+
+func (e *Link) UnmarshalXMLAttrs(attrs []xml.Attr) error {
+	for _, a := range attrs {
+		if a.Name == (xml.Name{Local: "href"}) {
+			var u attr.URL
+			if err := u.UnmarshalXMLAttr(a); err != nil {
+				return err
+			}
+			e.href = u.String()
+		}
+	}
+	return e.Hotspot.UnmarshalXMLAttrs(attrs)
+}
+
+// Jump is an interaction that moves the reader to another section of the
+// package, or to a node inside of it, such as a panel.
+type Jump struct {
+	section string
+	path    string
+
+	Hotspot
+}
+
+var KindJump = NewNodeKind("jump", &Jump{})
+
+func (e Jump) Kind() NodeKind {
+	return KindJump
+}
+
+// Section returns the href of the target section. If empty, the target is in the
+// same section as the jump.
+func (e Jump) Section() string {
+	return e.section
+}
+
+func (e *Jump) SetSection(href string) {
+	e.section = href
+}
+
+// Path returns the path of the target node inside of the target section, relative
+// to it, as accepted by [FindPath]. If empty, the target is the section itself.
+func (e Jump) Path() string {
+	return e.path
+}
+
+func (e *Jump) SetPath(p string) {
+	e.path = p
+}
+
+// Target returns the node which the jump points to, or nil if it doesn't exist.
+// Targets in other sections are looked up in pkg, which can be nil if the jump
+// is known to point to its own section.
+func (e *Jump) Target(pkg *Package) Node {
+	return findTarget(e, pkg, e.section, e.path)
+}
+
+// findTarget resolves the node at path inside the section with the given href,
+// which is looked up in pkg unless it is the section that holds n. An empty href
+// means the section of n.
+func findTarget(n Node, pkg *Package, href, path string) Node {
+	s, _ := FindAncestor(n, KindSection).(*Section)
+	if href != "" && (s == nil || s.Href() != href) {
+		s = nil
+		if pkg != nil {
+			for _, ps := range pkg.Sections() {
+				if ps.Href() == href {
+					s = ps
+					break
+				}
+			}
+		}
+	}
+	if s == nil {
+		return nil
+	}
+	return FindPath(s, path)
+}
+
+func (e *Jump) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	return marshalNode(enc, e, "a")
+}
+
+func (e *Jump) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return unmarshalNode(d, e, start)
+}
+
+var (
+	jumpSectionAttrName = xml.Name{Local: "data-ipub-section"}
+	jumpPathAttrName    = xml.Name{Local: "data-ipub-path"}
+)
+
+func (e Jump) MarshalXMLAttrs() ([]xml.Attr, error) {
+	attrs, err := e.Hotspot.MarshalXMLAttrs()
+	if err != nil {
+		return nil, err
+	}
+	if e.section != "" {
+		attrs = append(attrs, xml.Attr{Name: jumpSectionAttrName, Value: e.section})
+	}
+	if e.path != "" {
+		attrs = append(attrs, xml.Attr{Name: jumpPathAttrName, Value: e.path})
+	}
+	return attrs, nil
+}
+
+func (e *Jump) UnmarshalXMLAttrs(attrs []xml.Attr) error {
+	for _, a := range attrs {
+		switch a.Name {
+		case jumpSectionAttrName:
+			e.section = a.Value
+		case jumpPathAttrName:
+			e.path = a.Value
+		}
+	}
+	return e.Hotspot.UnmarshalXMLAttrs(attrs)
+}
+
+// Reveal is an interaction that toggles the visibility of a [Layer] in the same
+// section.
+type Reveal struct {
+	layer string
+
+	Hotspot
+}
+
+var KindReveal = NewNodeKind("reveal", &Reveal{})
+
+func (e Reveal) Kind() NodeKind {
+	return KindReveal
+}
+
+// Layer returns the name of the layer toggled by the interaction.
+func (e Reveal) Layer() string {
+	return e.layer
+}
+
+func (e *Reveal) SetLayer(name string) {
+	e.layer = name
+}
+
+// Target returns the layer toggled by the interaction in its section, or nil if
+// it doesn't exist.
+func (e *Reveal) Target() *Layer {
+	var root Node = e
+	for p := e.Parent(); p != nil && root.Kind() != KindSection; p = p.Parent() {
+		root = p
+	}
+
+	var l *Layer
+	_ = Walk(root, func(n Node, entering bool) (WalkStatus, error) {
+		if n, ok := n.(*Layer); ok && entering && n.name == e.layer {
+			l = n
+			return WalkStop, nil
+		}
+		return WalkContinue, nil
+	})
+	return l
+}
+
+func (e *Reveal) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	return marshalNode(enc, e, "button")
+}
+
+func (e *Reveal) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return unmarshalNode(d, e, start)
+}
+
+var revealLayerAttrName = xml.Name{Local: "data-ipub-layer"}
+
+func (e Reveal) MarshalXMLAttrs() ([]xml.Attr, error) {
+	attrs, err := e.Hotspot.MarshalXMLAttrs()
+	if err != nil {
+		return nil, err
+	}
+	return append(attrs, xml.Attr{Name: revealLayerAttrName, Value: e.layer}), nil
+}
+
+func (e *Reveal) UnmarshalXMLAttrs(attrs []xml.Attr) error {
+	for _, a := range attrs {
+		if a.Name == revealLayerAttrName {
+			e.layer = a.Value
+		}
+	}
+	return e.Hotspot.UnmarshalXMLAttrs(attrs)
+}
+
+var (
+	_ Interaction = (*Link)(nil)
+	_ Interaction = (*Jump)(nil)
+	_ Interaction = (*Reveal)(nil)
+)
@@ -0,0 +1,114 @@
+package ast_test
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/loreddev/x/tinyssert"
+)
+
+func TestInteractions(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	s := []byte(`
+	<html>
+		<body data-ipub-element="body">
+			<section data-ipub-element="content">
+				<div data-ipub-element="panel" data-ipub-bounds="0 0 100 50"></div>
+				<div data-ipub-element="panel" data-ipub-bounds="0 50 100 50"></div>
+				<div data-ipub-element="layer" data-ipub-name="secret" hidden=""></div>
+				<a data-ipub-element="link" data-ipub-point="10,20" href="https://example.com"></a>
+				<a data-ipub-element="jump" data-ipub-bounds="0 90 100 10" data-ipub-path="body[0]/content[0]/panel[1]"></a>
+				<a data-ipub-element="jump" data-ipub-section="sections/002.xhtml"></a>
+				<button data-ipub-element="reveal" data-ipub-polygon="0,0 10,0 0,10" data-ipub-layer="secret"></button>
+			</section>
+		</body>
+	</html>
+	`)
+
+	var sec ast.Section
+	if err := xml.Unmarshal(s, &sec); err != nil {
+		t.Fatal(err)
+	}
+	sec.SetHref("sections/001.xhtml")
+
+	other := &ast.Section{}
+	other.SetHref("sections/002.xhtml")
+
+	pkg := &ast.Package{}
+	pkg.AppendChild(pkg, &sec)
+	pkg.AppendChild(pkg, other)
+
+	c := sec.Body().FirstChild()
+	panels := c.(*ast.Content).Panels()
+
+	layer := ast.FindFirst(c, ast.KindLayer).(*ast.Layer)
+	assert.Equal("secret", layer.Name())
+	assert.Equal(true, layer.Hidden())
+
+	link := ast.FindFirst(c, ast.KindLink).(*ast.Link)
+	assert.Equal("https://example.com", link.Href())
+	assert.Equal(ast.Shape(ast.Point{X: 10, Y: 20}), link.Shape())
+
+	jumps := ast.FindAll(c, ast.KindJump)
+	assert.Equal(2, len(jumps))
+	assert.Equal(ast.Shape(ast.Rect{X: 0, Y: 90, Width: 100, Height: 10}), jumps[0].(*ast.Jump).Shape())
+	assert.Equal(ast.Node(panels[1]), jumps[0].(*ast.Jump).Target(nil))
+	assert.Equal(ast.Node(nil), jumps[1].(*ast.Jump).Target(nil))
+	assert.Equal(ast.Node(other), jumps[1].(*ast.Jump).Target(pkg))
+
+	reveal := ast.FindFirst(c, ast.KindReveal).(*ast.Reveal)
+	assert.Equal(ast.Shape(ast.Polygon{{0, 0}, {10, 0}, {0, 10}}), reveal.Shape())
+	assert.Equal(layer, reveal.Target())
+}
+
+func TestInteractionInvalid(t *testing.T) {
+	tests := map[string]string{
+		"two shapes":      `data-ipub-point="10,20" data-ipub-bounds="0 0 10 10" href="https://example.com"`,
+		"javascript href": `data-ipub-point="10,20" href="javascript:alert(document.cookie)"`,
+		"data href":       `data-ipub-point="10,20" href="data:text/html,&lt;script&gt;alert(1)&lt;/script&gt;"`,
+		"absolute href":   `data-ipub-point="10,20" href="/"`,
+	}
+
+	for name, a := range tests {
+		t.Run(name, func(t *testing.T) {
+			var l ast.Link
+			if err := xml.Unmarshal([]byte(`<a data-ipub-element="link" `+a+`></a>`), &l); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestShapeContains(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	triangle := ast.Polygon{{0, 0}, {10, 0}, {0, 10}}
+	assert.Equal(true, triangle.Contains(ast.Point{X: 2, Y: 2}))
+	assert.Equal(false, triangle.Contains(ast.Point{X: 8, Y: 8}))
+
+	r := ast.Rect{X: 10, Y: 10, Width: 20, Height: 20}
+	assert.Equal(true, r.Contains(ast.Point{X: 30, Y: 30}))
+	assert.Equal(false, r.Contains(ast.Point{X: 31, Y: 30}))
+
+	assert.Equal(true, ast.Point{X: 1, Y: 1}.Contains(ast.Point{X: 1, Y: 1}))
+}
+
+func TestFindPath(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	pkg, images := newTree()
+
+	assert.Equal(ast.Node(pkg), ast.FindPath(pkg, ""))
+	assert.Equal(ast.Node(images[2]), ast.FindPath(pkg, "section[1]/body[0]/content[0]/image[0]"))
+	assert.Equal(ast.Node(nil), ast.FindPath(pkg, "section[2]"))
+	assert.Equal(ast.Node(nil), ast.FindPath(pkg, "section"))
+
+	for _, i := range images {
+		p := ast.Path(i)
+		_, rel, _ := strings.Cut(p, "/")
+		assert.Equal(ast.Node(i), ast.FindPath(pkg, rel))
+	}
+}
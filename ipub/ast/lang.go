@@ -0,0 +1,183 @@
+package ast
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
+)
+
+// XMLNamespace is the namespace bound to the "xml" prefix, used by attributes such
+// as xml:lang.
+const XMLNamespace = "http://www.w3.org/XML/1998/namespace"
+
+var langAttrName = xml.Name{Space: XMLNamespace, Local: "lang"}
+
+// Alternate is a version of the text of its parent node in a specific language.
+// Text-bearing nodes, such as dialogs, can have one alternate per language as
+// children instead of [Text] runs, with one of them marked as the source language
+// that the others were translated from.
+type Alternate struct {
+	lang   string
+	source bool
+
+	BaseNode
+}
+
+var KindAlternate = NewNodeKind("alternate", &Alternate{})
+
+func (e Alternate) Kind() NodeKind {
+	return KindAlternate
+}
+
+// Lang returns the BCP 47 language tag of the alternate, such as "pt-BR".
+func (e Alternate) Lang() string {
+	return e.lang
+}
+
+func (e *Alternate) SetLang(l string) {
+	e.lang = l
+}
+
+// IsSource reports whether the alternate is in the source language of its parent.
+func (e Alternate) IsSource() bool {
+	return e.source
+}
+
+func (e *Alternate) SetIsSource(v bool) {
+	e.source = v
+}
+
+func (e *Alternate) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	return marshalNode(enc, e, "span")
+}
+
+func (e *Alternate) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return unmarshalNode(d, e, start)
+}
+
+var alternateSourceAttrName = xml.Name{Local: "data-ipub-source"}
+
+func (e Alternate) MarshalXMLAttrs() ([]xml.Attr, error) {
+	attrs := []xml.Attr{{Name: langAttrName, Value: e.lang}}
+	if e.source {
+		attrs = append(attrs, xml.Attr{Name: alternateSourceAttrName, Value: "true"})
+	}
+	return attrs, nil
+}
+
+func (e *Alternate) UnmarshalXMLAttrs(attrs []xml.Attr) error {
+	for _, a := range attrs {
+		switch a.Name {
+		case langAttrName:
+			var l attr.Lang
+			if err := l.UnmarshalXMLAttr(a); err != nil {
+				return err
+			}
+			e.lang = l.String()
+		case alternateSourceAttrName:
+			e.source = a.Value == "true"
+		}
+	}
+	return nil
+}
+
+// Alternates returns all [Alternate] children of n.
+func Alternates(n Node) []*Alternate {
+	as := []*Alternate{}
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		if a, ok := c.(*Alternate); ok {
+			as = append(as, a)
+		}
+	}
+	return as
+}
+
+// SourceAlternate returns the alternate of n marked as the source language. If
+// none is marked, the first alternate is returned. It returns nil if n has no
+// alternates.
+func SourceAlternate(n Node) *Alternate {
+	as := Alternates(n)
+	if len(as) == 0 {
+		return nil
+	}
+	for _, a := range as {
+		if a.source {
+			return a
+		}
+	}
+	return as[0]
+}
+
+// FallbackChain returns the language tags to try, in order, when looking up text
+// for the given languages. Each tag is followed by its less specific versions, so
+// FallbackChain("pt-BR", "en") returns ["pt-BR", "pt", "en"]. Duplicated tags are
+// removed.
+func FallbackChain(langs ...string) []string {
+	chain := []string{}
+	for _, l := range langs {
+		for l != "" {
+			if !containsLang(chain, l) {
+				chain = append(chain, l)
+			}
+			i := strings.LastIndex(l, "-")
+			if i == -1 {
+				break
+			}
+			l = l[:i]
+		}
+	}
+	return chain
+}
+
+func containsLang(langs []string, l string) bool {
+	for _, v := range langs {
+		if strings.EqualFold(v, l) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveAlternate returns the alternate of n with the first language of chain
+// that it has, comparing tags case-insensitively. If no language in the chain is
+// available, the source alternate is returned. It returns nil if n has no
+// alternates.
+func ResolveAlternate(n Node, chain []string) *Alternate {
+	as := Alternates(n)
+	for _, l := range chain {
+		for _, a := range as {
+			if strings.EqualFold(a.lang, l) {
+				return a
+			}
+		}
+	}
+	return SourceAlternate(n)
+}
+
+// Localize resolves the tree of n for the given languages, using their
+// [FallbackChain]. Every node with alternates keeps only the one returned by
+// [ResolveAlternate], so renderers and exporters can handle a single language.
+//
+// The tree is changed in place. To keep all languages, decode the section again.
+func Localize(n Node, langs ...string) {
+	chain := FallbackChain(langs...)
+
+	parents := []Node{}
+	_ = Walk(n, func(n Node, entering bool) (WalkStatus, error) {
+		if entering && len(Alternates(n)) > 0 {
+			parents = append(parents, n)
+			return WalkSkipChildren, nil
+		}
+		return WalkContinue, nil
+	})
+
+	for _, p := range parents {
+		keep := ResolveAlternate(p, chain)
+		for _, a := range Alternates(p) {
+			if a != keep {
+				p.RemoveChild(p, a)
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package ast_test
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/loreddev/x/tinyssert"
+)
+
+func TestFallbackChain(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	assert.Equal([]string{"pt-BR", "pt", "en"}, ast.FallbackChain("pt-BR", "en"))
+	assert.Equal([]string{"zh-Hant-TW", "zh-Hant", "zh", "en-US", "en"}, ast.FallbackChain("zh-Hant-TW", "en-US", "EN"))
+	assert.Equal([]string{}, ast.FallbackChain())
+}
+
+func TestLocalize(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	s := []byte(`
+	<body data-ipub-element="body">
+		<section data-ipub-element="content">
+			<p data-ipub-element="speech" data-ipub-anchor="0,0">
+				<span data-ipub-element="alternate" xml:lang="ja">
+					<span data-ipub-element="text">こんにちは</span>
+				</span>
+				<span data-ipub-element="alternate" xml:lang="en" data-ipub-source="true">
+					<span data-ipub-element="text">Hello</span>
+				</span>
+				<span data-ipub-element="alternate" xml:lang="pt">
+					<span data-ipub-element="text">Olá</span>
+				</span>
+			</p>
+			<p data-ipub-element="caption" data-ipub-anchor="0,0">
+				<span data-ipub-element="alternate" xml:lang="en" data-ipub-source="true">
+					<span data-ipub-element="text">Later</span>
+				</span>
+				<span data-ipub-element="alternate" xml:lang="es">
+					<span data-ipub-element="text">Después</span>
+				</span>
+			</p>
+		</section>
+	</body>
+	`)
+
+	var b ast.Body
+	if err := xml.Unmarshal(s, &b); err != nil {
+		t.Fatal(err)
+	}
+
+	speech := b.FirstChild().FirstChild()
+	caption := speech.NextSibling()
+
+	assert.Equal(3, len(ast.Alternates(speech)))
+	assert.Equal("en", ast.SourceAlternate(speech).Lang())
+	assert.Equal("Hello", ast.DialogText(speech))
+
+	chain := ast.FallbackChain("pt-BR", "ja")
+	assert.Equal("pt", ast.ResolveAlternate(speech, chain).Lang())
+	assert.Equal("en", ast.ResolveAlternate(caption, chain).Lang())
+
+	ast.Localize(&b, "pt-BR", "ja")
+
+	assert.Equal(uint(1), speech.ChildCount())
+	assert.Equal("Olá", ast.DialogText(speech))
+	assert.Equal(uint(1), caption.ChildCount())
+	assert.Equal("Later", ast.DialogText(caption))
+}
+
+func TestLangInvalid(t *testing.T) {
+	alternate := `<body data-ipub-element="body"><p data-ipub-element="speech">` +
+		`<span data-ipub-element="alternate" xml:lang="pt BR"></span></p></body>`
+	if err := xml.Unmarshal([]byte(alternate), &ast.Body{}); err == nil {
+		t.Error("expected error for alternate language, got nil")
+	}
+
+	section := `<html xmlns="http://www.w3.org/1999/xhtml" xml:lang="e"><body data-ipub-element="body"></body></html>`
+	if err := xml.Unmarshal([]byte(section), &ast.Section{}); err == nil {
+		t.Error("expected error for section language, got nil")
+	}
+}
@@ -0,0 +1,149 @@
+package ast
+
+import (
+	"encoding/xml"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
+)
+
+// Layout is how the pages of a [Package] or [Section] are laid out by readers.
+// Empty fields are inherited from the parent, see [ResolveLayout].
+type Layout struct {
+	Progression Progression
+	Spread      SpreadMode
+}
+
+// Progression is the direction in which pages are read.
+type Progression string
+
+const (
+	// ProgressionDefault is inherited from the parent, or left to right if no
+	// parent sets it.
+	ProgressionDefault     Progression = ""
+	ProgressionLeftToRight Progression = "ltr"
+	// ProgressionRightToLeft is used mostly by manga.
+	ProgressionRightToLeft Progression = "rtl"
+	// ProgressionVertical shows pages one below the other in a continuous scroll,
+	// used mostly by webtoons. Spreads are not used.
+	ProgressionVertical Progression = "vertical"
+)
+
+func (Progression) Values() []Progression {
+	return []Progression{ProgressionLeftToRight, ProgressionRightToLeft, ProgressionVertical}
+}
+
+// SpreadMode is how many pages are shown at once.
+type SpreadMode string
+
+const (
+	// SpreadAuto is inherited from the parent, or left for the reader to choose,
+	// such as based on the size of the screen, if no parent sets it.
+	SpreadAuto   SpreadMode = ""
+	SpreadSingle SpreadMode = "single"
+	// SpreadDouble shows pages in pairs, side by side in the order of the
+	// progression. Pages which are marked as spreads, see [Content.Spread], are
+	// shown alone.
+	SpreadDouble SpreadMode = "double"
+)
+
+func (SpreadMode) Values() []SpreadMode {
+	return []SpreadMode{SpreadSingle, SpreadDouble}
+}
+
+// Inherit returns l with its empty fields set to the ones of parent.
+func (l Layout) Inherit(parent Layout) Layout {
+	if l.Progression == ProgressionDefault {
+		l.Progression = parent.Progression
+	}
+	if l.Spread == SpreadAuto {
+		l.Spread = parent.Spread
+	}
+	return l
+}
+
+// ResolveLayout returns the layout used by n, which is the one of its section
+// inheriting from the one of its package.
+func ResolveLayout(n Node) Layout {
+	var l Layout
+	for ; n != nil; n = n.Parent() {
+		switch n := n.(type) {
+		case *Section:
+			l = l.Inherit(n.Layout)
+		case *Package:
+			l = l.Inherit(n.Layout)
+		}
+	}
+	return l
+}
+
+var (
+	layoutProgressionAttrName = xml.Name{Local: "data-ipub-progression"}
+	layoutSpreadAttrName      = xml.Name{Local: "data-ipub-spread-mode"}
+)
+
+// marshalAttrs returns the attributes of the layout's non-empty fields.
+func (l Layout) marshalAttrs() ([]xml.Attr, error) {
+	p, err := attr.Enum[Progression]{Value: l.Progression}.MarshalXMLAttr(layoutProgressionAttrName)
+	if err != nil {
+		return nil, err
+	}
+	s, err := attr.Enum[SpreadMode]{Value: l.Spread}.MarshalXMLAttr(layoutSpreadAttrName)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := []xml.Attr{}
+	for _, a := range []xml.Attr{p, s} {
+		if a.Name.Local != "" {
+			attrs = append(attrs, a)
+		}
+	}
+	return attrs, nil
+}
+
+// unmarshalAttr decodes a if it is one of the attributes of the layout.
+func (l *Layout) unmarshalAttr(a xml.Attr) error {
+	switch a.Name {
+	case layoutProgressionAttrName:
+		var v attr.Enum[Progression]
+		if err := v.UnmarshalXMLAttr(a); err != nil {
+			return err
+		}
+		l.Progression = v.Value
+
+	case layoutSpreadAttrName:
+		var v attr.Enum[SpreadMode]
+		if err := v.UnmarshalXMLAttr(a); err != nil {
+			return err
+		}
+		l.Spread = v.Value
+	}
+	return nil
+}
+
+// Spreads groups the [Content] children of b, its pages, into the ones shown at
+// once by readers, in reading order.
+//
+// With [SpreadDouble], pages are paired, except the ones marked as spreads, which
+// are always alone and leave the page before them alone if it had no pair. With
+// other spread modes, and with [ProgressionVertical], each page is alone.
+func Spreads(b *Body) [][]*Content {
+	l := ResolveLayout(b)
+	double := l.Spread == SpreadDouble && l.Progression != ProgressionVertical
+
+	spreads := [][]*Content{}
+	for c := b.FirstChild(); c != nil; c = c.NextSibling() {
+		content, ok := c.(*Content)
+		if !ok {
+			continue
+		}
+
+		last := len(spreads) - 1
+		if double && !content.Spread() && last >= 0 && len(spreads[last]) == 1 && !spreads[last][0].Spread() {
+			spreads[last] = append(spreads[last], content)
+			continue
+		}
+		spreads = append(spreads, []*Content{content})
+	}
+	return spreads
+}
@@ -0,0 +1,79 @@
+package ast_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"testing"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
+	"forge.capytal.company/loreddev/x/tinyssert"
+)
+
+func TestLayout(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	const doc = `<html xmlns="http://www.w3.org/1999/xhtml" data-ipub-progression="rtl">` +
+		`<body data-ipub-element="body">` +
+		`<section data-ipub-element="content" id="p1"></section>` +
+		`<section data-ipub-element="content" id="p2"></section>` +
+		`<section data-ipub-element="content" id="p3"></section>` +
+		`<section data-ipub-element="content" id="p4" data-ipub-spread="true"></section>` +
+		`<section data-ipub-element="content" id="p5"></section>` +
+		`</body></html>`
+
+	var s ast.Section
+	if err := xml.Unmarshal([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(ast.Layout{Progression: ast.ProgressionRightToLeft}, s.Layout)
+
+	pkg := &ast.Package{Layout: ast.Layout{Progression: ast.ProgressionLeftToRight, Spread: ast.SpreadDouble}}
+	pkg.AppendChild(pkg, &s)
+
+	p4 := ast.FindID(&s, "p4")
+	assert.Equal(true, p4.(*ast.Content).Spread())
+	assert.Equal(ast.Layout{Progression: ast.ProgressionRightToLeft, Spread: ast.SpreadDouble}, ast.ResolveLayout(p4))
+
+	ids := func() [][]string {
+		spreads := [][]string{}
+		for _, s := range ast.Spreads(s.Body()) {
+			ids := []string{}
+			for _, c := range s {
+				ids = append(ids, c.ID())
+			}
+			spreads = append(spreads, ids)
+		}
+		return spreads
+	}
+	assert.Equal([][]string{{"p1", "p2"}, {"p3"}, {"p4"}, {"p5"}}, ids())
+
+	s.Layout.Progression = ast.ProgressionVertical
+	assert.Equal([][]string{{"p1"}, {"p2"}, {"p3"}, {"p4"}, {"p5"}}, ids())
+
+	s.Layout = ast.Layout{Spread: ast.SpreadSingle}
+	b, err := xml.Marshal(&s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rs ast.Section
+	if err := xml.Unmarshal(b, &rs); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(s.Layout, rs.Layout)
+}
+
+func TestLayoutInvalid(t *testing.T) {
+	var aerr attr.ErrInvalidValue
+
+	var s ast.Section
+	err := xml.Unmarshal([]byte(`<html data-ipub-spread-mode="triple"></html>`), &s)
+	if !errors.As(err, &aerr) {
+		t.Errorf("expected error %T for invalid spread mode, got %v", aerr, err)
+	}
+
+	s = ast.Section{Layout: ast.Layout{Progression: "up"}}
+	if _, err := xml.Marshal(s); !errors.As(err, &aerr) {
+		t.Errorf("expected error %T for invalid progression, got %v", aerr, err)
+	}
+}
@@ -0,0 +1,331 @@
+package ast
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DublinCoreNamespace = "http://purl.org/dc/elements/1.1/"
+
+// Metadata describes the publication of a [Package]. It is encoded as the
+// metadata element of an EPUB 3 package document (OPF), with Dublin Core elements
+// and meta properties, so it can be copied as-is into exported publications.
+type Metadata struct {
+	// Identifier is the unique identifier of the publication, such as
+	// "urn:uuid:<uuid>".
+	Identifier string
+	// ISBN is the International Standard Book Number of the publication, without
+	// the "urn:isbn:" prefix.
+	ISBN string
+
+	Title     string
+	Subtitles []string
+	Creators  []Creator
+	Publisher string
+	// Language is the BCP 47 language tag of the publication.
+	Language    string
+	Description string
+
+	// Series is the name of the series the publication is part of, and
+	// SeriesPosition its position in it, starting at 1. Zero if not set.
+	Series         string
+	SeriesPosition float64
+	// Issue is the issue number, such as "1" or "1.5".
+	Issue string
+
+	// AgeRating is the audience the publication is rated for, such as "Teen" or
+	// "Mature 17+".
+	AgeRating string
+	// License is the rights statement of the publication, such as "CC BY 4.0".
+	License string
+
+	Modified time.Time
+
+	// Meta are other meta properties of the publication, which are kept as-is.
+	Meta []MetaProperty
+}
+
+// Creator is a person or organization credited for a publication.
+type Creator struct {
+	Name string
+	// FileAs is the name used to sort the creator, such as "Doe, Jane".
+	FileAs string
+	Role   Role
+}
+
+// Role is a MARC relator code of the contribution of a [Creator].
+type Role string
+
+const (
+	RoleAuthor      Role = "aut"
+	RoleArtist      Role = "art"
+	RoleIllustrator Role = "ill"
+	RoleColorist    Role = "clr"
+	RoleCoverArtist Role = "cov"
+	RoleEditor      Role = "edt"
+	RoleTranslator  Role = "trl"
+	// RoleLetterer uses the calligrapher code, since MARC has no relator for
+	// letterers.
+	RoleLetterer Role = "cll"
+)
+
+// CreatorsByRole returns the names of the creators with the given role, in order.
+func (m Metadata) CreatorsByRole(r Role) []string {
+	names := []string{}
+	for _, c := range m.Creators {
+		if c.Role == r {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// MetaProperty is a meta element of the package document, such as
+// <meta property="rendition:layout">pre-paginated</meta>.
+type MetaProperty struct {
+	Property string
+	Value    string
+}
+
+// ID of the identifier element referenced by the unique-identifier attribute of
+// EPUB package documents.
+const PublicationIDRef = "pub-id"
+
+const (
+	isbnPrefix = "urn:isbn:"
+
+	modifiedProperty   = "dcterms:modified"
+	collectionProperty = "belongs-to-collection"
+	issueProperty      = "schema:issueNumber"
+	ageRatingProperty  = "schema:contentRating"
+)
+
+func (m Metadata) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xmlns:dc"}, Value: DublinCoreNamespace})
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	elem := func(name, value string, attrs ...xml.Attr) error {
+		return enc.EncodeElement(value, xml.StartElement{Name: xml.Name{Local: name}, Attr: attrs})
+	}
+	id := func(id string) xml.Attr {
+		return xml.Attr{Name: xml.Name{Local: "id"}, Value: id}
+	}
+	meta := func(property, value string, attrs ...xml.Attr) error {
+		attrs = append([]xml.Attr{{Name: xml.Name{Local: "property"}, Value: property}}, attrs...)
+		return elem("meta", value, attrs...)
+	}
+	refines := func(id string) xml.Attr {
+		return xml.Attr{Name: xml.Name{Local: "refines"}, Value: "#" + id}
+	}
+
+	errs := []error{}
+
+	if m.Identifier != "" {
+		errs = append(errs, elem("dc:identifier", m.Identifier, id(PublicationIDRef)))
+	}
+	if m.ISBN != "" {
+		errs = append(errs, elem("dc:identifier", isbnPrefix+m.ISBN, id("isbn")))
+	}
+
+	if m.Title != "" {
+		errs = append(errs,
+			elem("dc:title", m.Title, id("title")),
+			meta("title-type", "main", refines("title")))
+	}
+	for i, s := range m.Subtitles {
+		sid := fmt.Sprintf("subtitle-%d", i+1)
+		errs = append(errs,
+			elem("dc:title", s, id(sid)),
+			meta("title-type", "subtitle", refines(sid)))
+	}
+
+	for i, c := range m.Creators {
+		cid := fmt.Sprintf("creator-%d", i+1)
+		errs = append(errs, elem("dc:creator", c.Name, id(cid)))
+		if c.Role != "" {
+			errs = append(errs, meta("role", string(c.Role), refines(cid),
+				xml.Attr{Name: xml.Name{Local: "scheme"}, Value: "marc:relators"}))
+		}
+		if c.FileAs != "" {
+			errs = append(errs, meta("file-as", c.FileAs, refines(cid)))
+		}
+	}
+
+	if m.Publisher != "" {
+		errs = append(errs, elem("dc:publisher", m.Publisher))
+	}
+	if m.Language != "" {
+		errs = append(errs, elem("dc:language", m.Language))
+	}
+	if m.Description != "" {
+		errs = append(errs, elem("dc:description", m.Description))
+	}
+	if m.License != "" {
+		errs = append(errs, elem("dc:rights", m.License))
+	}
+
+	if m.Series != "" {
+		errs = append(errs,
+			meta(collectionProperty, m.Series, id("series")),
+			meta("collection-type", "series", refines("series")))
+		if m.SeriesPosition != 0 {
+			errs = append(errs, meta("group-position", formatFloat(m.SeriesPosition), refines("series")))
+		}
+	}
+	if m.Issue != "" {
+		errs = append(errs, meta(issueProperty, m.Issue))
+	}
+	if m.AgeRating != "" {
+		errs = append(errs, meta(ageRatingProperty, m.AgeRating))
+	}
+	if !m.Modified.IsZero() {
+		errs = append(errs, meta(modifiedProperty, m.Modified.UTC().Format(time.RFC3339)))
+	}
+
+	for _, p := range m.Meta {
+		errs = append(errs, meta(p.Property, p.Value))
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return enc.EncodeToken(start.End())
+}
+
+// UnmarshalXML decodes the metadata element of a package document. Meta elements
+// which refine elements that are not part of [Metadata] are ignored.
+func (m *Metadata) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	*m = Metadata{}
+
+	dc := []opfElement{}
+	metas := []opfElement{}
+	// Refinements are stored by the ID of the element they refine and by property,
+	// since they can come before or after it.
+	refinements := map[string]map[string]string{}
+
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		var start xml.StartElement
+		switch t := t.(type) {
+		case xml.StartElement:
+			start = t
+		case xml.EndElement:
+			return m.resolve(dc, metas, refinements)
+		default:
+			continue
+		}
+
+		var e opfElement
+		if err := d.DecodeElement(&e, &start); err != nil {
+			return err
+		}
+		e.name = start.Name.Local
+		e.Value = strings.TrimSpace(e.Value)
+
+		switch {
+		case start.Name.Space == DublinCoreNamespace:
+			dc = append(dc, e)
+
+		case e.name == "meta" && e.Refines != "":
+			id := strings.TrimPrefix(e.Refines, "#")
+			if refinements[id] == nil {
+				refinements[id] = map[string]string{}
+			}
+			refinements[id][e.Property] = e.Value
+
+		case e.name == "meta" && e.Property != "":
+			metas = append(metas, e)
+		}
+	}
+}
+
+type opfElement struct {
+	name string
+
+	ID       string `xml:"id,attr"`
+	Property string `xml:"property,attr"`
+	Refines  string `xml:"refines,attr"`
+	Value    string `xml:",chardata"`
+}
+
+func (m *Metadata) resolve(dc, metas []opfElement, refinements map[string]map[string]string) error {
+	for _, e := range dc {
+		ref := refinements[e.ID]
+
+		switch e.name {
+		case "identifier":
+			if isbn, ok := strings.CutPrefix(e.Value, isbnPrefix); ok && e.ID != PublicationIDRef {
+				m.ISBN = isbn
+			} else if m.Identifier == "" || e.ID == PublicationIDRef {
+				m.Identifier = e.Value
+			}
+
+		case "title":
+			if ref["title-type"] == "subtitle" || (m.Title != "" && ref["title-type"] != "main") {
+				m.Subtitles = append(m.Subtitles, e.Value)
+			} else {
+				m.Title = e.Value
+			}
+
+		case "creator":
+			m.Creators = append(m.Creators, Creator{Name: e.Value, FileAs: ref["file-as"], Role: Role(ref["role"])})
+
+		case "publisher":
+			m.Publisher = e.Value
+		case "language":
+			m.Language = e.Value
+		case "description":
+			m.Description = e.Value
+		case "rights":
+			m.License = e.Value
+		}
+	}
+
+	for _, e := range metas {
+		switch e.Property {
+		case collectionProperty:
+			ref := refinements[e.ID]
+			if t := ref["collection-type"]; t != "" && t != "series" {
+				m.Meta = append(m.Meta, MetaProperty{Property: e.Property, Value: e.Value})
+				continue
+			}
+			m.Series = e.Value
+			if p, ok := ref["group-position"]; ok {
+				v, err := strconv.ParseFloat(p, 64)
+				if err != nil {
+					return fmt.Errorf("series position %q is not a number", p)
+				}
+				m.SeriesPosition = v
+			}
+
+		case issueProperty:
+			m.Issue = e.Value
+		case ageRatingProperty:
+			m.AgeRating = e.Value
+
+		case modifiedProperty:
+			t, err := time.Parse(time.RFC3339, e.Value)
+			if err != nil {
+				return fmt.Errorf("modified date %q is not a valid RFC 3339 date", e.Value)
+			}
+			m.Modified = t
+
+		default:
+			m.Meta = append(m.Meta, MetaProperty{Property: e.Property, Value: e.Value})
+		}
+	}
+
+	return nil
+}
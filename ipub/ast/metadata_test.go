@@ -0,0 +1,108 @@
+package ast_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/loreddev/x/tinyssert"
+)
+
+func TestMetadata(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	m := ast.Metadata{
+		Identifier: "urn:uuid:0195f3a4-7b2c-7000-8000-000000000000",
+		ISBN:       "9780000000002",
+		Title:      "The Docks",
+		Subtitles:  []string{"A Leo & Ana Story"},
+		Creators: []ast.Creator{
+			{Name: "Jane Doe", FileAs: "Doe, Jane", Role: ast.RoleAuthor},
+			{Name: "John Roe", Role: ast.RoleLetterer},
+		},
+		Publisher:      "Capytal",
+		Language:       "en",
+		Description:    "Ana and Leo at the docks.",
+		Series:         "Leo & Ana",
+		SeriesPosition: 2,
+		Issue:          "2",
+		AgeRating:      "Teen",
+		License:        "CC BY 4.0",
+		Modified:       time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Meta:           []ast.MetaProperty{{Property: "rendition:layout", Value: "pre-paginated"}},
+	}
+
+	var b bytes.Buffer
+	enc := xml.NewEncoder(&b)
+	enc.Indent("", "\t")
+	if err := enc.EncodeElement(m, xml.StartElement{Name: xml.Name{Local: "metadata"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+	<dc:identifier id="pub-id">urn:uuid:0195f3a4-7b2c-7000-8000-000000000000</dc:identifier>
+	<dc:identifier id="isbn">urn:isbn:9780000000002</dc:identifier>
+	<dc:title id="title">The Docks</dc:title>
+	<meta property="title-type" refines="#title">main</meta>
+	<dc:title id="subtitle-1">A Leo &amp; Ana Story</dc:title>
+	<meta property="title-type" refines="#subtitle-1">subtitle</meta>
+	<dc:creator id="creator-1">Jane Doe</dc:creator>
+	<meta property="role" refines="#creator-1" scheme="marc:relators">aut</meta>
+	<meta property="file-as" refines="#creator-1">Doe, Jane</meta>
+	<dc:creator id="creator-2">John Roe</dc:creator>
+	<meta property="role" refines="#creator-2" scheme="marc:relators">cll</meta>
+	<dc:publisher>Capytal</dc:publisher>
+	<dc:language>en</dc:language>
+	<dc:description>Ana and Leo at the docks.</dc:description>
+	<dc:rights>CC BY 4.0</dc:rights>
+	<meta property="belongs-to-collection" id="series">Leo &amp; Ana</meta>
+	<meta property="collection-type" refines="#series">series</meta>
+	<meta property="group-position" refines="#series">2</meta>
+	<meta property="schema:issueNumber">2</meta>
+	<meta property="schema:contentRating">Teen</meta>
+	<meta property="dcterms:modified">2025-01-01T00:00:00Z</meta>
+	<meta property="rendition:layout">pre-paginated</meta>
+</metadata>`
+	assert.Equal(expected, b.String())
+
+	var rm ast.Metadata
+	if err := xml.Unmarshal(b.Bytes(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(m, rm)
+	assert.Equal([]string{"John Roe"}, rm.CreatorsByRole(ast.RoleLetterer))
+}
+
+func TestMetadataOPF(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	// Package documents of EPUB publications use the OPF namespace by default.
+	s := `<metadata xmlns="http://www.idpf.org/2007/opf" xmlns:dc="http://purl.org/dc/elements/1.1/">
+		<dc:title>The Docks</dc:title>
+		<dc:title>A Leo &amp; Ana Story</dc:title>
+		<dc:identifier>urn:uuid:0195f3a4-7b2c-7000-8000-000000000000</dc:identifier>
+		<meta refines="#ana" property="role" scheme="marc:relators">art</meta>
+		<dc:creator id="ana">Ana</dc:creator>
+		<meta property="belongs-to-collection" id="c1">Leo &amp; Ana</meta>
+		<meta refines="#c1" property="group-position">1.5</meta>
+		<link rel="record" href="record.xml"/>
+	</metadata>`
+
+	var m ast.Metadata
+	if err := xml.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal("The Docks", m.Title)
+	assert.Equal([]string{"A Leo & Ana Story"}, m.Subtitles)
+	assert.Equal("urn:uuid:0195f3a4-7b2c-7000-8000-000000000000", m.Identifier)
+	assert.Equal([]ast.Creator{{Name: "Ana", Role: ast.RoleArtist}}, m.Creators)
+	assert.Equal("Leo & Ana", m.Series)
+	assert.Equal(1.5, m.SeriesPosition)
+	assert.Equal(0, len(m.Meta))
+
+	err := xml.Unmarshal([]byte(`<metadata><meta property="dcterms:modified">yesterday</meta></metadata>`), &m)
+	assert.NotNil(err)
+}
@@ -0,0 +1,235 @@
+package ast
+
+import (
+	"encoding/xml"
+	"fmt"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
+)
+
+// NavType is the purpose of a [Nav], following the navigation types of EPUB 3.
+type NavType string
+
+const (
+	// NavTOC is the table of contents of the package.
+	NavTOC NavType = "toc"
+	// NavLandmarks lists the structural points of the package, such as the cover
+	// and the start of the content. See [Landmark].
+	NavLandmarks NavType = "landmarks"
+)
+
+// Landmark is the structural role of an entry inside a [NavLandmarks] nav. Values
+// are the same as the epub:type ones of EPUB 3 landmarks.
+type Landmark string
+
+const (
+	LandmarkCover   Landmark = "cover"
+	LandmarkStart   Landmark = "bodymatter"
+	LandmarkCredits Landmark = "credits"
+)
+
+// Nav is a navigation document of a [Package], holding a nested list of
+// [NavEntry] children. Navs are direct children of the package, next to its
+// sections.
+type Nav struct {
+	navType NavType
+
+	BaseNode
+}
+
+var KindNav = NewNodeKind("nav", &Nav{})
+
+func (e Nav) Kind() NodeKind {
+	return KindNav
+}
+
+func (e Nav) Type() NavType {
+	return e.navType
+}
+
+func (e *Nav) SetType(t NavType) {
+	e.navType = t
+}
+
+// Entries returns the top-level entries of the nav.
+func (e *Nav) Entries() []*NavEntry {
+	return navEntries(e)
+}
+
+func (e *Nav) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	return marshalNode(enc, e, "nav")
+}
+
+func (e *Nav) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return unmarshalNode(d, e, start)
+}
+
+var navTypeAttrName = xml.Name{Local: "data-ipub-type"}
+
+func (e Nav) MarshalXMLAttrs() ([]xml.Attr, error) {
+	if e.navType == "" {
+		return []xml.Attr{}, nil
+	}
+	return []xml.Attr{{Name: navTypeAttrName, Value: string(e.navType)}}, nil
+}
+
+func (e *Nav) UnmarshalXMLAttrs(attrs []xml.Attr) error {
+	for _, a := range attrs {
+		if a.Name == navTypeAttrName {
+			e.navType = NavType(a.Value)
+		}
+	}
+	return nil
+}
+
+// NavEntry is an item of a [Nav], pointing to a section of the package or to a
+// node inside of it, such as a panel. Entries can have other entries as children,
+// to create nested lists such as volumes and their chapters.
+type NavEntry struct {
+	title    string
+	section  string
+	path     string
+	landmark Landmark
+
+	BaseNode
+}
+
+var KindNavEntry = NewNodeKind("nav-entry", &NavEntry{})
+
+func (e NavEntry) Kind() NodeKind {
+	return KindNavEntry
+}
+
+func (e NavEntry) Title() string {
+	return e.title
+}
+
+func (e *NavEntry) SetTitle(t string) {
+	e.title = t
+}
+
+// Section returns the href of the target section. Unlike [Jump], entries are not
+// inside a section, so it is required for the entry to have a target.
+func (e NavEntry) Section() string {
+	return e.section
+}
+
+func (e *NavEntry) SetSection(href string) {
+	e.section = href
+}
+
+// Path returns the path of the target node inside of the target section, relative
+// to it, as accepted by [FindPath]. If empty, the target is the section itself.
+func (e NavEntry) Path() string {
+	return e.path
+}
+
+func (e *NavEntry) SetPath(p string) {
+	e.path = p
+}
+
+// Landmark returns the structural role of the entry, or an empty string if it
+// doesn't have one.
+func (e NavEntry) Landmark() Landmark {
+	return e.landmark
+}
+
+func (e *NavEntry) SetLandmark(l Landmark) {
+	e.landmark = l
+}
+
+// Entries returns the entries nested inside of the entry.
+func (e *NavEntry) Entries() []*NavEntry {
+	return navEntries(e)
+}
+
+// Target returns the node which the entry points to, or nil if it doesn't exist
+// in pkg.
+func (e *NavEntry) Target(pkg *Package) Node {
+	if e.section == "" {
+		return nil
+	}
+	return findTarget(e, pkg, e.section, e.path)
+}
+
+func (e *NavEntry) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	return marshalNode(enc, e, "li")
+}
+
+func (e *NavEntry) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return unmarshalNode(d, e, start)
+}
+
+var (
+	navEntryTitleAttrName    = xml.Name{Local: "data-ipub-title"}
+	navEntryLandmarkAttrName = xml.Name{Local: "data-ipub-landmark"}
+)
+
+func (e NavEntry) MarshalXMLAttrs() ([]xml.Attr, error) {
+	attrs := []xml.Attr{{Name: navEntryTitleAttrName, Value: e.title}}
+	if e.section != "" {
+		attrs = append(attrs, xml.Attr{Name: jumpSectionAttrName, Value: e.section})
+	}
+	if e.path != "" {
+		attrs = append(attrs, xml.Attr{Name: jumpPathAttrName, Value: e.path})
+	}
+	if e.landmark != "" {
+		attrs = append(attrs, xml.Attr{Name: navEntryLandmarkAttrName, Value: string(e.landmark)})
+	}
+	return attrs, nil
+}
+
+func (e *NavEntry) UnmarshalXMLAttrs(attrs []xml.Attr) error {
+	for _, a := range attrs {
+		switch a.Name {
+		case navEntryTitleAttrName:
+			e.title = a.Value
+		case jumpSectionAttrName:
+			e.section = a.Value
+		case jumpPathAttrName:
+			e.path = a.Value
+		case navEntryLandmarkAttrName:
+			switch l := Landmark(a.Value); l {
+			case LandmarkCover, LandmarkStart, LandmarkCredits:
+				e.landmark = l
+			default:
+				return attr.ErrInvalidValue{Attr: a, Message: "unknown landmark"}
+			}
+		}
+	}
+	return nil
+}
+
+func navEntries(n Node) []*NavEntry {
+	es := []*NavEntry{}
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		if e, ok := c.(*NavEntry); ok {
+			es = append(es, e)
+		}
+	}
+	return es
+}
+
+// GenerateTOC creates a table of contents with one entry per section of pkg, in
+// spine order, titled after the sections. Sections without a title are named
+// after their position, and sections without an href are left out, since they
+// can't be pointed to.
+//
+// The returned nav is not added to pkg.
+func GenerateTOC(pkg *Package) *Nav {
+	nav := &Nav{navType: NavTOC}
+	for i, s := range pkg.Sections() {
+		if s.Href() == "" {
+			continue
+		}
+
+		t := s.Title
+		if t == "" {
+			t = fmt.Sprintf("Section %d", i+1)
+		}
+
+		e := &NavEntry{title: t, section: s.Href()}
+		nav.AppendChild(nav, e)
+	}
+	return nav
+}
@@ -0,0 +1,126 @@
+package ast_test
+
+import (
+	"encoding/xml"
+	"fmt"
+	"testing"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/loreddev/x/tinyssert"
+)
+
+func TestNav(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	s := []byte(`
+	<nav data-ipub-element="nav" data-ipub-type="toc">
+		<li data-ipub-element="nav-entry" data-ipub-title="Volume 1" data-ipub-section="sections/001.xhtml">
+			<li data-ipub-element="nav-entry" data-ipub-title="Chapter 1" data-ipub-section="sections/001.xhtml"></li>
+			<li data-ipub-element="nav-entry" data-ipub-title="The Reveal" data-ipub-section="sections/002.xhtml" data-ipub-path="body[0]/content[0]/panel[1]"></li>
+		</li>
+		<li data-ipub-element="nav-entry" data-ipub-title="Missing" data-ipub-section="sections/404.xhtml"></li>
+	</nav>
+	`)
+
+	var nav ast.Nav
+	if err := xml.Unmarshal(s, &nav); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(ast.NavTOC, nav.Type())
+
+	s1 := &ast.Section{Title: "Chapter 1"}
+	s1.SetHref("sections/001.xhtml")
+	s1.SetBody(&ast.Body{})
+
+	s2 := &ast.Section{}
+	s2.SetHref("sections/002.xhtml")
+	s2.SetBody(&ast.Body{})
+	c := &ast.Content{}
+	s2.Body().AppendChild(s2.Body(), c)
+	p := &ast.Panel{}
+	c.AppendChild(c, &ast.Panel{})
+	c.AppendChild(c, p)
+
+	pkg := &ast.Package{}
+	pkg.AppendChild(pkg, s1)
+	pkg.AppendChild(pkg, s2)
+	pkg.AppendChild(pkg, &nav)
+
+	assert.Equal(&nav, pkg.Nav(ast.NavTOC))
+	assert.Equal((*ast.Nav)(nil), pkg.Nav(ast.NavLandmarks))
+	assert.Equal(2, len(pkg.Sections()))
+
+	es := nav.Entries()
+	assert.Equal(2, len(es))
+	assert.Equal("Volume 1", es[0].Title())
+	assert.Equal(ast.Node(s1), es[0].Target(pkg))
+	assert.Equal(ast.Node(nil), es[1].Target(pkg))
+
+	nested := es[0].Entries()
+	assert.Equal(2, len(nested))
+	assert.Equal(ast.Node(s1), nested[0].Target(pkg))
+	assert.Equal(ast.Node(p), nested[1].Target(pkg))
+	assert.Equal(ast.Node(nil), nested[1].Target(nil))
+}
+
+func TestNavLandmarks(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	var nav ast.Nav
+	err := xml.Unmarshal([]byte(`
+	<nav data-ipub-element="nav" data-ipub-type="landmarks">
+		<li data-ipub-element="nav-entry" data-ipub-title="Cover" data-ipub-section="sections/001.xhtml" data-ipub-landmark="cover"></li>
+		<li data-ipub-element="nav-entry" data-ipub-title="Start" data-ipub-section="sections/002.xhtml" data-ipub-landmark="bodymatter"></li>
+	</nav>
+	`), &nav)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	es := nav.Entries()
+	assert.Equal(ast.NavLandmarks, nav.Type())
+	assert.Equal(ast.LandmarkCover, es[0].Landmark())
+	assert.Equal(ast.LandmarkStart, es[1].Landmark())
+
+	err = xml.Unmarshal([]byte(
+		`<li data-ipub-element="nav-entry" data-ipub-title="?" data-ipub-landmark="index"></li>`,
+	), &ast.NavEntry{})
+	if err == nil {
+		t.Error("expected error for unknown landmark, got nil")
+	}
+}
+
+func TestGenerateTOC(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	pkg := &ast.Package{}
+	for i, title := range []string{"Prologue", "", "Epilogue"} {
+		s := &ast.Section{Title: title}
+		if i != 2 {
+			s.SetHref(fmt.Sprintf("sections/%03d.xhtml", i+1))
+		}
+		pkg.AppendChild(pkg, s)
+	}
+
+	nav := ast.GenerateTOC(pkg)
+	assert.Equal(ast.NavTOC, nav.Type())
+
+	es := nav.Entries()
+	assert.Equal(2, len(es))
+	assert.Equal("Prologue", es[0].Title())
+	assert.Equal("sections/001.xhtml", es[0].Section())
+	assert.Equal("Section 2", es[1].Title())
+	assert.Equal("sections/002.xhtml", es[1].Section())
+
+	b, err := xml.Marshal(nav)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(
+		`<nav data-ipub-element="nav" data-ipub-type="toc">`+
+			`<li data-ipub-element="nav-entry" data-ipub-title="Prologue" data-ipub-section="sections/001.xhtml"></li>`+
+			`<li data-ipub-element="nav-entry" data-ipub-title="Section 2" data-ipub-section="sections/002.xhtml"></li>`+
+			`</nav>`,
+		string(b),
+	)
+}
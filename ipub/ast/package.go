@@ -1,6 +1,15 @@
 package ast
 
+import "io"
+
 type Package struct {
+	Metadata  Metadata
+	Resources []Resource
+
+	// Layout is how the pages of all sections are laid out, unless a section
+	// overrides it. See [ResolveLayout].
+	Layout Layout
+
 	BaseNode
 }
 
@@ -9,3 +18,35 @@ var KindPackage = NewNodeKind("package", &Package{})
 func (e Package) Kind() NodeKind {
 	return KindPackage
 }
+
+// Resource is a non-section file stored inside a package, such as page images.
+type Resource struct {
+	Href      string // Path of the file relative to the package root
+	MediaType string
+
+	// Open is used to lazily read the resource's content, so packages with big
+	// files don't need to be fully loaded into memory.
+	Open func() (io.ReadCloser, error)
+}
+
+// Sections returns all [Section] children of the package, in spine order.
+func (e *Package) Sections() []*Section {
+	ss := []*Section{}
+	for c := e.FirstChild(); c != nil; c = c.NextSibling() {
+		if s, ok := c.(*Section); ok {
+			ss = append(ss, s)
+		}
+	}
+	return ss
+}
+
+// Nav returns the first [Nav] child of the package with the given type, or nil if
+// the package doesn't have one.
+func (e *Package) Nav(t NavType) *Nav {
+	for c := e.FirstChild(); c != nil; c = c.NextSibling() {
+		if n, ok := c.(*Nav); ok && n.Type() == t {
+			return n
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package ast
+
+import (
+	"encoding/xml"
+	"strconv"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
+)
+
+// Panel is a region of the page image of its parent [Content], used for
+// panel-by-panel reading and to describe the structure of the page.
+//
+// Its geometry is either a rectangle or a polygon, for non-rectangular panels.
+type Panel struct {
+	order   int
+	bounds  Rect
+	polygon Polygon
+
+	condition Condition
+
+	BaseNode
+}
+
+var KindPanel = NewNodeKind("panel", &Panel{})
+
+func (e Panel) Kind() NodeKind {
+	return KindPanel
+}
+
+// Order returns the reading-order index of the panel inside its page. Panels
+// are read from the lowest to the highest index.
+func (e Panel) Order() int {
+	return e.order
+}
+
+func (e *Panel) SetOrder(i int) {
+	e.order = i
+}
+
+// Bounds returns the bounding box of the panel. If the panel is a polygon, this
+// is the smallest rectangle which contains it.
+func (e Panel) Bounds() Rect {
+	if e.polygon != nil {
+		return e.polygon.Bounds()
+	}
+	return e.bounds
+}
+
+// SetBounds sets the geometry of the panel to a rectangle, removing its polygon
+// if it has one.
+func (e *Panel) SetBounds(r Rect) {
+	e.bounds = r
+	e.polygon = nil
+}
+
+// Polygon returns the polygon of the panel, or nil if it's a rectangle.
+func (e Panel) Polygon() Polygon {
+	return e.polygon
+}
+
+// SetPolygon sets the geometry of the panel to a polygon.
+func (e *Panel) SetPolygon(p Polygon) {
+	e.bounds = Rect{}
+	e.polygon = p
+}
+
+// Condition returns the condition for the panel to be shown.
+func (e Panel) Condition() Condition {
+	return e.condition
+}
+
+func (e *Panel) SetCondition(c Condition) {
+	e.condition = c
+}
+
+func (e *Panel) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	return marshalNode(enc, e, "div")
+}
+
+func (e *Panel) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return unmarshalNode(d, e, start)
+}
+
+var (
+	panelOrderAttrName   = xml.Name{Local: "data-ipub-order"}
+	panelBoundsAttrName  = xml.Name{Local: "data-ipub-bounds"}
+	panelPolygonAttrName = xml.Name{Local: "data-ipub-polygon"}
+)
+
+func (e Panel) MarshalXMLAttrs() ([]xml.Attr, error) {
+	attrs := []xml.Attr{{Name: panelOrderAttrName, Value: strconv.Itoa(e.order)}}
+	if e.polygon != nil {
+		attrs = append(attrs, xml.Attr{Name: panelPolygonAttrName, Value: formatPolygon(e.polygon)})
+	} else {
+		attrs = append(attrs, xml.Attr{Name: panelBoundsAttrName, Value: formatRect(e.bounds)})
+	}
+	if len(e.condition) > 0 {
+		attrs = append(attrs, xml.Attr{Name: conditionAttrName, Value: e.condition.String()})
+	}
+	return attrs, nil
+}
+
+func (e *Panel) UnmarshalXMLAttrs(attrs []xml.Attr) error {
+	for _, a := range attrs {
+		switch a.Name {
+		case panelOrderAttrName:
+			var i attr.Int
+			if err := i.UnmarshalXMLAttr(a); err != nil {
+				return err
+			}
+			e.order = int(i)
+
+		case panelBoundsAttrName:
+			r, err := parseRect(a.Value)
+			if err != nil {
+				return attr.ErrInvalidValue{Attr: a, Message: err.Error()}
+			}
+			e.SetBounds(r)
+
+		case panelPolygonAttrName:
+			p, err := parsePolygon(a.Value)
+			if err != nil {
+				return attr.ErrInvalidValue{Attr: a, Message: err.Error()}
+			}
+			e.SetPolygon(p)
+
+		case conditionAttrName:
+			c, err := unmarshalCondition(a)
+			if err != nil {
+				return err
+			}
+			e.condition = c
+		}
+	}
+	return nil
+}
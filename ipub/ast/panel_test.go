@@ -0,0 +1,63 @@
+package ast_test
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/loreddev/x/tinyssert"
+)
+
+func TestPanel(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	s := []byte(`
+	<section data-ipub-element="content">
+		<img data-ipub-element="image" src="images/001.png"/>
+		<div data-ipub-element="panel" data-ipub-order="2" data-ipub-polygon="0,50 100,40 100,100 0,100"></div>
+		<div data-ipub-element="panel" data-ipub-order="1" data-ipub-bounds="0 0 100 50"></div>
+	</section>
+	`)
+
+	var c ast.Content
+	if err := xml.Unmarshal(s, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	ps := c.Panels()
+	assert.Equal(2, len(ps))
+
+	assert.Equal(1, ps[0].Order())
+	assert.Equal(ast.Rect{X: 0, Y: 0, Width: 100, Height: 50}, ps[0].Bounds())
+	assert.Equal(ast.Polygon(nil), ps[0].Polygon())
+
+	assert.Equal(2, ps[1].Order())
+	assert.Equal(ast.Polygon{{0, 50}, {100, 40}, {100, 100}, {0, 100}}, ps[1].Polygon())
+	assert.Equal(ast.Rect{X: 0, Y: 40, Width: 100, Height: 60}, ps[1].Bounds())
+	assert.Equal(true, ps[1].Bounds().In(ast.PageRect))
+}
+
+func TestPanelInvalid(t *testing.T) {
+	tests := map[string]string{
+		"order":          `data-ipub-order="first"`,
+		"order fraction": `data-ipub-order="1.5"`,
+		"bounds length":  `data-ipub-bounds="0 0 100"`,
+		"bounds number":  `data-ipub-bounds="0 0 100 a"`,
+		"bounds NaN":     `data-ipub-bounds="0 NaN 100 100"`,
+		"bounds Inf":     `data-ipub-bounds="0 0 Inf 100"`,
+		"polygon length": `data-ipub-polygon="0,0 100,100"`,
+		"polygon point":  `data-ipub-polygon="0,0 100 100,100"`,
+		"polygon number": `data-ipub-polygon="0,0 a,100 100,100"`,
+		"polygon NaN":    `data-ipub-polygon="0,0 NaN,100 100,100"`,
+	}
+
+	for name, a := range tests {
+		t.Run(name, func(t *testing.T) {
+			var p ast.Panel
+			err := xml.Unmarshal([]byte(`<div data-ipub-element="panel" `+a+`></div>`), &p)
+			if err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
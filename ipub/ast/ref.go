@@ -0,0 +1,271 @@
+package ast
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
+)
+
+var (
+	ErrInvalidRef  = errors.New("ast: invalid node reference")
+	ErrRefNotFound = errors.New("ast: referenced node not found")
+)
+
+// Ref is an address of a node inside a package, similar to an EPUB CFI. It holds
+// the href of the node's section and the steps from the section to the node, and
+// optionally a range of characters inside of the node's text.
+//
+// Refs are formatted as "<href>#<steps>[:<start>,<end>]", where each step is the
+// kind of a node and its index among its siblings of the same kind, with its ID if
+// it has one:
+//
+//	sections/001.xhtml#body[0]/content[2;page-3]/panel[1]
+//	sections/001.xhtml#body[0]/content[2;page-3]/speech[0;ana-1]:4,8
+//
+// When resolved, the closest step with an ID is looked up by it, and only the
+// steps after it use indexes. So refs to nodes with IDs, or inside of nodes with
+// IDs, keep pointing to the same node if other parts of the section are changed.
+type Ref struct {
+	Section string
+	Steps   []RefStep
+	// Range is the range of characters inside of the node's text, nil if the ref
+	// points to the whole node. See [RefText].
+	Range *TextRange
+}
+
+type RefStep struct {
+	Kind  NodeKind
+	Index int
+	ID    string
+}
+
+// TextRange is a range of characters, counted in runes, from Start up to but not
+// including End.
+type TextRange struct {
+	Start int
+	End   int
+}
+
+// ParseRef parses a ref in the format returned by [Ref.String].
+func ParseRef(s string) (Ref, error) {
+	href, frag, _ := strings.Cut(s, "#")
+	if href == "" {
+		return Ref{}, errors.Join(ErrInvalidRef, fmt.Errorf("ref %q has no section", s))
+	}
+
+	r := Ref{Section: href}
+	if frag == "" {
+		return r, nil
+	}
+
+	if steps, rng, ok := strings.Cut(frag, ":"); ok {
+		start, end, ok := strings.Cut(rng, ",")
+		if !ok {
+			return Ref{}, errors.Join(ErrInvalidRef, fmt.Errorf("ref %q has an invalid range", s))
+		}
+		tr, err := parseTextRange(start, end)
+		if err != nil {
+			return Ref{}, errors.Join(ErrInvalidRef, fmt.Errorf("ref %q has an invalid range: %w", s, err))
+		}
+		r.Range = &tr
+		frag = steps
+	}
+
+	for _, seg := range strings.Split(frag, "/") {
+		var id string
+		if i := strings.IndexByte(seg, ';'); i != -1 && strings.HasSuffix(seg, "]") {
+			id = seg[i+1 : len(seg)-1]
+			seg = seg[:i] + "]"
+			if !attr.ID(id).Valid() {
+				return Ref{}, errors.Join(ErrInvalidRef, fmt.Errorf("ref %q has an invalid ID %q", s, id))
+			}
+		}
+
+		k, i, ok := parsePathSegment(seg)
+		if !ok {
+			return Ref{}, errors.Join(ErrInvalidRef, fmt.Errorf("ref %q has an invalid step %q", s, seg))
+		}
+		r.Steps = append(r.Steps, RefStep{Kind: k, Index: i, ID: id})
+	}
+
+	return r, nil
+}
+
+func parseTextRange(start, end string) (TextRange, error) {
+	s, err := strconv.Atoi(start)
+	if err != nil {
+		return TextRange{}, err
+	}
+	e, err := strconv.Atoi(end)
+	if err != nil {
+		return TextRange{}, err
+	}
+	if s < 0 || e < s {
+		return TextRange{}, fmt.Errorf("range %d,%d is out of order", s, e)
+	}
+	return TextRange{Start: s, End: e}, nil
+}
+
+func (r Ref) String() string {
+	var b strings.Builder
+	b.WriteString(r.Section)
+
+	for i, s := range r.Steps {
+		if i == 0 {
+			b.WriteByte('#')
+		} else {
+			b.WriteByte('/')
+		}
+		fmt.Fprintf(&b, "%s[%d", s.Kind, s.Index)
+		if s.ID != "" {
+			fmt.Fprintf(&b, ";%s", s.ID)
+		}
+		b.WriteByte(']')
+	}
+
+	if r.Range != nil {
+		fmt.Fprintf(&b, ":%d,%d", r.Range.Start, r.Range.End)
+	}
+
+	return b.String()
+}
+
+// RefOf returns the ref of n, which must be inside of a section with an href.
+// IDs which can't be used in refs, such as ones with slashes, are left out.
+func RefOf(n Node) (Ref, error) {
+	s, ok := n.(*Section)
+	if !ok {
+		s, ok = FindAncestor(n, KindSection).(*Section)
+	}
+	if !ok || s.Href() == "" {
+		return Ref{}, errors.Join(ErrInvalidRef, fmt.Errorf("node %q is not inside a section with an href", Path(n)))
+	}
+
+	r := Ref{Section: s.Href()}
+	for c := n; c != s; c = c.Parent() {
+		step := RefStep{Kind: c.Kind(), Index: kindIndex(c)}
+		if attr.ID(c.ID()).Valid() {
+			step.ID = c.ID()
+		}
+		r.Steps = append(r.Steps, step)
+	}
+	for i, j := 0, len(r.Steps)-1; i < j; i, j = i+1, j-1 {
+		r.Steps[i], r.Steps[j] = r.Steps[j], r.Steps[i]
+	}
+
+	return r, nil
+}
+
+// Resolve returns the node in pkg which ref points to. If the ref has a range, it
+// is checked to be inside of the node's text.
+func Resolve(pkg *Package, ref Ref) (Node, error) {
+	var n Node
+	for _, s := range pkg.Sections() {
+		if s.Href() == ref.Section {
+			n = s
+			break
+		}
+	}
+	if n == nil {
+		return nil, errors.Join(ErrRefNotFound, fmt.Errorf("section %q", ref.Section))
+	}
+
+	steps := ref.Steps
+	for i := len(steps) - 1; i >= 0; i-- {
+		if steps[i].ID == "" {
+			continue
+		}
+		a := FindID(n, steps[i].ID)
+		if a == nil || a.Kind() != steps[i].Kind {
+			return nil, errors.Join(ErrRefNotFound, fmt.Errorf("%s with ID %q", steps[i].Kind, steps[i].ID))
+		}
+		n, steps = a, steps[i+1:]
+		break
+	}
+
+	for _, s := range steps {
+		f := kindChild(n, s.Kind, s.Index)
+		if f == nil {
+			return nil, errors.Join(ErrRefNotFound, fmt.Errorf("%s[%d] in %q", s.Kind, s.Index, Path(n)))
+		}
+		n = f
+	}
+
+	if r := ref.Range; r != nil {
+		t, ok := RefText(n)
+		if !ok {
+			return nil, errors.Join(ErrInvalidRef, fmt.Errorf("node %q has no text for a range", Path(n)))
+		}
+		if l := utf8.RuneCountInString(t); r.End > l {
+			return nil, errors.Join(ErrRefNotFound, fmt.Errorf("range %d,%d is outside of the text of length %d", r.Start, r.End, l))
+		}
+	}
+
+	return n, nil
+}
+
+// RefText returns the text which ranges of refs to n are counted in, and false if
+// n has no text. Dialogs use their [DialogText], text runs their value, and
+// alternates and choices the text of their runs.
+func RefText(n Node) (string, bool) {
+	switch n := n.(type) {
+	case *Text:
+		return n.Value(), true
+	case Dialog:
+		return DialogText(n), true
+	case *Alternate, *Choice:
+		return textRuns(n), true
+	default:
+		return "", false
+	}
+}
+
+// FindID returns the first node in the tree of root with the given ID, or nil if
+// there is none.
+func FindID(root Node, id string) Node {
+	var f Node
+	_ = Walk(root, func(n Node, entering bool) (WalkStatus, error) {
+		if entering && n.ID() == id {
+			f = n
+			return WalkStop, nil
+		}
+		return WalkContinue, nil
+	})
+	return f
+}
+
+// AssignIDs gives an ID to every node of the given kinds inside root which doesn't
+// have one, so refs to them keep working after other nodes are added or removed.
+// IDs are the kind of the node followed by a number, such as "panel-3", skipping
+// the ones already in use.
+func AssignIDs(root Node, kinds ...NodeKind) {
+	used := map[string]bool{}
+	_ = Walk(root, func(n Node, entering bool) (WalkStatus, error) {
+		if entering && n.ID() != "" {
+			used[n.ID()] = true
+		}
+		return WalkContinue, nil
+	})
+
+	next := map[NodeKind]int{}
+	_ = Walk(root, func(n Node, entering bool) (WalkStatus, error) {
+		if !entering || n.ID() != "" || !slices.Contains(kinds, n.Kind()) {
+			return WalkContinue, nil
+		}
+		for {
+			next[n.Kind()]++
+			id := fmt.Sprintf("%s-%d", n.Kind(), next[n.Kind()])
+			if !used[id] {
+				used[id] = true
+				n.SetID(id)
+				break
+			}
+		}
+		return WalkContinue, nil
+	})
+}
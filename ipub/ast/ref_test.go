@@ -0,0 +1,156 @@
+package ast_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"testing"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/loreddev/x/tinyssert"
+)
+
+func TestRef(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	var s ast.Section
+	if err := xml.Unmarshal(test, &s); err != nil {
+		t.Fatal(err)
+	}
+	s.SetHref("sections/001.xhtml")
+
+	pkg := &ast.Package{}
+	pkg.AppendChild(pkg, &s)
+
+	c := s.Body().FirstChild().NextSibling()
+	panel := ast.FindFirst(c, ast.KindPanel)
+	assert.Equal("panel-1", panel.ID())
+
+	sound := ast.FindFirst(panel, ast.KindSound)
+	r, err := ast.RefOf(sound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal("sections/001.xhtml#body[0]/content[0]/panel[0;panel-1]/sound[0]", r.String())
+
+	speech := ast.FindFirst(c, ast.KindSpeech)
+	r, err = ast.RefOf(speech)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Range = &ast.TextRange{Start: 4, End: 8}
+	assert.Equal("sections/001.xhtml#body[0]/content[0]/speech[0;ana-shout]:4,8", r.String())
+
+	pr, err := ast.ParseRef(r.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(r, pr)
+
+	n, err := ast.Resolve(pkg, pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(speech, n)
+
+	text, _ := ast.RefText(n)
+	assert.Equal("down", string([]rune(text)[pr.Range.Start:pr.Range.End]))
+
+	// Unrelated edits: a new content before the page and a new panel before the
+	// one with the ID.
+	s.Body().InsertBefore(s.Body(), c, &ast.Content{})
+	c.InsertBefore(c, panel, &ast.Panel{})
+
+	r, err = ast.ParseRef("sections/001.xhtml#body[0]/content[0]/panel[0;panel-1]/sound[0]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err = ast.Resolve(pkg, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(sound, n)
+
+	// Without IDs, refs point to whatever node is now at the same position.
+	r, err = ast.ParseRef("sections/001.xhtml#body[0]/content[0]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err = ast.Resolve(pkg, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(ast.FindFirst(s.Body(), ast.KindContent), n)
+
+	panel.SetID("")
+	if _, err = ast.Resolve(pkg, pr); err != nil {
+		t.Errorf("ref without the removed ID is not resolved: %s", err)
+	}
+	r, _ = ast.ParseRef("sections/001.xhtml#body[0]/content[1]/panel[0;panel-1]")
+	_, err = ast.Resolve(pkg, r)
+	if !errors.Is(err, ast.ErrRefNotFound) {
+		t.Errorf("expected error %q for removed ID, got %v", ast.ErrRefNotFound, err)
+	}
+}
+
+func TestRefInvalid(t *testing.T) {
+	pkg := &ast.Package{}
+	s := &ast.Section{}
+	s.SetHref("sections/001.xhtml")
+	s.SetBody(&ast.Body{})
+	pkg.AppendChild(pkg, s)
+
+	for _, r := range []string{
+		"",
+		"#body[0]",
+		"sections/001.xhtml#body",
+		"sections/001.xhtml#body[-1]",
+		"sections/001.xhtml#body[0;a/b]",
+		"sections/001.xhtml#body[0]:4",
+		"sections/001.xhtml#body[0]:8,4",
+	} {
+		if _, err := ast.ParseRef(r); !errors.Is(err, ast.ErrInvalidRef) {
+			t.Errorf("expected error %q for ref %q, got %v", ast.ErrInvalidRef, r, err)
+		}
+	}
+
+	for _, r := range []string{
+		"sections/002.xhtml",
+		"sections/001.xhtml#body[1]",
+		"sections/001.xhtml#body[0;missing]",
+	} {
+		ref, err := ast.ParseRef(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ast.Resolve(pkg, ref); !errors.Is(err, ast.ErrRefNotFound) {
+			t.Errorf("expected error %q for ref %q, got %v", ast.ErrRefNotFound, r, err)
+		}
+	}
+
+	ref, _ := ast.ParseRef("sections/001.xhtml#body[0]:0,1")
+	if _, err := ast.Resolve(pkg, ref); !errors.Is(err, ast.ErrInvalidRef) {
+		t.Errorf("expected error %q for range in node without text, got %v", ast.ErrInvalidRef, err)
+	}
+
+	if _, err := ast.RefOf(&ast.Panel{}); !errors.Is(err, ast.ErrInvalidRef) {
+		t.Errorf("expected error %q for node outside of section, got %v", ast.ErrInvalidRef, err)
+	}
+}
+
+func TestAssignIDs(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	c := &ast.Content{}
+	p1, p2, p3 := &ast.Panel{}, &ast.Panel{}, &ast.Panel{}
+	p2.SetID("panel-1")
+	c.AppendChild(c, p1)
+	c.AppendChild(c, p2)
+	c.AppendChild(c, p3)
+
+	ast.AssignIDs(c, ast.KindPanel)
+
+	assert.Equal("", c.ID())
+	assert.Equal("panel-2", p1.ID())
+	assert.Equal("panel-1", p2.ID())
+	assert.Equal("panel-3", p3.ID())
+}
@@ -2,11 +2,253 @@ package ast
 
 import (
 	"encoding/xml"
+	"fmt"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
 )
 
+// Section is a XHTML document inside a package, with the content nodes inside
+// its body. The body is set with [Section.SetBody], so it is linked to the
+// section.
 type Section struct {
-	XMLName xml.Name `xml:"html"`
-	Body    *Body    `xml:"body"`
+	Title string
+
+	// Language is the language tag of the section's text which isn't inside an
+	// [Alternate].
+	Language string
+
+	// Ending marks the section as an ending of a branching story, so it doesn't
+	// continue to the next section in the spine.
+	Ending bool
+
+	// Stylesheets are the hrefs of the CSS resources of the package which style
+	// the section's nodes, in the order they are applied. They are linked in the
+	// head of the section's document.
+	Stylesheets []string
+
+	// Layout overrides the layout of the package in the section, such as for a
+	// chapter read in a different direction. See [ResolveLayout].
+	Layout Layout
+
+	body      *Body
+	href      string
+	condition Condition
+
+	BaseNode
+}
+
+var KindSection = NewNodeKind("section", &Section{})
+
+func (e Section) Kind() NodeKind {
+	return KindSection
+}
+
+// Href returns the path of the section's document inside its package. It is empty
+// if the section was not read from or written to a package.
+func (e Section) Href() string {
+	return e.href
+}
+
+func (e *Section) SetHref(href string) {
+	e.href = href
+}
+
+// Condition returns the condition for the section to be shown. Hidden sections
+// are skipped when reading.
+func (e Section) Condition() Condition {
+	return e.condition
+}
+
+func (e *Section) SetCondition(c Condition) {
+	e.condition = c
+}
+
+// Body returns the body of the section, or nil if it doesn't have one.
+func (e Section) Body() *Body {
+	return e.body
+}
+
+// SetBody sets the body of the section and links it as the section's only child,
+// so it can be reached when walking the tree.
+func (e *Section) SetBody(b *Body) {
+	if e.body != nil {
+		e.body.SetParent(nil)
+	}
+	e.body = b
+	if b != nil {
+		b.SetParent(e)
+	}
+}
+
+// The body of a section is stored in its own field instead of the list of children
+// in [BaseNode], so these methods are overridden to expose it as a child.
+
+func (e *Section) HasChildren() bool {
+	return e.body != nil
+}
+
+func (e *Section) ChildCount() uint {
+	if e.body == nil {
+		return 0
+	}
+	return 1
+}
+
+func (e *Section) FirstChild() Node {
+	if e.body == nil {
+		return nil
+	}
+	return e.body
+}
+
+func (e *Section) LastChild() Node {
+	return e.FirstChild()
+}
+
+var sectionEndingAttrName = xml.Name{Local: "data-ipub-ending"}
+
+const XHTMLNamespace = "http://www.w3.org/1999/xhtml"
+
+func (e Section) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	html := xml.StartElement{Name: xml.Name{Space: XHTMLNamespace, Local: "html"}}
+	if e.Language != "" {
+		html.Attr = append(html.Attr, xml.Attr{Name: langAttrName, Value: e.Language})
+	}
+	if len(e.condition) > 0 {
+		html.Attr = append(html.Attr, xml.Attr{Name: conditionAttrName, Value: e.condition.String()})
+	}
+	if e.Ending {
+		html.Attr = append(html.Attr, xml.Attr{Name: sectionEndingAttrName, Value: "true"})
+	}
+	layout, err := e.Layout.marshalAttrs()
+	if err != nil {
+		return fmt.Errorf("unable to marshal section layout: %w", err)
+	}
+	html.Attr = append(html.Attr, layout...)
+	if err := enc.EncodeToken(html); err != nil {
+		return err
+	}
+
+	if e.Title != "" || len(e.Stylesheets) > 0 {
+		head := sectionHead{Title: e.Title}
+		for _, href := range e.Stylesheets {
+			head.Links = append(head.Links, sectionLink{Rel: stylesheetRel, Type: "text/css", Href: href})
+		}
+		if err := enc.EncodeElement(head, xml.StartElement{Name: xml.Name{Local: "head"}}); err != nil {
+			return err
+		}
+	}
+
+	if e.body != nil {
+		if err := enc.Encode(e.body); err != nil {
+			return err
+		}
+	}
+
+	return enc.EncodeToken(html.End())
+}
+
+func (e *Section) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if start.Name.Local != "html" {
+		return fmt.Errorf("section root element must be %q, found %q", "html", start.Name.Local)
+	}
+
+	if err := e.unmarshalAttrs(start.Attr); err != nil {
+		return err
+	}
+
+	for {
+		line, col := d.InputPos()
+
+		t, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := t.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "head":
+				if err := e.unmarshalHead(d, t); err != nil {
+					return err
+				}
+
+			case "body":
+				b := &Body{}
+				b.SetPosition(Position{Line: line, Column: col})
+				if err := d.DecodeElement(b, &t); err != nil {
+					return err
+				}
+				e.SetBody(b)
+
+			default:
+				if err := d.Skip(); err != nil {
+					return err
+				}
+			}
+
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+type sectionHead struct {
+	Title string        `xml:"title,omitempty"`
+	Links []sectionLink `xml:"link"`
+}
+
+type sectionLink struct {
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+const stylesheetRel = "stylesheet"
+
+// unmarshalHead decodes the title and stylesheets of the section's head element.
+func (e *Section) unmarshalHead(d *xml.Decoder, start xml.StartElement) error {
+	var head sectionHead
+	if err := d.DecodeElement(&head, &start); err != nil {
+		return err
+	}
+	e.Title = head.Title
+	for _, l := range head.Links {
+		if l.Rel == stylesheetRel {
+			e.Stylesheets = append(e.Stylesheets, l.Href)
+		}
+	}
+	return nil
+}
+
+// unmarshalAttrs decodes the attributes of the section's html element.
+func (e *Section) unmarshalAttrs(attrs []xml.Attr) error {
+	for _, a := range attrs {
+		switch a.Name {
+		case langAttrName:
+			var l attr.Lang
+			if err := l.UnmarshalXMLAttr(a); err != nil {
+				return err
+			}
+			e.Language = l.String()
+
+		case conditionAttrName:
+			c, err := unmarshalCondition(a)
+			if err != nil {
+				return err
+			}
+			e.condition = c
+
+		case sectionEndingAttrName:
+			e.Ending = a.Value == "true"
+
+		default:
+			if err := e.Layout.unmarshalAttr(a); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
 type Body struct {
@@ -18,3 +260,11 @@ var KindBody = NewNodeKind("body", &Body{})
 func (e Body) Kind() NodeKind {
 	return KindBody
 }
+
+func (e *Body) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	return marshalNode(enc, e, "body")
+}
+
+func (e *Body) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return unmarshalNode(d, e, start)
+}
@@ -0,0 +1,75 @@
+package ast_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/loreddev/x/tinyssert"
+)
+
+func TestSectionStylesheets(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	const doc = `<html xmlns="http://www.w3.org/1999/xhtml"><head>` +
+		`<title>Chapter 1</title>` +
+		`<link rel="stylesheet" type="text/css" href="styles/house.css"/>` +
+		`<link rel="icon" href="images/icon.png"/>` +
+		`<link rel="stylesheet" href="styles/chapter-1.css"/>` +
+		`</head><body data-ipub-element="body" class="night">` +
+		`<section data-ipub-element="content" id="p1" class="  cover  wide "></section>` +
+		`<p data-ipub-element="caption" class="narration"><span data-ipub-element="text" class="shout">Meanwhile</span></p>` +
+		`<aside data-ipub-element="not-a-kind" class="ad"></aside>` +
+		`</body></html>`
+
+	var s ast.Section
+	if err := xml.Unmarshal([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal([]string{"styles/house.css", "styles/chapter-1.css"}, s.Stylesheets)
+	assert.Equal([]string{"night"}, s.Body().Classes())
+	assert.Equal([]string{"cover", "wide"}, ast.FindID(&s, "p1").Classes())
+
+	caption := ast.FindFirst(&s, ast.KindCaption)
+	assert.Equal([]string{"narration"}, caption.Classes())
+	assert.Equal([]string{"shout"}, caption.FirstChild().Classes())
+
+	u := s.Body().LastChild().(*ast.Unknown)
+	assert.Equal([]string{"ad"}, u.Classes())
+	attrs, _ := u.MarshalXMLAttrs()
+	assert.Equal(0, len(attrs))
+
+	var b bytes.Buffer
+	if err := xml.NewEncoder(&b).Encode(&s); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(`<html xmlns="http://www.w3.org/1999/xhtml"><head><title>Chapter 1</title>`+
+		`<link rel="stylesheet" type="text/css" href="styles/house.css"></link>`+
+		`<link rel="stylesheet" type="text/css" href="styles/chapter-1.css"></link>`+
+		`</head><body data-ipub-element="body" class="night">`+
+		`<section data-ipub-element="content" id="p1" class="cover wide"></section>`+
+		`<p data-ipub-element="caption" class="narration" data-ipub-anchor="0,0">`+
+		`<span data-ipub-element="text" class="shout">Meanwhile</span></p>`+
+		`<aside data-ipub-element="not-a-kind" class="ad"></aside>`+
+		`</body></html>`, b.String())
+}
+
+func TestSectionSetBody(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	s := &ast.Section{}
+	assert.Equal(false, s.HasChildren())
+
+	b1 := &ast.Body{}
+	s.SetBody(b1)
+	assert.Equal(ast.Node(s), b1.Parent())
+	assert.Equal(ast.Node(b1), s.FirstChild())
+
+	b2 := &ast.Body{}
+	s.SetBody(b2)
+	assert.Equal(nil, b1.Parent())
+	assert.Equal(ast.Node(s), b2.Parent())
+	assert.Equal(b2, s.Body())
+}
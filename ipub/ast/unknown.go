@@ -0,0 +1,198 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
+)
+
+var ErrUnknownKind = errors.New("ast: unknown node kind")
+
+// Unknown is a node of a kind which is not registered, such as one added by a
+// newer version of the format. It keeps the name, attributes and inner XML of its
+// element, so documents can be opened and written back by older versions without
+// losing data.
+//
+// The inner XML is not decoded, so nodes inside of an unknown node are not part
+// of the tree. Use [CheckKinds] or [Decoder.Strict] to reject unknown nodes.
+type Unknown struct {
+	name  xml.Name
+	kind  NodeKind
+	attrs []xml.Attr
+	inner []byte
+
+	BaseNode
+}
+
+// Kind returns the kind of the node's element, which is not registered.
+func (e Unknown) Kind() NodeKind {
+	return e.kind
+}
+
+func (e *Unknown) SetKind(k NodeKind) {
+	e.kind = k
+}
+
+// Name returns the name of the node's element.
+func (e Unknown) Name() xml.Name {
+	return e.name
+}
+
+func (e *Unknown) SetName(n xml.Name) {
+	e.name = n
+}
+
+// InnerXML returns the content of the node's element as found in the document.
+func (e Unknown) InnerXML() []byte {
+	return e.inner
+}
+
+func (e *Unknown) SetInnerXML(b []byte) {
+	e.inner = b
+}
+
+// MarshalXMLAttrs returns the attributes of the node's element, except its kind,
+// ID and classes.
+func (e Unknown) MarshalXMLAttrs() ([]xml.Attr, error) {
+	return append([]xml.Attr{}, e.attrs...), nil
+}
+
+func (e *Unknown) UnmarshalXMLAttrs(attrs []xml.Attr) error {
+	e.attrs = []xml.Attr{}
+	for _, a := range attrs {
+		if a.Name == NodeKindAttrName || a.Name == NodeIDAttrName || a.Name == NodeClassAttrName || isNamespaceAttr(a) {
+			continue
+		}
+		e.attrs = append(e.attrs, a)
+	}
+	return nil
+}
+
+func (e *Unknown) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	start := xml.StartElement{
+		Name: localName(e.name),
+		Attr: []xml.Attr{{Name: NodeKindAttrName, Value: string(e.kind)}},
+	}
+	start.Attr = append(start.Attr, baseAttrs(e)...)
+	start.Attr = append(start.Attr, e.attrs...)
+
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	// The inner XML is decoded inside of an element with the XHTML namespace, so
+	// its elements are written without it like the ones of other nodes.
+	d := xml.NewDecoder(io.MultiReader(
+		bytes.NewReader([]byte(`<inner xmlns="`+XHTMLNamespace+`">`)),
+		bytes.NewReader(e.inner),
+		bytes.NewReader([]byte(`</inner>`)),
+	))
+	for depth := 0; ; {
+		t, err := d.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("unable to marshal inner XML of %q node: %w", e.kind, err)
+		}
+
+		switch v := t.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 1 {
+				continue
+			}
+			attrs := []xml.Attr{}
+			for _, a := range v.Attr {
+				if !isNamespaceAttr(a) {
+					attrs = append(attrs, a)
+				}
+			}
+			t = xml.StartElement{Name: localName(v.Name), Attr: attrs}
+
+		case xml.EndElement:
+			depth--
+			if depth == 0 {
+				continue
+			}
+			t = xml.EndElement{Name: localName(v.Name)}
+
+		case xml.CharData:
+			// Whitespace between elements is indentation, which is written by the
+			// encoder, as done for the elements of other nodes.
+			if len(bytes.TrimSpace(v)) == 0 {
+				continue
+			}
+		}
+
+		if err := enc.EncodeToken(t); err != nil {
+			return err
+		}
+	}
+
+	return enc.EncodeToken(start.End())
+}
+
+func (e *Unknown) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	unmarshalBaseAttrs(e, start)
+
+	e.name = start.Name
+	for _, a := range start.Attr {
+		if a.Name == NodeKindAttrName {
+			e.kind = NodeKind(a.Value)
+		}
+	}
+	if err := e.UnmarshalXMLAttrs(start.Attr); err != nil {
+		return err
+	}
+
+	var v struct {
+		Inner []byte `xml:",innerxml"`
+	}
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	e.inner = v.Inner
+
+	return nil
+}
+
+// localName returns n without the XHTML namespace, which is the default one of
+// section documents.
+func localName(n xml.Name) xml.Name {
+	if n.Space == XHTMLNamespace {
+		return xml.Name{Local: n.Local}
+	}
+	return n
+}
+
+func isNamespaceAttr(a xml.Attr) bool {
+	return a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns")
+}
+
+// CheckKinds returns an error for each [Unknown] node in the tree of n, or nil
+// if all of its nodes are of registered kinds. The errors match [ErrUnknownKind]
+// and [attr.ErrInvalidValue].
+func CheckKinds(n Node) error {
+	errs := []error{}
+	_ = Walk(n, func(n Node, entering bool) (WalkStatus, error) {
+		u, ok := n.(*Unknown)
+		if !entering || !ok {
+			return WalkContinue, nil
+		}
+
+		var k NodeKind
+		err := k.UnmarshalXMLAttr(xml.Attr{Name: NodeKindAttrName, Value: string(u.kind)})
+		err = fmt.Errorf("element %q: %w", attr.FmtXMLName(u.name), err)
+		if p := u.Position(); p.IsValid() {
+			err = fmt.Errorf("%s: %w", p, err)
+		}
+		errs = append(errs, errors.Join(ErrUnknownKind, err))
+
+		return WalkContinue, nil
+	})
+	return errors.Join(errs...)
+}
@@ -0,0 +1,85 @@
+package ast_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/loreddev/x/tinyssert"
+)
+
+const unknownSection = `<html xmlns="http://www.w3.org/1999/xhtml">
+	<body data-ipub-element="body">
+		<section data-ipub-element="content">
+			<div data-ipub-element="sticker" id="sticker-1" data-ipub-pack="cats" xml:lang="en">
+				<img src="stickers/cat.png" alt="A cat."></img>
+				<p data-ipub-element="speech">Meow &amp; purr</p>
+			</div>
+			<div data-ipub-element="panel" data-ipub-order="1" data-ipub-bounds="0 0 100 100"></div>
+		</section>
+	</body>
+</html>`
+
+func TestUnknown(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	var s ast.Section
+	if err := xml.Unmarshal([]byte(unknownSection), &s); err != nil {
+		t.Fatal(err)
+	}
+
+	u, ok := ast.FindFirst(&s, "sticker").(*ast.Unknown)
+	if !ok {
+		t.Fatalf("expected unknown node, got %T", ast.FindFirst(&s, "sticker"))
+	}
+	assert.Equal(ast.NodeKind("sticker"), u.Kind())
+	assert.Equal(false, u.Kind().Registered())
+	assert.Equal("div", u.Name().Local)
+	assert.Equal("sticker-1", u.ID())
+	assert.Equal(ast.Position{Line: 4, Column: 4}, u.Position())
+	assert.Equal("section/body[0]/content[0]/sticker[0]", ast.Path(u))
+	assert.Equal(false, u.HasChildren())
+	assert.Equal(true, strings.HasPrefix(strings.TrimSpace(string(u.InnerXML())), `<img src="stickers/cat.png"`))
+
+	attrs, err := u.MarshalXMLAttrs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal([]xml.Attr{
+		{Name: xml.Name{Local: "data-ipub-pack"}, Value: "cats"},
+		{Name: xml.Name{Space: ast.XMLNamespace, Local: "lang"}, Value: "en"},
+	}, attrs)
+
+	var b bytes.Buffer
+	e := xml.NewEncoder(&b)
+	e.Indent("", "\t")
+	if err := e.Encode(&s); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(unknownSection, b.String())
+
+	err = ast.CheckKinds(&s)
+	if !errors.Is(err, ast.ErrUnknownKind) {
+		t.Errorf("expected error %q, got %v", ast.ErrUnknownKind, err)
+	}
+	assert.Equal(true, strings.Contains(err.Error(), "4:4"))
+
+	d := ast.NewDecoder(strings.NewReader(unknownSection))
+	d.Strict = true
+	if _, err := d.Next(); !errors.Is(err, ast.ErrUnknownKind) {
+		t.Errorf("expected error %q in strict mode, got %v", ast.ErrUnknownKind, err)
+	}
+
+	d = ast.NewDecoder(strings.NewReader(unknownSection))
+	n, err := d.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(true, ast.FindFirst(n, "sticker") != nil)
+	_, err = d.Next()
+	assert.Equal(io.EOF, err)
+}
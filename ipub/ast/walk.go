@@ -0,0 +1,183 @@
+package ast
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// WalkStatus is returned by a [Walker] to control how [Walk] continues traversing
+// the tree.
+type WalkStatus int
+
+const (
+	// WalkStop stops the traversal immediately.
+	WalkStop WalkStatus = iota + 1
+	// WalkSkipChildren does not walk into the children of the current node. It only
+	// has effect when entering a node.
+	WalkSkipChildren
+	// WalkContinue continues the traversal normally.
+	WalkContinue
+)
+
+// Walker is called by [Walk] when entering and exiting each node.
+type Walker func(n Node, entering bool) (WalkStatus, error)
+
+// Walk traverses the tree of n depth-first, calling walker when entering a node and
+// after all of its children were walked. If walker returns an error, the traversal
+// is stopped and the error is returned.
+func Walk(n Node, walker Walker) error {
+	_, err := walkHelper(n, walker)
+	return err
+}
+
+func walkHelper(n Node, walker Walker) (WalkStatus, error) {
+	status, err := walker(n, true)
+	if err != nil || status == WalkStop {
+		return WalkStop, err
+	}
+
+	if status != WalkSkipChildren {
+		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+			if s, err := walkHelper(c, walker); err != nil || s == WalkStop {
+				return WalkStop, err
+			}
+		}
+	}
+
+	status, err = walker(n, false)
+	if err != nil || status == WalkStop {
+		return WalkStop, err
+	}
+
+	return WalkContinue, nil
+}
+
+// FindAll returns all descendants of n, including n itself, which are of the given
+// kind, in document order.
+func FindAll(n Node, kind NodeKind) []Node {
+	ns := []Node{}
+	_ = Walk(n, func(n Node, entering bool) (WalkStatus, error) {
+		if entering && n.Kind() == kind {
+			ns = append(ns, n)
+		}
+		return WalkContinue, nil
+	})
+	return ns
+}
+
+// FindFirst returns the first node of the given kind in the tree of n, including
+// n itself, in document order. It returns nil if none is found.
+func FindFirst(n Node, kind NodeKind) Node {
+	var f Node
+	_ = Walk(n, func(n Node, entering bool) (WalkStatus, error) {
+		if entering && n.Kind() == kind {
+			f = n
+			return WalkStop, nil
+		}
+		return WalkContinue, nil
+	})
+	return f
+}
+
+// Ancestors returns all ancestors of n, from its parent to the root of the tree.
+func Ancestors(n Node) []Node {
+	ns := []Node{}
+	for p := n.Parent(); p != nil; p = p.Parent() {
+		ns = append(ns, p)
+	}
+	return ns
+}
+
+// FindAncestor returns the closest ancestor of n of the given kind, or nil if
+// there is none.
+func FindAncestor(n Node, kind NodeKind) Node {
+	for p := n.Parent(); p != nil; p = p.Parent() {
+		if p.Kind() == kind {
+			return p
+		}
+	}
+	return nil
+}
+
+// Path returns the location of n in its tree, formatted as the kind of the root
+// followed by the kind and index of each node from the root to n. The index is
+// the position of the node among its siblings of the same kind.
+//
+// Example: "package/section[1]/body[0]/content[3]/image[0]"
+func Path(n Node) string {
+	ns := append([]Node{n}, Ancestors(n)...)
+
+	var b strings.Builder
+	for i := len(ns) - 1; i >= 0; i-- {
+		if i == len(ns)-1 {
+			b.WriteString(string(ns[i].Kind()))
+			continue
+		}
+		fmt.Fprintf(&b, "/%s[%d]", ns[i].Kind(), kindIndex(ns[i]))
+	}
+
+	return b.String()
+}
+
+// FindPath returns the node at path relative to root, or nil if it doesn't exist.
+// The path has the same format as the ones returned by [Path], without the kind of
+// the root, such as "body[0]/content[3]/panel[1]". If path is empty, root is
+// returned.
+func FindPath(root Node, path string) Node {
+	n := root
+	for path != "" {
+		var seg string
+		seg, path, _ = strings.Cut(path, "/")
+
+		k, i, ok := parsePathSegment(seg)
+		if !ok {
+			return nil
+		}
+
+		n = kindChild(n, k, i)
+		if n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+// kindChild returns the i-th child of n of the given kind, or nil if there is none.
+func kindChild(n Node, k NodeKind, i int) Node {
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		if c.Kind() != k {
+			continue
+		}
+		if i == 0 {
+			return c
+		}
+		i--
+	}
+	return nil
+}
+
+func parsePathSegment(s string) (NodeKind, int, bool) {
+	if len(s) < 4 || s[len(s)-1] != ']' {
+		return "", 0, false
+	}
+	k, is, ok := strings.Cut(s[:len(s)-1], "[")
+	if !ok {
+		return "", 0, false
+	}
+	i, err := strconv.Atoi(is)
+	if err != nil || i < 0 {
+		return "", 0, false
+	}
+	return NodeKind(k), i, true
+}
+
+func kindIndex(n Node) int {
+	i := 0
+	for s := n.PreviousSibling(); s != nil; s = s.PreviousSibling() {
+		if s.Kind() == n.Kind() {
+			i++
+		}
+	}
+	return i
+}
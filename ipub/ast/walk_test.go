@@ -0,0 +1,135 @@
+package ast_test
+
+import (
+	"errors"
+	"testing"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/loreddev/x/tinyssert"
+)
+
+func TestWalk(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	pkg, images := newTree()
+
+	events := []string{}
+	err := ast.Walk(pkg, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering {
+			events = append(events, "+"+string(n.Kind()))
+		} else {
+			events = append(events, "-"+string(n.Kind()))
+		}
+		if entering && n == images[1].Parent() {
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal([]string{
+		"+package",
+		"+section", "+body",
+		"+content", "+image", "-image", "-content",
+		"+content", "-content",
+		"-body", "-section",
+		"+section", "+body",
+		"+content", "+image", "-image", "-content",
+		"-body", "-section",
+		"-package",
+	}, events)
+}
+
+func TestWalkStop(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	pkg, images := newTree()
+
+	count := 0
+	err := ast.Walk(pkg, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		count++
+		if n == images[0] {
+			return ast.WalkStop, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	assert.Equal(nil, err)
+	assert.Equal(5, count)
+
+	expected := errors.New("walker error")
+	err = ast.Walk(pkg, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if n == images[1] {
+			return ast.WalkContinue, expected
+		}
+		return ast.WalkContinue, nil
+	})
+	assert.Equal(expected, err)
+}
+
+func TestFind(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	pkg, images := newTree()
+
+	found := ast.FindAll(pkg, ast.KindImage)
+	assert.Equal(3, len(found))
+	for i, img := range images {
+		assert.Equal(ast.Node(img), found[i])
+	}
+
+	assert.Equal(ast.Node(images[0]), ast.FindFirst(pkg, ast.KindImage))
+	assert.Equal(nil, ast.FindFirst(images[0], ast.KindContent))
+}
+
+func TestAncestors(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	pkg, images := newTree()
+
+	as := ast.Ancestors(images[2])
+	assert.Equal(4, len(as))
+	assert.Equal(ast.KindContent, as[0].Kind())
+	assert.Equal(ast.KindBody, as[1].Kind())
+	assert.Equal(ast.KindSection, as[2].Kind())
+	assert.Equal(ast.Node(pkg), as[3])
+
+	assert.Equal(as[2], ast.FindAncestor(images[2], ast.KindSection))
+	assert.Equal(nil, ast.FindAncestor(images[2], ast.KindImage))
+}
+
+func TestPath(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	pkg, images := newTree()
+
+	assert.Equal("package", ast.Path(pkg))
+	assert.Equal("package/section[0]/body[0]/content[0]/image[0]", ast.Path(images[0]))
+	assert.Equal("package/section[0]/body[0]/content[1]/image[0]", ast.Path(images[1]))
+	assert.Equal("package/section[1]/body[0]/content[0]/image[0]", ast.Path(images[2]))
+}
+
+// newTree creates a package with two sections, the first with two pages and the
+// second with one. Each page has a single image.
+func newTree() (*ast.Package, []*ast.Image) {
+	pkg := &ast.Package{}
+	images := []*ast.Image{}
+
+	for _, pages := range []int{2, 1} {
+		b := &ast.Body{}
+		for range pages {
+			c := &ast.Content{}
+			i := &ast.Image{}
+			c.AppendChild(c, i)
+			b.AppendChild(b, c)
+			images = append(images, i)
+		}
+
+		s := &ast.Section{}
+		s.SetBody(b)
+		pkg.AppendChild(pkg, s)
+	}
+
+	return pkg, images
+}
@@ -0,0 +1,239 @@
+package ast
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
+)
+
+// AttributesMarshaler is implemented by nodes which store data as attributes of
+// their XML element, besides their kind.
+type AttributesMarshaler interface {
+	MarshalXMLAttrs() ([]xml.Attr, error)
+}
+
+// AttributesUnmarshaler is implemented by nodes which read data from attributes of
+// their XML element. Attributes which are unknown to the node should be ignored.
+type AttributesUnmarshaler interface {
+	UnmarshalXMLAttrs(attrs []xml.Attr) error
+}
+
+// marshalNode encodes n as a XML element with the given local name, with its kind
+// and attributes, and all of its children, which must implement [xml.Marshaler].
+func marshalNode(e *xml.Encoder, n Node, name string) error {
+	k, err := n.Kind().MarshalXMLAttr(NodeKindAttrName)
+	if err != nil {
+		return err
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}, Attr: append([]xml.Attr{k}, baseAttrs(n)...)}
+
+	if m, ok := n.(AttributesMarshaler); ok {
+		attrs, err := m.MarshalXMLAttrs()
+		if err != nil {
+			return fmt.Errorf("unable to marshal attributes of %q node: %w", n.Kind(), err)
+		}
+		start.Attr = append(start.Attr, attrs...)
+	}
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	if err := marshalChildren(e, n); err != nil {
+		return err
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+func marshalChildren(e *xml.Encoder, n Node) error {
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		if _, ok := c.(xml.Marshaler); !ok {
+			return fmt.Errorf("node of kind %q can't be marshalled into XML", c.Kind())
+		}
+		if err := e.Encode(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unmarshalNode decodes the attributes of start into n, and appends all of the
+// element's children as nodes, based on their kind attribute, until the end of
+// the element.
+func unmarshalNode(d *xml.Decoder, n Node, start xml.StartElement) error {
+	if err := unmarshalNodeAttrs(n, start); err != nil {
+		return err
+	}
+
+	for {
+		line, col := d.InputPos()
+
+		t, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := t.(type) {
+		case xml.StartElement:
+			c, err := newNodeFromElement(t)
+			if err != nil {
+				return err
+			}
+			c.SetPosition(Position{Line: line, Column: col})
+
+			if err := d.DecodeElement(c, &t); err != nil {
+				return err
+			}
+
+			n.AppendChild(n, c)
+
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// unmarshalNodeAttrs decodes the attributes of start into n.
+func unmarshalNodeAttrs(n Node, start xml.StartElement) error {
+	unmarshalBaseAttrs(n, start)
+
+	if u, ok := n.(AttributesUnmarshaler); ok {
+		if err := u.UnmarshalXMLAttrs(start.Attr); err != nil {
+			return fmt.Errorf("unable to unmarshal attributes of %q node: %w", n.Kind(), err)
+		}
+	}
+	return nil
+}
+
+// baseAttrs returns the attributes of the data which all nodes have, their ID
+// and classes, if they are set.
+func baseAttrs(n Node) []xml.Attr {
+	attrs := []xml.Attr{}
+	if id := n.ID(); id != "" {
+		attrs = append(attrs, xml.Attr{Name: NodeIDAttrName, Value: id})
+	}
+	if cs := n.Classes(); len(cs) > 0 {
+		attrs = append(attrs, xml.Attr{Name: NodeClassAttrName, Value: strings.Join(cs, " ")})
+	}
+	return attrs
+}
+
+func unmarshalBaseAttrs(n Node, start xml.StartElement) {
+	for _, a := range start.Attr {
+		switch a.Name {
+		case NodeIDAttrName:
+			n.SetID(a.Value)
+		case NodeClassAttrName:
+			n.SetClasses(strings.Fields(a.Value))
+		}
+	}
+}
+
+// newNodeFromElement creates a new instance of the node implementation registered
+// with the kind found in the element's attributes. Elements of kinds which are not
+// registered are decoded as [Unknown] nodes.
+func newNodeFromElement(start xml.StartElement) (Node, error) {
+	elErr := fmt.Errorf("unable to unmarshal element %q", attr.FmtXMLName(start.Name))
+
+	i := slices.IndexFunc(start.Attr, func(a xml.Attr) bool {
+		return a.Name == NodeKindAttrName
+	})
+	if i == -1 {
+		return nil, errors.Join(elErr, fmt.Errorf("node kind not specified"))
+	}
+
+	if k := NodeKind(start.Attr[i].Value); !k.Registered() {
+		return &Unknown{name: start.Name, kind: k}, nil
+	}
+
+	var k NodeKind
+	if err := k.UnmarshalXMLAttr(start.Attr[i]); err != nil {
+		return nil, errors.Join(elErr, err)
+	}
+
+	n := newNode(k)
+	if _, ok := n.(xml.Unmarshaler); !ok {
+		return nil, errors.Join(elErr, fmt.Errorf("node of kind %q can't be unmarshalled from XML", k))
+	}
+
+	return n, nil
+}
+
+// NewNode returns a new, empty node of the implementation registered with k.
+func NewNode(k NodeKind) (Node, error) {
+	if !k.Registered() {
+		return nil, fmt.Errorf("node kind %q is not registered", k)
+	}
+	return newNode(k), nil
+}
+
+// newNode returns a pointer to a new instance of the underlying implementation of
+// the kind, so it can be changed without manipulating the value in elementKindList.
+func newNode(k NodeKind) Node {
+	t := reflect.TypeOf(elementKindList[k])
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return reflect.New(t).Interface().(Node)
+}
+
+func (k NodeKind) MarshalXMLAttr(n xml.Name) (xml.Attr, error) {
+	if n != NodeKindAttrName {
+		return xml.Attr{}, attr.ErrInvalidName{Actual: n, Expected: NodeKindAttrName}
+	}
+	return xml.Attr{Name: NodeKindAttrName, Value: string(k)}, nil
+}
+
+func (k *NodeKind) UnmarshalXMLAttr(a xml.Attr) error {
+	ak := NodeKind(a.Value)
+
+	if !ak.Registered() {
+		v := make([]string, 0, len(elementKindList))
+		for _, k := range Kinds() {
+			v = append(v, string(k))
+		}
+		return attr.ErrInvalidValue{
+			Attr:    a,
+			Message: fmt.Sprintf("must be a registered node kind (%q)", strings.Join(v, `", "`)),
+		}
+	}
+
+	*k = ak
+
+	return nil
+}
+
+// Registered reports whether k was registered with [NewNodeKind].
+func (k NodeKind) Registered() bool {
+	_, ok := elementKindList[k]
+	return ok
+}
+
+// Kinds returns all registered node kinds, sorted.
+func Kinds() []NodeKind {
+	ks := make([]NodeKind, 0, len(elementKindList))
+	for k := range elementKindList {
+		ks = append(ks, k)
+	}
+	slices.Sort(ks)
+	return ks
+}
+
+// NodeKindAttrName is the name of the attribute which holds the kind of each node's
+// element.
+var NodeKindAttrName = xml.Name{Local: "data-ipub-element"}
+
+// NodeIDAttrName is the name of the attribute which holds the ID of each node's
+// element, see [Node.ID].
+var NodeIDAttrName = xml.Name{Local: "id"}
+
+// NodeClassAttrName is the name of the attribute which holds the classes of each
+// node's element, separated by spaces, see [Node.Classes].
+var NodeClassAttrName = xml.Name{Local: "class"}
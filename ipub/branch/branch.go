@@ -0,0 +1,241 @@
+// Package branch resolves the paths a reader can take through a branching ipub
+// package, following its choices and the conditions of its sections.
+//
+// Reading starts at the first shown section of the spine with an empty state. A
+// section with [ast.Choice] nodes continues only to the targets of the choices
+// which are shown, applying their effects to the state. A section without choices
+// continues to the next section in the spine, unless it is marked as an ending.
+// Sections whose condition is false are skipped, as if they were not in the spine.
+package branch
+
+import (
+	"errors"
+	"fmt"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+)
+
+// MaxStates is the maximum number of combinations of sections and states visited
+// by [Resolve], so stories with counters which grow forever don't loop forever.
+const MaxStates = 10000
+
+var ErrTooManyStates = fmt.Errorf("branch: story has more than %d reachable states", MaxStates)
+
+// Edge is a step the reader can take from one section to another.
+type Edge struct {
+	From *ast.Section
+	To   *ast.Section
+
+	// Choice is the choice which leads to To, or nil if To is the next section in
+	// the spine.
+	Choice *ast.Choice
+}
+
+// DeadEnd is a section where the reader gets stuck: it has choices, but none of
+// them are shown or lead to a shown section in the state the reader has when
+// reaching it, and it is not marked as an ending. Sections with a shown choice to
+// a target which doesn't exist are not dead ends, the choice is in
+// [Result.Dangling] instead.
+type DeadEnd struct {
+	Section *ast.Section
+	State   ast.State
+}
+
+// Result is the story graph of a package, as computed by [Resolve].
+type Result struct {
+	// Start is the first section shown to the reader, or nil if there is none.
+	Start *ast.Section
+
+	// Reachable are the sections which can be reached from Start, in spine order.
+	Reachable []*ast.Section
+	// Unreachable are the sections which can never be reached, in spine order.
+	Unreachable []*ast.Section
+	// Endings are the reachable sections where the story ends, either because
+	// they are marked as an ending or because they are at the end of the spine.
+	Endings []*ast.Section
+	// DeadEnds are the sections where the reader gets stuck, with the first state
+	// found that causes it.
+	DeadEnds []DeadEnd
+	// Dangling are the choices shown to the reader whose target is not a section
+	// of the package, without duplicates.
+	Dangling []*ast.Choice
+
+	// Edges are all steps which can be taken between reachable sections, without
+	// duplicates.
+	Edges []Edge
+}
+
+// Resolve follows all paths of pkg, starting with an empty state, and returns
+// the graph of the story. It returns [ErrTooManyStates] if there are more than
+// [MaxStates] combinations of sections and states to visit.
+func Resolve(pkg *ast.Package) (*Result, error) {
+	if pkg == nil {
+		return nil, errors.New("branch: package must not be nil")
+	}
+
+	r := &resolver{
+		sections: pkg.Sections(),
+		index:    map[string]int{},
+		visited:  map[visit]bool{},
+		edges:    map[Edge]bool{},
+		dangling: map[*ast.Choice]bool{},
+	}
+	for i, s := range r.sections {
+		if s.Href() != "" {
+			r.index[s.Href()] = i
+		}
+	}
+
+	return r.resolve()
+}
+
+type visit struct {
+	section int
+	state   string
+}
+
+type step struct {
+	section int
+	state   ast.State
+}
+
+type resolver struct {
+	sections []*ast.Section
+	index    map[string]int
+
+	visited   map[visit]bool
+	reached   []bool
+	ending    []bool
+	deadEnded []bool
+	edges     map[Edge]bool
+	dangling  map[*ast.Choice]bool
+
+	result Result
+}
+
+func (r *resolver) resolve() (*Result, error) {
+	r.reached = make([]bool, len(r.sections))
+	r.ending = make([]bool, len(r.sections))
+	r.deadEnded = make([]bool, len(r.sections))
+
+	start, ok := r.enter(0, ast.State{})
+	if !ok {
+		r.result.Unreachable = r.sections
+		return &r.result, nil
+	}
+	r.result.Start = r.sections[start]
+
+	queue := []step{{section: start, state: ast.State{}}}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+
+		v := visit{section: s.section, state: s.state.Key()}
+		if r.visited[v] {
+			continue
+		}
+		if len(r.visited) >= MaxStates {
+			return nil, ErrTooManyStates
+		}
+		r.visited[v] = true
+		r.reached[s.section] = true
+
+		queue = append(queue, r.next(s)...)
+	}
+
+	for i, s := range r.sections {
+		if !r.reached[i] {
+			r.result.Unreachable = append(r.result.Unreachable, s)
+			continue
+		}
+		r.result.Reachable = append(r.result.Reachable, s)
+		if r.ending[i] {
+			r.result.Endings = append(r.result.Endings, s)
+		}
+	}
+
+	return &r.result, nil
+}
+
+// next returns the steps which can be taken from s.
+func (r *resolver) next(s step) []step {
+	sec := r.sections[s.section]
+
+	choices := []*ast.Choice{}
+	for _, n := range ast.FindAll(sec, ast.KindChoice) {
+		choices = append(choices, n.(*ast.Choice))
+	}
+
+	if len(choices) == 0 {
+		if sec.Ending {
+			r.ending[s.section] = true
+			return nil
+		}
+
+		i, ok := r.enter(s.section+1, s.state)
+		if !ok {
+			r.ending[s.section] = true
+			return nil
+		}
+
+		r.edge(Edge{From: sec, To: r.sections[i]})
+		return []step{{section: i, state: s.state}}
+	}
+
+	steps := []step{}
+	dangling := false
+	for _, c := range choices {
+		if !ast.Visible(c, s.state) {
+			continue
+		}
+
+		t, ok := r.index[c.Target()]
+		if !ok {
+			dangling = true
+			if !r.dangling[c] {
+				r.dangling[c] = true
+				r.result.Dangling = append(r.result.Dangling, c)
+			}
+			continue
+		}
+
+		state := c.Effects().Apply(s.state)
+		i, ok := r.enter(t, state)
+		if !ok {
+			continue
+		}
+
+		r.edge(Edge{From: sec, To: r.sections[i], Choice: c})
+		steps = append(steps, step{section: i, state: state})
+	}
+
+	if len(steps) == 0 {
+		if sec.Ending {
+			r.ending[s.section] = true
+		} else if !dangling && !r.deadEnded[s.section] {
+			r.deadEnded[s.section] = true
+			r.result.DeadEnds = append(r.result.DeadEnds, DeadEnd{Section: sec, State: s.state})
+		}
+	}
+
+	return steps
+}
+
+// enter returns the index of the first section from i onwards which is shown in
+// state, or false if there is none.
+func (r *resolver) enter(i int, state ast.State) (int, bool) {
+	for ; i < len(r.sections); i++ {
+		if r.sections[i].Condition().Eval(state) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (r *resolver) edge(e Edge) {
+	if r.edges[e] {
+		return
+	}
+	r.edges[e] = true
+	r.result.Edges = append(r.result.Edges, e)
+}
@@ -0,0 +1,127 @@
+package branch_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"testing"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/branch"
+	"forge.capytal.company/loreddev/x/tinyssert"
+)
+
+func newPackage(t *testing.T, sections map[string]string, spine ...string) *ast.Package {
+	t.Helper()
+
+	pkg := &ast.Package{}
+	for _, href := range spine {
+		s := &ast.Section{}
+		if err := xml.Unmarshal([]byte(sections[href]), s); err != nil {
+			t.Fatalf("failed to decode section %q: %s", href, err)
+		}
+		s.SetHref(href)
+		pkg.AppendChild(pkg, s)
+	}
+	return pkg
+}
+
+func hrefs(ss []*ast.Section) []string {
+	hs := []string{}
+	for _, s := range ss {
+		hs = append(hs, s.Href())
+	}
+	return hs
+}
+
+func TestResolve(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	pkg := newPackage(t, map[string]string{
+		"001.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml"><body data-ipub-element="body">
+			<a data-ipub-element="choice" data-ipub-target="002a.xhtml" data-ipub-set="trusted_leo = 1"></a>
+			<a data-ipub-element="choice" data-ipub-target="002b.xhtml" data-ipub-if="coins &gt;= 3"></a>
+			<a data-ipub-element="choice" data-ipub-target="003.xhtml"></a>
+		</body></html>`,
+		"002a.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml" data-ipub-ending="true"></html>`,
+		"002b.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml" data-ipub-ending="true"></html>`,
+		"003.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml"><body data-ipub-element="body">
+			<a data-ipub-element="choice" data-ipub-target="004.xhtml" data-ipub-if="trusted_leo"></a>
+		</body></html>`,
+		"004.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml" data-ipub-if="trusted_leo"></html>`,
+	}, "001.xhtml", "002a.xhtml", "002b.xhtml", "003.xhtml", "004.xhtml")
+
+	r, err := branch.Resolve(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal("001.xhtml", r.Start.Href())
+	assert.Equal([]string{"001.xhtml", "002a.xhtml", "003.xhtml"}, hrefs(r.Reachable))
+	assert.Equal([]string{"002b.xhtml", "004.xhtml"}, hrefs(r.Unreachable))
+	assert.Equal([]string{"002a.xhtml"}, hrefs(r.Endings))
+
+	assert.Equal(1, len(r.DeadEnds))
+	assert.Equal("003.xhtml", r.DeadEnds[0].Section.Href())
+	assert.Equal("", r.DeadEnds[0].State.Key())
+
+	assert.Equal(2, len(r.Edges))
+	assert.Equal("002a.xhtml", r.Edges[0].To.Href())
+	assert.Equal("002a.xhtml", r.Edges[0].Choice.Target())
+	assert.Equal("003.xhtml", r.Edges[1].To.Href())
+}
+
+func TestResolveLinear(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	pkg := newPackage(t, map[string]string{
+		"001.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml"></html>`,
+		"002.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml" data-ipub-if="bonus"></html>`,
+		"003.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml"></html>`,
+	}, "001.xhtml", "002.xhtml", "003.xhtml")
+
+	r, err := branch.Resolve(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal([]string{"001.xhtml", "003.xhtml"}, hrefs(r.Reachable))
+	assert.Equal([]string{"002.xhtml"}, hrefs(r.Unreachable))
+	assert.Equal([]string{"003.xhtml"}, hrefs(r.Endings))
+	assert.Equal(0, len(r.DeadEnds))
+	assert.Equal(1, len(r.Edges))
+	assert.Equal((*ast.Choice)(nil), r.Edges[0].Choice)
+}
+
+func TestResolveDangling(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	pkg := newPackage(t, map[string]string{
+		"001.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml"><body data-ipub-element="body">
+			<a data-ipub-element="choice" data-ipub-target="missing.xhtml"></a>
+			<a data-ipub-element="choice" data-ipub-target="hidden.xhtml" data-ipub-if="never"></a>
+		</body></html>`,
+		"002.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml"></html>`,
+	}, "001.xhtml", "002.xhtml")
+
+	r, err := branch.Resolve(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(1, len(r.Dangling))
+	assert.Equal("missing.xhtml", r.Dangling[0].Target())
+	assert.Equal(0, len(r.DeadEnds))
+	assert.Equal([]string{"001.xhtml"}, hrefs(r.Reachable))
+}
+
+func TestResolveTooManyStates(t *testing.T) {
+	pkg := newPackage(t, map[string]string{
+		"001.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml"><body data-ipub-element="body">
+			<a data-ipub-element="choice" data-ipub-target="001.xhtml" data-ipub-set="loops += 1"></a>
+		</body></html>`,
+	}, "001.xhtml")
+
+	if _, err := branch.Resolve(pkg); !errors.Is(err, branch.ErrTooManyStates) {
+		t.Fatalf("expected ErrTooManyStates, got %v", err)
+	}
+}
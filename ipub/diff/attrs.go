@@ -0,0 +1,222 @@
+package diff
+
+import (
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strings"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
+)
+
+// Names used in changes for the data of nodes which is not stored as attributes
+// of their elements, so it is compared as any other attribute.
+var (
+	TextAttrName         = xml.Name{Local: "#text"}
+	SectionTitleAttrName = xml.Name{Local: "#title"}
+	InnerXMLAttrName     = xml.Name{Local: "#inner"}
+	// Hrefs of the stylesheets of sections, separated by spaces.
+	SectionStylesheetsAttrName = xml.Name{Local: "#stylesheets"}
+)
+
+var (
+	sectionLangAttrName        = xml.Name{Space: ast.XMLNamespace, Local: "lang"}
+	sectionIfAttrName          = xml.Name{Local: "data-ipub-if"}
+	sectionEndingAttrName      = xml.Name{Local: "data-ipub-ending"}
+	sectionProgressionAttrName = xml.Name{Local: "data-ipub-progression"}
+	sectionSpreadAttrName      = xml.Name{Local: "data-ipub-spread-mode"}
+	textEmphasisAttrName       = xml.Name{Local: "data-ipub-emphasis"}
+)
+
+type attributes map[xml.Name]string
+
+// attrs returns the attributes of n, including its ID. Nodes which fail to
+// marshal their attributes are compared as if they had none.
+func attrs(n ast.Node) attributes {
+	as, _ := marshalAttrs(n)
+	return as
+}
+
+// marshalAttrs returns the attributes of n, as attrs does, and the error of
+// marshaling them, if any.
+func marshalAttrs(n ast.Node) (attributes, error) {
+	as := attributes{}
+	if n == nil {
+		return as, nil
+	}
+	if id := n.ID(); id != "" {
+		as[ast.NodeIDAttrName] = id
+	}
+	if cs := n.Classes(); len(cs) > 0 {
+		as[ast.NodeClassAttrName] = strings.Join(cs, " ")
+	}
+
+	switch n := n.(type) {
+	case *ast.Text:
+		as[TextAttrName] = n.Value()
+		if e := n.Emphasis(); e != ast.EmphasisNone {
+			as[textEmphasisAttrName] = string(e)
+		}
+
+	case *ast.Section:
+		if n.Title != "" {
+			as[SectionTitleAttrName] = n.Title
+		}
+		if n.Language != "" {
+			as[sectionLangAttrName] = n.Language
+		}
+		if c := n.Condition(); len(c) > 0 {
+			as[sectionIfAttrName] = c.String()
+		}
+		if n.Ending {
+			as[sectionEndingAttrName] = "true"
+		}
+		if len(n.Stylesheets) > 0 {
+			as[SectionStylesheetsAttrName] = strings.Join(n.Stylesheets, " ")
+		}
+		if p := n.Layout.Progression; p != ast.ProgressionDefault {
+			as[sectionProgressionAttrName] = string(p)
+		}
+		if s := n.Layout.Spread; s != ast.SpreadAuto {
+			as[sectionSpreadAttrName] = string(s)
+		}
+
+	case *ast.Unknown:
+		xas, err := n.MarshalXMLAttrs()
+		if err != nil {
+			return as, err
+		}
+		for _, a := range xas {
+			as[a.Name] = a.Value
+		}
+		if inner := strings.TrimSpace(string(n.InnerXML())); inner != "" {
+			as[InnerXMLAttrName] = inner
+		}
+
+	case ast.AttributesMarshaler:
+		xas, err := n.MarshalXMLAttrs()
+		if err != nil {
+			return as, err
+		}
+		for _, a := range xas {
+			as[a.Name] = a.Value
+		}
+	}
+
+	return as, nil
+}
+
+// names returns the names of all attributes in as, sorted.
+func (as attributes) names() []xml.Name {
+	ns := make([]xml.Name, 0, len(as))
+	for n := range as {
+		ns = append(ns, n)
+	}
+	slices.SortFunc(ns, func(a, b xml.Name) int {
+		return strings.Compare(attr.FmtXMLName(a), attr.FmtXMLName(b))
+	})
+	return ns
+}
+
+// setAttrs sets the attributes of a new node n, as returned by attrs.
+func setAttrs(n ast.Node, as attributes) error {
+	n.SetID(as[ast.NodeIDAttrName])
+	if cs := strings.Fields(as[ast.NodeClassAttrName]); len(cs) > 0 {
+		n.SetClasses(cs)
+	}
+
+	switch n := n.(type) {
+	case *ast.Text:
+		n.SetValue(as[TextAttrName])
+		n.SetEmphasis(ast.Emphasis(as[textEmphasisAttrName]))
+
+	case *ast.Section:
+		n.Title = as[SectionTitleAttrName]
+		n.Language = as[sectionLangAttrName]
+		n.Ending = as[sectionEndingAttrName] == "true"
+		if hrefs := strings.Fields(as[SectionStylesheetsAttrName]); len(hrefs) > 0 {
+			n.Stylesheets = hrefs
+		}
+		n.Layout = ast.Layout{
+			Progression: ast.Progression(as[sectionProgressionAttrName]),
+			Spread:      ast.SpreadMode(as[sectionSpreadAttrName]),
+		}
+		c, err := ast.ParseCondition(as[sectionIfAttrName])
+		if err != nil {
+			return err
+		}
+		n.SetCondition(c)
+
+	case *ast.Unknown:
+		n.SetInnerXML([]byte(as[InnerXMLAttrName]))
+		xas := make([]xml.Attr, 0, len(as))
+		for _, name := range as.names() {
+			if !isBaseAttr(name) && name != InnerXMLAttrName {
+				xas = append(xas, xml.Attr{Name: name, Value: as[name]})
+			}
+		}
+		return n.UnmarshalXMLAttrs(xas)
+
+	case ast.AttributesUnmarshaler:
+		xas := make([]xml.Attr, 0, len(as))
+		for _, name := range as.names() {
+			if !isBaseAttr(name) {
+				xas = append(xas, xml.Attr{Name: name, Value: as[name]})
+			}
+		}
+		return n.UnmarshalXMLAttrs(xas)
+
+	default:
+		for name := range as {
+			if !isBaseAttr(name) {
+				return fmt.Errorf("%q node has no attribute %q", n.Kind(), attr.FmtXMLName(name))
+			}
+		}
+	}
+
+	return nil
+}
+
+// isBaseAttr reports whether name is of one of the attributes which all nodes
+// have, which are set by setAttrs before the ones of the node's kind.
+func isBaseAttr(name xml.Name) bool {
+	return name == ast.NodeIDAttrName || name == ast.NodeClassAttrName
+}
+
+// newNode returns a new node of the same kind as src.
+func newNode(src ast.Node) (ast.Node, error) {
+	if _, ok := src.(*ast.Unknown); ok {
+		return &ast.Unknown{}, nil
+	}
+	return ast.NewNode(src.Kind())
+}
+
+// copyState copies the data of src which is not compared, such as the href of
+// sections, into the new node n.
+func copyState(n, src ast.Node) {
+	switch n := n.(type) {
+	case *ast.Unknown:
+		u := src.(*ast.Unknown)
+		n.SetName(u.Name())
+		n.SetKind(u.Kind())
+	case *ast.Section:
+		n.SetHref(src.(*ast.Section).Href())
+	case *ast.Package:
+		p := src.(*ast.Package)
+		n.Metadata = p.Metadata
+		n.Resources = p.Resources
+		n.Layout = p.Layout
+	}
+}
+
+// appendChild appends c to n, setting it as the body if n is a section.
+func appendChild(n, c ast.Node) {
+	if s, ok := n.(*ast.Section); ok {
+		if b, ok := c.(*ast.Body); ok {
+			s.SetBody(b)
+			return
+		}
+	}
+	n.AppendChild(n, c)
+}
@@ -0,0 +1,366 @@
+// Package diff compares ipub trees, reporting the changes between two revisions
+// of a section, and merges concurrent edits made to the same revision.
+//
+// Nodes are matched between trees by their IDs first, see [ast.Node.ID]. Nodes
+// without IDs are matched by their position among the siblings of the same kind,
+// preferring siblings with the same content, and subtrees which are equal in both
+// trees but in different places are matched as moved. Giving IDs to nodes which
+// are edited often, such as panels and balloons, makes their changes easier to
+// follow.
+package diff
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
+)
+
+type Op string
+
+const (
+	OpInsert Op = "insert"
+	OpDelete Op = "delete"
+	OpMove   Op = "move"
+	OpAttr   Op = "attr"
+)
+
+// Change is a difference between two trees.
+type Change struct {
+	Op Op
+
+	// Old is the node in the old tree, nil for insertions.
+	Old ast.Node
+	// New is the node in the new tree, nil for deletions.
+	New ast.Node
+
+	// Attr is the name of the changed attribute, for attribute changes. Values
+	// are empty if the attribute is not set in that tree.
+	Attr     xml.Name
+	OldValue string
+	NewValue string
+}
+
+func (c Change) String() string {
+	switch c.Op {
+	case OpInsert:
+		return fmt.Sprintf("insert %s", ast.Path(c.New))
+	case OpDelete:
+		return fmt.Sprintf("delete %s", ast.Path(c.Old))
+	case OpMove:
+		return fmt.Sprintf("move %s to %s", ast.Path(c.Old), ast.Path(c.New))
+	default:
+		return fmt.Sprintf("attr %s %s: %q to %q", ast.Path(c.New), attr.FmtXMLName(c.Attr), c.OldValue, c.NewValue)
+	}
+}
+
+// Diff returns the changes which turn the tree of a into the tree of b. Deletions
+// are reported in the order of the nodes in a, followed by the other changes in
+// the order of the nodes in b. Only the topmost node of inserted or deleted
+// subtrees is reported, but nodes moved into or out of them are reported as moves.
+//
+// The roots are always compared with each other, so they should be the same node
+// in both revisions, such as a section.
+func Diff(a, b ast.Node) []Change {
+	m := match(a, b)
+	cs := []Change{}
+
+	_ = ast.Walk(a, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if m.ab[n] == nil && (n.Parent() == nil || m.ab[n.Parent()] != nil) {
+			cs = append(cs, Change{Op: OpDelete, Old: n})
+		}
+		return ast.WalkContinue, nil
+	})
+
+	_ = ast.Walk(b, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		an := m.ba[n]
+		if an == nil {
+			if n.Parent() == nil || m.ba[n.Parent()] != nil {
+				cs = append(cs, Change{Op: OpInsert, New: n})
+			}
+			return ast.WalkContinue, nil
+		}
+
+		if m.moved(an, n) {
+			cs = append(cs, Change{Op: OpMove, Old: an, New: n})
+		}
+
+		aas, bas := attrs(an), attrs(n)
+		for _, name := range union(aas, bas).names() {
+			if av, bv := aas[name], bas[name]; av != bv {
+				cs = append(cs, Change{Op: OpAttr, Old: an, New: n, Attr: name, OldValue: av, NewValue: bv})
+			}
+		}
+
+		return ast.WalkContinue, nil
+	})
+
+	return cs
+}
+
+func union(as ...attributes) attributes {
+	u := attributes{}
+	for _, a := range as {
+		for n := range a {
+			u[n] = ""
+		}
+	}
+	return u
+}
+
+// matching pairs the nodes of two trees.
+type matching struct {
+	ab map[ast.Node]ast.Node
+	ba map[ast.Node]ast.Node
+
+	hashes map[ast.Node]string
+	// stable holds the nodes of the second tree which kept their order among
+	// their matched siblings, for each parent already checked.
+	stable map[ast.Node]map[ast.Node]bool
+}
+
+func match(a, b ast.Node) *matching {
+	m := &matching{
+		ab:     map[ast.Node]ast.Node{},
+		ba:     map[ast.Node]ast.Node{},
+		hashes: map[ast.Node]string{},
+		stable: map[ast.Node]map[ast.Node]bool{},
+	}
+	if a.Kind() != b.Kind() {
+		return m
+	}
+
+	queue := [][2]ast.Node{{a, b}}
+	m.pair(a, b)
+
+	ids := map[string]ast.Node{}
+	_ = ast.Walk(b, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if id := n.ID(); entering && id != "" && ids[id] == nil {
+			ids[id] = n
+		}
+		return ast.WalkContinue, nil
+	})
+	_ = ast.Walk(a, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering || n.ID() == "" || m.ab[n] != nil {
+			return ast.WalkContinue, nil
+		}
+		if bn := ids[n.ID()]; bn != nil && bn.Kind() == n.Kind() && m.ba[bn] == nil {
+			m.pair(n, bn)
+			queue = append(queue, [2]ast.Node{n, bn})
+		}
+		return ast.WalkContinue, nil
+	})
+
+	m.matchQueue(queue)
+
+	// Subtrees left unmatched in both trees with the same content were moved.
+	moved := map[string][]ast.Node{}
+	_ = ast.Walk(b, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering && m.ba[n] == nil {
+			h := m.hash(n)
+			moved[h] = append(moved[h], n)
+		}
+		return ast.WalkContinue, nil
+	})
+	_ = ast.Walk(a, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering || m.ab[n] != nil {
+			return ast.WalkContinue, nil
+		}
+		h := m.hash(n)
+		if h == "" {
+			return ast.WalkContinue, nil
+		}
+		for _, bn := range moved[h] {
+			if m.ba[bn] == nil {
+				m.pair(n, bn)
+				m.matchQueue([][2]ast.Node{{n, bn}})
+				break
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+
+	return m
+}
+
+func (m *matching) pair(a, b ast.Node) {
+	m.ab[a] = b
+	m.ba[b] = a
+}
+
+// matchQueue matches the children of each pair in queue, and the children of the
+// pairs created by it.
+func (m *matching) matchQueue(queue [][2]ast.Node) {
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		queue = append(queue, m.children(p[0], p[1])...)
+	}
+}
+
+// children matches the unmatched children of a and b. Children with the same
+// content are matched first, and then, between them, children of the same kind.
+func (m *matching) children(a, b ast.Node) [][2]ast.Node {
+	ca, cb := m.unmatchedChildren(a, m.ab), m.unmatchedChildren(b, m.ba)
+
+	same := lcs(len(ca), len(cb), func(i, j int) bool {
+		return m.unchanged(ca[i], cb[j])
+	})
+
+	pairs := [][2]ast.Node{}
+	pi, pj := 0, 0
+	for _, p := range append(same, [2]int{len(ca), len(cb)}) {
+		ga, gb := ca[pi:p[0]], cb[pj:p[1]]
+		for _, q := range lcs(len(ga), len(gb), func(i, j int) bool {
+			return similar(ga[i], gb[j])
+		}) {
+			pairs = append(pairs, [2]ast.Node{ga[q[0]], gb[q[1]]})
+		}
+		if p[0] < len(ca) {
+			pairs = append(pairs, [2]ast.Node{ca[p[0]], cb[p[1]]})
+		}
+		pi, pj = p[0]+1, p[1]+1
+	}
+
+	for _, p := range pairs {
+		m.pair(p[0], p[1])
+	}
+	return pairs
+}
+
+// similar reports whether a and b can be the same node with different content.
+func similar(a, b ast.Node) bool {
+	if a.Kind() != b.Kind() {
+		return false
+	}
+	return a.ID() == "" || b.ID() == "" || a.ID() == b.ID()
+}
+
+func (m *matching) unmatchedChildren(n ast.Node, matched map[ast.Node]ast.Node) []ast.Node {
+	cs := []ast.Node{}
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		if matched[c] == nil {
+			cs = append(cs, c)
+		}
+	}
+	return cs
+}
+
+// hash returns a digest of the subtree of n, used to find equal subtrees. It is
+// built from the kind and attributes of n, see attrs, and the hashes of its
+// children, so each node of a tree is only encoded once. It is empty if the
+// attributes of n or of any of its descendants can't be marshaled, in which case
+// the subtree must not be considered equal to any other.
+func (m *matching) hash(n ast.Node) string {
+	if h, ok := m.hashes[n]; ok {
+		return h
+	}
+	m.hashes[n] = ""
+
+	as, err := marshalAttrs(n)
+	if err != nil {
+		return ""
+	}
+
+	d := sha256.New()
+	fmt.Fprintf(d, "%q\n", n.Kind())
+	for _, name := range as.names() {
+		fmt.Fprintf(d, "%q %q\n", attr.FmtXMLName(name), as[name])
+	}
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		h := m.hash(c)
+		if h == "" {
+			return ""
+		}
+		fmt.Fprintf(d, "%s\n", h)
+	}
+
+	h := hex.EncodeToString(d.Sum(nil))
+	m.hashes[n] = h
+	return h
+}
+
+// unchanged reports whether the subtrees of a and b are equal. Subtrees which
+// can't be hashed are always considered changed.
+func (m *matching) unchanged(a, b ast.Node) bool {
+	h := m.hash(a)
+	return h != "" && h == m.hash(b)
+}
+
+// moved reports whether the node b, matched with a, is in a different parent or
+// changed its order among its matched siblings.
+func (m *matching) moved(a, b ast.Node) bool {
+	pa, pb := a.Parent(), b.Parent()
+	if pa == nil || pb == nil {
+		return pa != pb
+	}
+	if m.ab[pa] != pb {
+		return true
+	}
+
+	stable, ok := m.stable[pb]
+	if !ok {
+		ca, cb := []ast.Node{}, []ast.Node{}
+		for c := pa.FirstChild(); c != nil; c = c.NextSibling() {
+			if mc := m.ab[c]; mc != nil && mc.Parent() == pb {
+				ca = append(ca, c)
+			}
+		}
+		for c := pb.FirstChild(); c != nil; c = c.NextSibling() {
+			if mc := m.ba[c]; mc != nil && mc.Parent() == pa {
+				cb = append(cb, c)
+			}
+		}
+
+		stable = map[ast.Node]bool{}
+		for _, p := range lcs(len(ca), len(cb), func(i, j int) bool { return m.ab[ca[i]] == cb[j] }) {
+			stable[cb[p[1]]] = true
+		}
+		m.stable[pb] = stable
+	}
+
+	return !stable[b]
+}
+
+// lcs returns the pairs of indexes of the longest common subsequence of two lists
+// with lengths n and l, in order.
+func lcs(n, l int, eq func(i, j int) bool) [][2]int {
+	t := make([][]int, n+1)
+	for i := range t {
+		t[i] = make([]int, l+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := l - 1; j >= 0; j-- {
+			if eq(i, j) {
+				t[i][j] = t[i+1][j+1] + 1
+			} else {
+				t[i][j] = max(t[i+1][j], t[i][j+1])
+			}
+		}
+	}
+
+	ps := [][2]int{}
+	for i, j := 0, 0; i < n && j < l; {
+		switch {
+		case eq(i, j):
+			ps = append(ps, [2]int{i, j})
+			i++
+			j++
+		case t[i+1][j] >= t[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return ps
+}
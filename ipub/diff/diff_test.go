@@ -0,0 +1,304 @@
+package diff_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"testing"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/diff"
+	"forge.capytal.company/loreddev/x/tinyssert"
+)
+
+const base = `<html xmlns="http://www.w3.org/1999/xhtml">
+	<head>
+		<title>Chapter 1</title>
+	</head>
+	<body data-ipub-element="body">
+		<section data-ipub-element="content">
+			<img data-ipub-element="image" src="images/001.png" alt="Ana at the docks."></img>
+			<div data-ipub-element="panel" id="p1" data-ipub-order="1" data-ipub-bounds="0 0 100 50"></div>
+			<div data-ipub-element="panel" id="p2" data-ipub-order="2" data-ipub-bounds="0 50 100 50"></div>
+			<p data-ipub-element="caption" data-ipub-anchor="5,5">
+				<span data-ipub-element="text">Meanwhile...</span>
+			</p>
+			<p data-ipub-element="speech" id="s1" data-ipub-speaker="ana" data-ipub-anchor="70,20">
+				<span data-ipub-element="text">Get down!</span>
+			</p>
+		</section>
+	</body>
+</html>`
+
+func TestDiff(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	a := parse(t, base)
+	b := parse(t, `<html xmlns="http://www.w3.org/1999/xhtml">
+	<head>
+		<title>Chapter One</title>
+	</head>
+	<body data-ipub-element="body">
+		<section data-ipub-element="content">
+			<img data-ipub-element="image" src="images/001.png" alt="Ana at the docks."></img>
+			<div data-ipub-element="panel" id="p2" data-ipub-order="2" data-ipub-bounds="0 50 100 50"></div>
+			<div data-ipub-element="panel" id="p1" data-ipub-order="1" data-ipub-bounds="0 0 100 40"></div>
+			<p data-ipub-element="speech" id="s1" data-ipub-speaker="ana" data-ipub-anchor="70,20">
+				<span data-ipub-element="text">Get down, Leo!</span>
+			</p>
+			<p data-ipub-element="sfx" data-ipub-anchor="50,80">
+				<span data-ipub-element="text">BLAM</span>
+			</p>
+		</section>
+		<section data-ipub-element="content">
+			<p data-ipub-element="caption" data-ipub-anchor="5,5">
+				<span data-ipub-element="text">Meanwhile...</span>
+			</p>
+		</section>
+	</body>
+</html>`)
+
+	changes := []string{}
+	for _, c := range diff.Diff(a, b) {
+		changes = append(changes, c.String())
+	}
+
+	assert.Equal([]string{
+		`attr section #title: "Chapter 1" to "Chapter One"`,
+		`move section/body[0]/content[0]/panel[0] to section/body[0]/content[0]/panel[1]`,
+		`attr section/body[0]/content[0]/panel[1] data-ipub-bounds: "0 0 100 50" to "0 0 100 40"`,
+		`attr section/body[0]/content[0]/speech[0]/text[0] #text: "Get down!" to "Get down, Leo!"`,
+		`insert section/body[0]/content[0]/sfx[0]`,
+		`insert section/body[0]/content[1]`,
+		`move section/body[0]/content[0]/caption[0] to section/body[0]/content[1]/caption[0]`,
+	}, changes)
+
+	assert.Equal(0, len(diff.Diff(a, parse(t, base))))
+}
+
+func TestDiffDelete(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	a := parse(t, base)
+	b := parse(t, base)
+	c := ast.FindFirst(b, ast.KindContent)
+	c.RemoveChild(c, ast.FindFirst(c, ast.KindCaption))
+	c.RemoveChild(c, ast.FindFirst(c, ast.KindImage))
+
+	changes := diff.Diff(a, b)
+	assert.Equal(2, len(changes))
+	assert.Equal(diff.OpDelete, changes[0].Op)
+	assert.Equal(ast.FindFirst(a, ast.KindImage), changes[0].Old)
+	assert.Equal(diff.OpDelete, changes[1].Op)
+	assert.Equal(ast.FindFirst(a, ast.KindCaption), changes[1].Old)
+}
+
+func TestMerge(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	// Ours moves the second panel, resizes the first one, and adds a sound effect.
+	ours := parse(t, `<html xmlns="http://www.w3.org/1999/xhtml">
+	<head>
+		<title>Chapter 1</title>
+	</head>
+	<body data-ipub-element="body">
+		<section data-ipub-element="content">
+			<img data-ipub-element="image" src="images/001.png" alt="Ana at the docks."></img>
+			<div data-ipub-element="panel" id="p2" data-ipub-order="2" data-ipub-bounds="0 50 100 50"></div>
+			<div data-ipub-element="panel" id="p1" data-ipub-order="1" data-ipub-bounds="0 0 100 40"></div>
+			<p data-ipub-element="caption" data-ipub-anchor="5,5">
+				<span data-ipub-element="text">Meanwhile...</span>
+			</p>
+			<p data-ipub-element="speech" id="s1" data-ipub-speaker="ana" data-ipub-anchor="70,20">
+				<span data-ipub-element="text">Get down!</span>
+			</p>
+			<p data-ipub-element="sfx" data-ipub-anchor="50,80">
+				<span data-ipub-element="text">BLAM</span>
+			</p>
+		</section>
+	</body>
+</html>`)
+
+	// Theirs changes the text of the speech, fixes the alt text, and adds a
+	// thought after the caption.
+	theirs := parse(t, `<html xmlns="http://www.w3.org/1999/xhtml">
+	<head>
+		<title>Chapter 1</title>
+	</head>
+	<body data-ipub-element="body">
+		<section data-ipub-element="content">
+			<img data-ipub-element="image" src="images/001.png" alt="Ana hides at the docks."></img>
+			<div data-ipub-element="panel" id="p1" data-ipub-order="1" data-ipub-bounds="0 0 100 50"></div>
+			<div data-ipub-element="panel" id="p2" data-ipub-order="2" data-ipub-bounds="0 50 100 50"></div>
+			<p data-ipub-element="caption" data-ipub-anchor="5,5">
+				<span data-ipub-element="text">Meanwhile...</span>
+			</p>
+			<p data-ipub-element="thought" data-ipub-speaker="leo" data-ipub-anchor="20,60">
+				<span data-ipub-element="text">Not again.</span>
+			</p>
+			<p data-ipub-element="speech" id="s1" data-ipub-speaker="ana" data-ipub-anchor="70,20">
+				<span data-ipub-element="text">Get DOWN!</span>
+			</p>
+		</section>
+	</body>
+</html>`)
+
+	merged, conflicts, err := diff.Merge(parse(t, base), ours, theirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(0, len(conflicts))
+
+	assert.Equal(`<html xmlns="http://www.w3.org/1999/xhtml">
+	<head>
+		<title>Chapter 1</title>
+	</head>
+	<body data-ipub-element="body">
+		<section data-ipub-element="content">
+			<img data-ipub-element="image" src="images/001.png" alt="Ana hides at the docks."></img>
+			<div data-ipub-element="panel" id="p2" data-ipub-order="2" data-ipub-bounds="0 50 100 50"></div>
+			<div data-ipub-element="panel" id="p1" data-ipub-order="1" data-ipub-bounds="0 0 100 40"></div>
+			<p data-ipub-element="caption" data-ipub-anchor="5,5">
+				<span data-ipub-element="text">Meanwhile...</span>
+			</p>
+			<p data-ipub-element="thought" data-ipub-speaker="leo" data-ipub-anchor="20,60">
+				<span data-ipub-element="text">Not again.</span>
+			</p>
+			<p data-ipub-element="speech" id="s1" data-ipub-speaker="ana" data-ipub-anchor="70,20">
+				<span data-ipub-element="text">Get DOWN!</span>
+			</p>
+			<p data-ipub-element="sfx" data-ipub-anchor="50,80">
+				<span data-ipub-element="text">BLAM</span>
+			</p>
+		</section>
+	</body>
+</html>`, encode(t, merged))
+}
+
+func TestMergeConflicts(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	ours := parse(t, base)
+	theirs := parse(t, base)
+
+	// Both sides resize the first panel.
+	ast.FindFirst(ours, ast.KindPanel).(*ast.Panel).SetBounds(ast.Rect{Width: 100, Height: 40})
+	ast.FindFirst(theirs, ast.KindPanel).(*ast.Panel).SetBounds(ast.Rect{Width: 100, Height: 60})
+
+	// Ours deletes the speech, which theirs changes.
+	c := ast.FindFirst(ours, ast.KindContent)
+	c.RemoveChild(c, ast.FindFirst(c, ast.KindSpeech))
+	ast.FindFirst(theirs, ast.KindSpeech).(*ast.Speech).SetSpeaker("leo")
+
+	// Theirs deletes the caption, which ours doesn't change.
+	c = ast.FindFirst(theirs, ast.KindContent)
+	c.RemoveChild(c, ast.FindFirst(c, ast.KindCaption))
+
+	merged, conflicts, err := diff.Merge(parse(t, base), ours, theirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := []string{}
+	for _, c := range conflicts {
+		messages = append(messages, c.String())
+	}
+	assert.Equal([]string{
+		`section/body[0]/content[0]/speech[0]: deleted by ours and changed by theirs`,
+		`section/body[0]/content[0]/panel[0] data-ipub-bounds: changed to "0 0 100 40" by ours and "0 0 100 60" by theirs`,
+	}, messages)
+
+	mc := ast.FindFirst(merged, ast.KindContent)
+	assert.Equal(ast.Rect{Width: 100, Height: 40}, ast.FindFirst(mc, ast.KindPanel).(*ast.Panel).Bounds())
+	assert.Equal("leo", ast.FindFirst(mc, ast.KindSpeech).(*ast.Speech).Speaker())
+	assert.Equal(nil, ast.FindFirst(mc, ast.KindCaption))
+}
+
+func TestMergeUnknown(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	doc := func(pack, inner string) string {
+		return `<html xmlns="http://www.w3.org/1999/xhtml">
+	<body data-ipub-element="body">
+		<section data-ipub-element="content">
+			<div data-ipub-element="sticker" data-ipub-pack="` + pack + `">` + inner + `</div>
+		</section>
+	</body>
+</html>`
+	}
+
+	base := parse(t, doc("cats", `<img src="cat.png"></img>`))
+	ours := parse(t, doc("dogs", `<img src="cat.png"></img>`))
+	theirs := parse(t, doc("cats", `<img src="dog.png"></img>`))
+
+	changes := []string{}
+	for _, c := range diff.Diff(base, theirs) {
+		changes = append(changes, c.String())
+	}
+	assert.Equal([]string{
+		`attr section/body[0]/content[0]/sticker[0] #inner: "<img src=\"cat.png\"></img>" to "<img src=\"dog.png\"></img>"`,
+	}, changes)
+
+	merged, conflicts, err := diff.Merge(base, ours, theirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(0, len(conflicts))
+	assert.Equal(doc("dogs", "\n\t\t\t\t<img src=\"dog.png\"></img>\n\t\t\t"), encode(t, merged))
+}
+
+// brokenPanel is a panel whose attributes can't be marshaled.
+type brokenPanel struct {
+	ast.Panel
+}
+
+func (p *brokenPanel) MarshalXMLAttrs() ([]xml.Attr, error) {
+	return nil, errors.New("broken panel")
+}
+
+func TestMergeUnhashable(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	tree := func(panel bool) *ast.Section {
+		c := &ast.Content{}
+		if panel {
+			c.AppendChild(c, &brokenPanel{})
+		}
+		b := &ast.Body{}
+		b.AppendChild(b, c)
+		s := &ast.Section{}
+		s.SetBody(b)
+		return s
+	}
+
+	// Theirs keeps the panel, which can't be compared with the base one, so it is
+	// not known to be unchanged.
+	_, conflicts, err := diff.Merge(tree(true), tree(false), tree(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := []string{}
+	for _, c := range conflicts {
+		messages = append(messages, c.String())
+	}
+	assert.Equal([]string{`section/body[0]/content[0]/panel[0]: deleted by ours and changed by theirs`}, messages)
+}
+
+func parse(t *testing.T, s string) *ast.Section {
+	var sec ast.Section
+	if err := xml.Unmarshal([]byte(s), &sec); err != nil {
+		t.Fatal(err)
+	}
+	return &sec
+}
+
+func encode(t *testing.T, n ast.Node) string {
+	var b bytes.Buffer
+	e := xml.NewEncoder(&b)
+	e.Indent("", "\t")
+	if err := e.Encode(n); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
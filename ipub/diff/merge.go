@@ -0,0 +1,307 @@
+package diff
+
+import (
+	"encoding/xml"
+	"fmt"
+	"slices"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
+)
+
+// Conflict is a change made differently by both sides of a merge. The merged tree
+// keeps the version of ours in conflicts, unless ours deleted a node which theirs
+// changed, in which case the node is kept.
+type Conflict struct {
+	// Nodes of each tree involved in the conflict, nil if the node doesn't exist
+	// in that tree.
+	Base   ast.Node
+	Ours   ast.Node
+	Theirs ast.Node
+
+	// Attr is the name of the attribute changed by both sides, if the conflict is
+	// about an attribute.
+	Attr xml.Name
+
+	Message string
+}
+
+func (c Conflict) String() string {
+	n := c.Base
+	if n == nil {
+		n = c.Ours
+	}
+	if c.Attr != (xml.Name{}) {
+		return fmt.Sprintf("%s %s: %s", ast.Path(n), attr.FmtXMLName(c.Attr), c.Message)
+	}
+	return fmt.Sprintf("%s: %s", ast.Path(n), c.Message)
+}
+
+// Merge combines the changes made to base by ours and theirs into a new tree,
+// which shares no nodes with the given ones. Changes made by only one side are
+// applied, and changes made by both sides are applied if they are the same, or
+// reported as conflicts otherwise.
+//
+// Children inserted by theirs are placed after the closest preceding sibling
+// which is also in the merged tree. The order of children is taken from theirs
+// only if ours didn't reorder them. Conflicts about the children of a node are
+// reported before the conflicts of the children themselves.
+func Merge(base, ours, theirs ast.Node) (ast.Node, []Conflict, error) {
+	if base.Kind() != ours.Kind() || base.Kind() != theirs.Kind() {
+		return nil, nil, fmt.Errorf("diff: can't merge trees with roots of different kinds")
+	}
+
+	m := &merger{
+		ours:      match(base, ours),
+		theirs:    match(base, theirs),
+		locations: map[ast.Node]side{},
+	}
+
+	n, err := m.build(base, ours, theirs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return n, m.conflicts, nil
+}
+
+type side int
+
+const (
+	sideNone side = iota
+	sideOurs
+	sideTheirs
+	sideBoth
+)
+
+type merger struct {
+	ours   *matching
+	theirs *matching
+
+	// locations caches the side whose parent each node of base is placed in.
+	locations map[ast.Node]side
+	conflicts []Conflict
+}
+
+func (m *merger) conflict(b, o, t ast.Node, format string, a ...any) {
+	m.conflicts = append(m.conflicts, Conflict{Base: b, Ours: o, Theirs: t, Message: fmt.Sprintf(format, a...)})
+}
+
+// location returns in which side's tree the node b of base ends up, or sideNone if
+// it is deleted. sideBoth is returned if both sides have the node in the same
+// parent.
+func (m *merger) location(b ast.Node) side {
+	if l, ok := m.locations[b]; ok {
+		return l
+	}
+
+	o, t := m.ours.ab[b], m.theirs.ab[b]
+	oMoved := o != nil && m.ours.ab[b.Parent()] != o.Parent()
+	tMoved := t != nil && m.theirs.ab[b.Parent()] != t.Parent()
+
+	var l side
+	switch {
+	case o == nil && t == nil:
+		l = sideNone
+
+	case o == nil:
+		if m.theirs.unchanged(b, t) && !tMoved {
+			l = sideNone
+		} else {
+			m.conflict(b, o, t, "deleted by ours and changed by theirs")
+			l = sideTheirs
+		}
+
+	case t == nil:
+		if m.ours.unchanged(b, o) && !oMoved {
+			l = sideNone
+		} else {
+			m.conflict(b, o, t, "changed by ours and deleted by theirs")
+			l = sideOurs
+		}
+
+	case oMoved && tMoved:
+		if m.ours.ba[o.Parent()] != m.theirs.ba[t.Parent()] || m.ours.ba[o.Parent()] == nil {
+			m.conflict(b, o, t, "moved to different places by ours and theirs")
+			l = sideOurs
+		} else {
+			l = sideBoth
+		}
+
+	case oMoved:
+		l = sideOurs
+
+	case tMoved:
+		l = sideTheirs
+
+	default:
+		l = sideBoth
+	}
+
+	m.locations[b] = l
+	return l
+}
+
+// build creates the merged node of the nodes b, o and t, any of which can be nil
+// if the node doesn't exist in that tree.
+func (m *merger) build(b, o, t ast.Node) (ast.Node, error) {
+	src := o
+	if src == nil {
+		src = t
+	}
+	if src == nil {
+		src = b
+	}
+
+	n, err := newNode(src)
+	if err != nil {
+		return nil, fmt.Errorf("diff: %w", err)
+	}
+	copyState(n, src)
+
+	if err := setAttrs(n, m.attrs(b, o, t)); err != nil {
+		return nil, fmt.Errorf("diff: unable to set attributes of %q node: %w", n.Kind(), err)
+	}
+
+	type entry struct{ b, o, t ast.Node }
+	entries := []entry{}
+	newEntry := func(s side, c ast.Node) entry {
+		bc := m.matching(s).ba[c]
+		if bc == nil {
+			if s == sideOurs {
+				return entry{o: c}
+			}
+			return entry{t: c}
+		}
+		return entry{b: bc, o: m.ours.ab[bc], t: m.theirs.ab[bc]}
+	}
+
+	primary, secondary := m.order(b, o, t)
+	if p := m.node(primary, o, t); p != nil {
+		for c := p.FirstChild(); c != nil; c = c.NextSibling() {
+			e := newEntry(primary, c)
+			if e.b == nil || m.location(e.b) == primary || m.location(e.b) == sideBoth {
+				entries = append(entries, e)
+			}
+		}
+	}
+
+	if s := m.node(secondary, o, t); s != nil {
+		i := 0
+		for c := s.FirstChild(); c != nil; c = c.NextSibling() {
+			e := newEntry(secondary, c)
+			if e.b != nil && m.location(e.b) == sideBoth {
+				if j := slices.IndexFunc(entries, func(f entry) bool { return f.b == e.b }); j != -1 {
+					i = j + 1
+				}
+				continue
+			}
+			if e.b != nil && m.location(e.b) != secondary {
+				continue
+			}
+			entries = slices.Insert(entries, i, e)
+			i++
+		}
+	}
+
+	for _, e := range entries {
+		c, err := m.build(e.b, e.o, e.t)
+		if err != nil {
+			return nil, err
+		}
+		appendChild(n, c)
+	}
+
+	return n, nil
+}
+
+// attrs merges the attributes of the nodes b, o and t.
+func (m *merger) attrs(b, o, t ast.Node) attributes {
+	switch {
+	case o == nil:
+		return attrs(t)
+	case t == nil:
+		return attrs(o)
+	case b == nil:
+		return attrs(o)
+	}
+
+	bas, oas, tas := attrs(b), attrs(o), attrs(t)
+	merged := attributes{}
+	for _, name := range union(bas, oas, tas).names() {
+		bv, bok := bas[name]
+		ov, ook := oas[name]
+		tv, tok := tas[name]
+
+		v, ok := ov, ook
+		switch {
+		case ov == tv && ook == tok:
+		case ov == bv && ook == bok:
+			v, ok = tv, tok
+		case tv == bv && tok == bok:
+		default:
+			m.conflicts = append(m.conflicts, Conflict{
+				Base: b, Ours: o, Theirs: t, Attr: name,
+				Message: fmt.Sprintf("changed to %q by ours and %q by theirs", ov, tv),
+			})
+		}
+
+		if ok {
+			merged[name] = v
+		}
+	}
+	return merged
+}
+
+// order returns the side whose order of children is used, and the other one. It
+// is theirs only if ours kept the order of the children of b, and theirs didn't.
+func (m *merger) order(b, o, t ast.Node) (side, side) {
+	switch {
+	case o == nil:
+		return sideTheirs, sideOurs
+	case t == nil || b == nil:
+		return sideOurs, sideTheirs
+	}
+
+	bo, oo := m.kept(b, o, m.ours)
+	bt, to := m.kept(b, t, m.theirs)
+	oChanged, tChanged := !slices.Equal(bo, oo), !slices.Equal(bt, to)
+
+	if oChanged && tChanged && !slices.Equal(oo, to) {
+		m.conflict(b, o, t, "children reordered by both ours and theirs")
+	}
+	if !oChanged && tChanged {
+		return sideTheirs, sideOurs
+	}
+	return sideOurs, sideTheirs
+}
+
+// kept returns the children of b which are still children of its match x, in the
+// order of b and in the order of x.
+func (m *merger) kept(b, x ast.Node, mt *matching) ([]ast.Node, []ast.Node) {
+	inB, inX := []ast.Node{}, []ast.Node{}
+	for c := b.FirstChild(); c != nil; c = c.NextSibling() {
+		if xc := mt.ab[c]; xc != nil && xc.Parent() == x {
+			inB = append(inB, c)
+		}
+	}
+	for c := x.FirstChild(); c != nil; c = c.NextSibling() {
+		if bc := mt.ba[c]; bc != nil && bc.Parent() == b {
+			inX = append(inX, bc)
+		}
+	}
+	return inB, inX
+}
+
+func (m *merger) matching(s side) *matching {
+	if s == sideOurs {
+		return m.ours
+	}
+	return m.theirs
+}
+
+func (m *merger) node(s side, o, t ast.Node) ast.Node {
+	if s == sideOurs {
+		return o
+	}
+	return t
+}
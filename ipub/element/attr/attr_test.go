@@ -0,0 +1,152 @@
+package attr_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"image/color"
+	"testing"
+	"time"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
+	"forge.capytal.company/loreddev/x/tinyssert"
+)
+
+type tail string
+
+func (tail) Values() []tail {
+	return []tail{"n", "s", "e", "w"}
+}
+
+type element struct {
+	XMLName  xml.Name        `xml:"div"`
+	Src      attr.URL        `xml:"src,attr,omitempty"`
+	Width    attr.Length     `xml:"width,attr"`
+	Color    attr.Color      `xml:"color,attr"`
+	Lang     attr.Lang       `xml:"lang,attr,omitempty"`
+	ID       attr.ID         `xml:"id,attr,omitempty"`
+	For      attr.IDRef      `xml:"for,attr,omitempty"`
+	Duration attr.Duration   `xml:"duration,attr"`
+	Tail     attr.Enum[tail] `xml:"tail,attr"`
+	Order    attr.Int        `xml:"order,attr,omitempty"`
+}
+
+func TestAttributes(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	s := `<div src="images/001.png" width="50%" color="#f808" lang="pt-BR" id="panel-1" ` +
+		`for="panel-2" duration="1.5s" tail="s" order="-3"></div>`
+
+	var e element
+	if err := xml.Unmarshal([]byte(s), &e); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(attr.URL("images/001.png"), e.Src)
+	assert.Equal(false, e.Src.IsAbs())
+	assert.Equal(attr.Percent(50), e.Width)
+	assert.Equal(true, e.Width.IsPercentage())
+	assert.Equal(attr.Color{R: 0xff, G: 0x88, B: 0x00, A: 0x88}, e.Color)
+	assert.Equal("pt", e.Lang.Primary())
+	assert.Equal(attr.ID("panel-1"), e.ID)
+	assert.Equal(attr.IDRef("panel-2"), e.For)
+	assert.Equal(attr.Duration(1500*time.Millisecond), e.Duration)
+	assert.Equal(tail("s"), e.Tail.Value)
+	assert.Equal(attr.Int(-3), e.Order)
+
+	b, err := xml.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(`<div src="images/001.png" width="50%" color="#ff880088" lang="pt-BR" id="panel-1" `+
+		`for="panel-2" duration="1.5s" tail="s" order="-3"></div>`, string(b))
+
+	r, g, bl, a := e.Color.RGBA()
+	er, eg, eb, ea := color.NRGBA{R: 0xff, G: 0x88, B: 0x00, A: 0x88}.RGBA()
+	assert.Equal([]uint32{er, eg, eb, ea}, []uint32{r, g, bl, a})
+
+	assert.Equal(true, attr.URL("https://example.com/chapter-2").IsAbs())
+	assert.Equal(true, attr.URL("mailto:team@example.com").Allowed())
+	assert.Equal(true, attr.URL("../images/001.png#xywh=0,0,10,10").Allowed())
+	assert.Equal(false, attr.URL("JavaScript:alert(1)").Allowed())
+	assert.Equal("#000000", attr.Color{A: 0xff}.String())
+	assert.Equal("-12.5px", attr.Length{Value: -12.5, Unit: attr.UnitPixel}.String())
+
+	b, err = xml.Marshal(element{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(`<div width="0" color="#00000000" duration="0s"></div>`, string(b))
+
+	var l attr.Length
+	if err := l.UnmarshalXMLAttr(xml.Attr{Value: "0"}); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(attr.Length{}, l)
+
+	if err := l.UnmarshalXMLAttr(xml.Attr{Value: "-.5em"}); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(attr.Length{Value: -0.5, Unit: attr.UnitEm}, l)
+
+	assert.Equal(true, attr.ID("panel-1").Valid())
+	assert.Equal(false, attr.ID("panel/1").Valid())
+}
+
+func TestAttributesInvalid(t *testing.T) {
+	tests := map[string]struct {
+		attr  attr.Attribute
+		value string
+	}{
+		"empty url":             {new(attr.URL), ""},
+		"malformed url":         {new(attr.URL), "http://[::1"},
+		"javascript url":        {new(attr.URL), "javascript:alert(1)"},
+		"data url":              {new(attr.URL), "data:text/html,<script>alert(1)</script>"},
+		"absolute path url":     {new(attr.URL), "/etc/passwd"},
+		"url without scheme":    {new(attr.URL), "//example.com/a.png"},
+		"length without number": {new(attr.Length), "px"},
+		"length without unit":   {new(attr.Length), "12"},
+		"length unknown unit":   {new(attr.Length), "12pt"},
+		"length trailing point": {new(attr.Length), "5.px"},
+		"length exponent":       {new(attr.Length), "1e5px"},
+		"length only point":     {new(attr.Length), ".px"},
+		"length only sign":      {new(attr.Length), "-px"},
+		"color without hash":    {new(attr.Color), "ff8800"},
+		"color wrong length":    {new(attr.Color), "#ff880"},
+		"color not hex":         {new(attr.Color), "#gg8800"},
+		"lang with space":       {new(attr.Lang), "pt BR"},
+		"lang long subtag":      {new(attr.Lang), "en-abcdefghi"},
+		"lang single letter":    {new(attr.Lang), "e"},
+		"empty id":              {new(attr.ID), ""},
+		"id with space":         {new(attr.ID), "panel 1"},
+		"id with slash":         {new(attr.ID), "panel/1"},
+		"idref with hash":       {new(attr.IDRef), "#panel-1"},
+		"negative duration":     {new(attr.Duration), "-1s"},
+		"duration without unit": {new(attr.Duration), "15"},
+		"enum":                  {new(attr.Enum[tail]), "sw"},
+		"int with fraction":     {new(attr.Int), "1.5"},
+		"empty int":             {new(attr.Int), ""},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.attr.UnmarshalXMLAttr(xml.Attr{Name: xml.Name{Local: "test"}, Value: test.value})
+
+			var aerr attr.ErrInvalidValue
+			if !errors.As(err, &aerr) {
+				t.Fatalf("expected error to be attr.ErrInvalidValue, got %T (%v)", err, err)
+			}
+			if aerr.Message == "" {
+				t.Error("expected error to have a message")
+			}
+		})
+	}
+
+	_, err := attr.Enum[tail]{Value: "sw"}.MarshalXMLAttr(xml.Name{Local: "tail"})
+	var aerr attr.ErrInvalidValue
+	if !errors.As(err, &aerr) {
+		t.Fatalf("expected error to be attr.ErrInvalidValue, got %T", err)
+	}
+	if aerr.Message != `must be one of "n", "s", "e", "w"` {
+		t.Errorf("unexpected message %q", aerr.Message)
+	}
+}
@@ -0,0 +1,75 @@
+package attr
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Color is an attribute holding a color in hexadecimal notation, "#rgb", "#rgba",
+// "#rrggbb" or "#rrggbbaa", like in CSS. It implements [image/color.Color].
+type Color struct {
+	R, G, B, A uint8
+}
+
+var _ Attribute = (*Color)(nil)
+
+func (a Color) MarshalXMLAttr(n xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: n, Value: a.String()}, nil
+}
+
+func (a *Color) UnmarshalXMLAttr(attr xml.Attr) error {
+	invalid := ErrInvalidValue{
+		Attr:    attr,
+		Message: `must be a hexadecimal color, such as "#f80", "#ff8800" or "#ff880080"`,
+	}
+
+	s, ok := strings.CutPrefix(attr.Value, "#")
+	if !ok {
+		return invalid
+	}
+
+	switch len(s) {
+	case 3, 4:
+		// Each digit of the short notation is repeated, so "#f80" is "#ff8800".
+		var b strings.Builder
+		for _, r := range s {
+			b.WriteRune(r)
+			b.WriteRune(r)
+		}
+		s = b.String()
+	case 6, 8:
+	default:
+		return invalid
+	}
+	if len(s) == 6 {
+		s += "ff"
+	}
+
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return invalid
+	}
+
+	*a = Color{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}
+	return nil
+}
+
+// String returns the color as "#rrggbb", or "#rrggbbaa" if it is not opaque.
+func (a Color) String() string {
+	if a.A == 0xff {
+		return fmt.Sprintf("#%02x%02x%02x", a.R, a.G, a.B)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", a.R, a.G, a.B, a.A)
+}
+
+// RGBA returns the alpha-premultiplied values of the color, as done by
+// [image/color.NRGBA].
+func (a Color) RGBA() (r, g, b, alpha uint32) {
+	alpha = uint32(a.A) * 0x101
+	r = uint32(a.R) * 0x101 * alpha / 0xffff
+	g = uint32(a.G) * 0x101 * alpha / 0xffff
+	b = uint32(a.B) * 0x101 * alpha / 0xffff
+	return r, g, b, alpha
+}
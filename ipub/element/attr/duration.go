@@ -0,0 +1,29 @@
+package attr
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// Duration is an attribute holding a positive duration, in the format of
+// [time.ParseDuration], such as "1.5s" or "500ms".
+type Duration time.Duration
+
+var _ Attribute = (*Duration)(nil)
+
+func (a Duration) MarshalXMLAttr(n xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: n, Value: a.String()}, nil
+}
+
+func (a *Duration) UnmarshalXMLAttr(attr xml.Attr) error {
+	d, err := time.ParseDuration(attr.Value)
+	if err != nil || d < 0 {
+		return ErrInvalidValue{Attr: attr, Message: `must be a positive duration, such as "1.5s" or "500ms"`}
+	}
+	*a = Duration(d)
+	return nil
+}
+
+func (a Duration) String() string {
+	return time.Duration(a).String()
+}
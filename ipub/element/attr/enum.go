@@ -0,0 +1,57 @@
+package attr
+
+import (
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Enumerable is implemented by string types which have a fixed set of valid
+// values. Values is called on the zero value of the type.
+type Enumerable[T any] interface {
+	~string
+	Values() []T
+}
+
+// Enum is an attribute holding one of the values of T.
+//
+//	type Tail string
+//
+//	func (Tail) Values() []Tail { return []Tail{"n", "s", "e", "w"} }
+//
+//	type Balloon struct {
+//		Tail attr.Enum[Tail] `xml:"data-ipub-tail,attr"`
+//	}
+type Enum[T Enumerable[T]] struct {
+	Value T
+}
+
+// MarshalXMLAttr returns an error if the value is not one of the values of T. The
+// attribute is omitted if the value is empty.
+func (a Enum[T]) MarshalXMLAttr(n xml.Name) (xml.Attr, error) {
+	if a.Value == "" {
+		return xml.Attr{}, nil
+	}
+	if !slices.Contains(a.Value.Values(), a.Value) {
+		return xml.Attr{}, ErrInvalidValue{Attr: xml.Attr{Name: n, Value: a.String()}, Message: a.message()}
+	}
+	return xml.Attr{Name: n, Value: a.String()}, nil
+}
+
+func (a *Enum[T]) UnmarshalXMLAttr(attr xml.Attr) error {
+	v := T(attr.Value)
+	if !slices.Contains(v.Values(), v) {
+		return ErrInvalidValue{Attr: attr, Message: a.message()}
+	}
+	a.Value = v
+	return nil
+}
+
+func (a Enum[T]) String() string {
+	return string(a.Value)
+}
+
+func (a Enum[T]) message() string {
+	return fmt.Sprintf("must be one of %s", strings.Join(slicesString(a.Value.Values()), ", "))
+}
@@ -0,0 +1,74 @@
+package attr
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// ID is an attribute holding the identifier of an element, which must be unique
+// in its document. IDs can't be empty or contain whitespace, as in HTML, and
+// can't contain the characters used by node refs, "#/;[]:,".
+type ID string
+
+var _ Attribute = (*ID)(nil)
+
+func (a ID) MarshalXMLAttr(n xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: n, Value: a.String()}, nil
+}
+
+func (a *ID) UnmarshalXMLAttr(attr xml.Attr) error {
+	if err := validateID(attr); err != nil {
+		return err
+	}
+	*a = ID(attr.Value)
+	return nil
+}
+
+func (a ID) String() string {
+	return string(a)
+}
+
+// Valid reports whether the ID can be used, such as in the steps of node refs.
+func (a ID) Valid() bool {
+	return validateID(xml.Attr{Value: string(a)}) == nil
+}
+
+// IDRef is an attribute holding a reference to the [ID] of another element in the
+// same document.
+type IDRef string
+
+var _ Attribute = (*IDRef)(nil)
+
+func (a IDRef) MarshalXMLAttr(n xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: n, Value: a.String()}, nil
+}
+
+func (a *IDRef) UnmarshalXMLAttr(attr xml.Attr) error {
+	if strings.HasPrefix(attr.Value, "#") {
+		return ErrInvalidValue{
+			Attr:    attr,
+			Message: `must be the ID of an element without a "#", such as "panel-1"`,
+		}
+	}
+	if err := validateID(attr); err != nil {
+		return err
+	}
+	*a = IDRef(attr.Value)
+	return nil
+}
+
+func (a IDRef) String() string {
+	return string(a)
+}
+
+func validateID(attr xml.Attr) error {
+	switch {
+	case attr.Value == "":
+		return ErrInvalidValue{Attr: attr, Message: "must not be empty"}
+	case strings.ContainsAny(attr.Value, " \t\n\f\r"):
+		return ErrInvalidValue{Attr: attr, Message: "must not contain whitespace"}
+	case strings.ContainsAny(attr.Value, "#/;[]:,"):
+		return ErrInvalidValue{Attr: attr, Message: `must not contain any of the characters "#/;[]:,"`}
+	}
+	return nil
+}
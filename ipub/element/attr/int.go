@@ -0,0 +1,28 @@
+package attr
+
+import (
+	"encoding/xml"
+	"strconv"
+)
+
+// Int is an attribute holding an integer, such as the reading order of panels.
+type Int int
+
+var _ Attribute = (*Int)(nil)
+
+func (a Int) MarshalXMLAttr(n xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: n, Value: a.String()}, nil
+}
+
+func (a *Int) UnmarshalXMLAttr(attr xml.Attr) error {
+	i, err := strconv.Atoi(attr.Value)
+	if err != nil {
+		return ErrInvalidValue{Attr: attr, Message: "must be an integer"}
+	}
+	*a = Int(i)
+	return nil
+}
+
+func (a Int) String() string {
+	return strconv.Itoa(int(a))
+}
@@ -0,0 +1,61 @@
+package attr
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// Lang is an attribute holding a BCP 47 language tag, such as "en" or "pt-BR".
+// Only the syntax of the tag is checked, not if its subtags are registered.
+//
+// An empty tag is valid, and means that the language is unknown, as in xml:lang.
+type Lang string
+
+var _ Attribute = (*Lang)(nil)
+
+func (a Lang) MarshalXMLAttr(n xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: n, Value: a.String()}, nil
+}
+
+func (a *Lang) UnmarshalXMLAttr(attr xml.Attr) error {
+	if attr.Value != "" && !validLang(attr.Value) {
+		return ErrInvalidValue{
+			Attr:    attr,
+			Message: `must be a language tag, such as "en" or "pt-BR"`,
+		}
+	}
+	*a = Lang(attr.Value)
+	return nil
+}
+
+func (a Lang) String() string {
+	return string(a)
+}
+
+// Primary returns the primary language subtag of the tag, in lower case, such
+// as "pt" for "pt-BR".
+func (a Lang) Primary() string {
+	p, _, _ := strings.Cut(string(a), "-")
+	return strings.ToLower(p)
+}
+
+// validLang reports whether s is a well-formed language tag: a primary subtag of
+// 2 to 8 letters, or "x" or "i" for private and irregular tags, followed by
+// subtags of 1 to 8 letters and digits.
+func validLang(s string) bool {
+	for i, sub := range strings.Split(s, "-") {
+		if len(sub) < 1 || len(sub) > 8 {
+			return false
+		}
+		for _, r := range sub {
+			letter := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+			if !letter && (i == 0 || r < '0' || r > '9') {
+				return false
+			}
+		}
+		if i == 0 && len(sub) == 1 && sub != "x" && sub != "i" {
+			return false
+		}
+	}
+	return true
+}
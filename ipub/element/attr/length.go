@@ -0,0 +1,119 @@
+package attr
+
+import (
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// LengthUnit is the unit of a [Length].
+type LengthUnit string
+
+const (
+	UnitNone    LengthUnit = ""
+	UnitPixel   LengthUnit = "px"
+	UnitEm      LengthUnit = "em"
+	UnitRem     LengthUnit = "rem"
+	UnitPercent LengthUnit = "%"
+	UnitVW      LengthUnit = "vw"
+	UnitVH      LengthUnit = "vh"
+)
+
+var lengthUnits = []LengthUnit{UnitPixel, UnitEm, UnitRem, UnitPercent, UnitVW, UnitVH}
+
+// Length is an attribute holding a CSS-like length or percentage, such as "12px"
+// or "50%". Only zero can be written without a unit.
+type Length struct {
+	Value float64
+	Unit  LengthUnit
+}
+
+var _ Attribute = (*Length)(nil)
+
+// Percent returns a length of p percent.
+func Percent(p float64) Length {
+	return Length{Value: p, Unit: UnitPercent}
+}
+
+func (a Length) MarshalXMLAttr(n xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: n, Value: a.String()}, nil
+}
+
+func (a *Length) UnmarshalXMLAttr(attr xml.Attr) error {
+	invalid := ErrInvalidValue{
+		Attr: attr,
+		Message: fmt.Sprintf(`must be a number followed by a unit (%s), such as "12px" or "50%%"`,
+			strings.Join(slicesString(lengthUnits), ", ")),
+	}
+
+	s := strings.TrimSpace(attr.Value)
+	i := numberLen(s)
+	if i == 0 {
+		return invalid
+	}
+
+	v, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return invalid
+	}
+
+	u := LengthUnit(s[i:])
+	if u == UnitNone && v != 0 {
+		return ErrInvalidValue{Attr: attr, Message: fmt.Sprintf(`must have a unit, such as "%spx"`, s)}
+	}
+	if u != UnitNone && !slices.Contains(lengthUnits, u) {
+		return invalid
+	}
+
+	*a = Length{Value: v, Unit: u}
+	return nil
+}
+
+func (a Length) String() string {
+	return strconv.FormatFloat(a.Value, 'f', -1, 64) + string(a.Unit)
+}
+
+// IsPercentage reports whether the length is relative to the size of its parent.
+func (a Length) IsPercentage() bool {
+	return a.Unit == UnitPercent
+}
+
+// numberLen returns the length of the number at the start of s, with an optional
+// sign and fraction, such as "-12" or "0.5", or 0 if s doesn't start with one. A
+// point must be followed by digits, and exponents aren't allowed, as in CSS.
+func numberLen(s string) int {
+	digits := func(i int) int {
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		return i
+	}
+
+	i := 0
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		i++
+	}
+	start := i
+	i = digits(i)
+	whole := i > start
+	if i < len(s) && s[i] == '.' {
+		j := digits(i + 1)
+		if j == i+1 {
+			return 0
+		}
+		i = j
+	} else if !whole {
+		return 0
+	}
+	return i
+}
+
+func slicesString[T ~string](s []T) []string {
+	ss := make([]string, len(s))
+	for i, v := range s {
+		ss[i] = fmt.Sprintf("%q", v)
+	}
+	return ss
+}
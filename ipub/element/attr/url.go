@@ -0,0 +1,75 @@
+package attr
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// URL is an attribute holding a URL, either absolute or relative to the document,
+// such as the src of images. Only http, https and mailto URLs and paths relative
+// to the document are allowed, see [URL.Allowed], so scripts can't be run from
+// links and sources.
+type URL string
+
+var urlSchemes = []string{"http", "https", "mailto"}
+
+var _ Attribute = (*URL)(nil)
+
+func (a URL) MarshalXMLAttr(n xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: n, Value: a.String()}, nil
+}
+
+func (a *URL) UnmarshalXMLAttr(attr xml.Attr) error {
+	if strings.TrimSpace(attr.Value) == "" {
+		return ErrInvalidValue{Attr: attr, Message: "must not be empty"}
+	}
+	if _, err := url.Parse(attr.Value); err != nil {
+		return ErrInvalidValue{Attr: attr, Message: "must be a valid URL: " + err.Error()}
+	}
+	if !URL(attr.Value).Allowed() {
+		return ErrInvalidValue{
+			Attr:    attr,
+			Message: fmt.Sprintf("must be a relative path or a URL with one of the schemes %s", strings.Join(urlSchemes, ", ")),
+		}
+	}
+	*a = URL(attr.Value)
+	return nil
+}
+
+func (a URL) String() string {
+	return string(a)
+}
+
+// URL returns the parsed URL, or nil if it is not valid.
+func (a URL) URL() *url.URL {
+	u, err := url.Parse(string(a))
+	if err != nil {
+		return nil
+	}
+	return u
+}
+
+// IsAbs reports whether the URL is absolute, such as links to external pages,
+// instead of relative to the document, such as resources of the package.
+func (a URL) IsAbs() bool {
+	u := a.URL()
+	return u != nil && u.IsAbs()
+}
+
+// Allowed reports whether the URL can be used in documents: an absolute URL with
+// one of the schemes http, https or mailto, or a path relative to the document,
+// such as "images/001.png". Absolute paths and URLs without a scheme, such as
+// "//example.com", are not relative to the document, so they are not allowed.
+func (a URL) Allowed() bool {
+	u := a.URL()
+	if u == nil {
+		return false
+	}
+	if u.Scheme != "" {
+		return slices.Contains(urlSchemes, u.Scheme)
+	}
+	return u.Host == "" && !strings.HasPrefix(u.Path, "/")
+}
@@ -7,7 +7,6 @@ import (
 	"io"
 	"reflect"
 	"slices"
-	"strings"
 
 	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
 )
@@ -16,6 +15,10 @@ type Element interface {
 	Kind() ElementKind
 }
 
+// ElementChildren are the child elements of an element, decoded by their kind.
+// All of them are decoded into memory at once, with reflection, so it is not fit
+// for large sections, such as vertical chapters with thousands of strips. Use the
+// Decoder of ipub/ast to read them one node at a time instead.
 type ElementChildren []Element
 
 func (ec *ElementChildren) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
@@ -33,7 +36,10 @@ func (ec *ElementChildren) UnmarshalXML(d *xml.Decoder, start xml.StartElement)
 		return err
 	}
 
-	ks := elementKindList[k]
+	ks, ok := elementKindList[k]
+	if !ok {
+		ks = &Unknown{}
+	}
 
 	// Get a pointer of a new instance of the underlying implementation so we can
 	// change it without manipulating the value inside the elementKindList.
@@ -83,25 +89,25 @@ func (k ElementKind) MarshalXMLAttr(n xml.Name) (xml.Attr, error) {
 	return xml.Attr{Name: elementKindAttrName, Value: k.String()}, nil
 }
 
+// UnmarshalXMLAttr sets k to the kind in a, which may not be registered, such as
+// a kind added by a newer version of the format. Use [ElementKind.Registered] or
+// [CheckKinds] to reject them.
 func (k *ElementKind) UnmarshalXMLAttr(a xml.Attr) error {
-	ak := ElementKind(a.Value)
-
-	if _, ok := elementKindList[ak]; !ok {
-		v := make([]string, 0, len(elementKindList))
-		for k := range elementKindList {
-			v = append(v, k.String())
-		}
-		return attr.ErrInvalidValue{
-			Attr:    a,
-			Message: fmt.Sprintf("must be a registered element (%q)", strings.Join(v, `", "`)),
-		}
+	if a.Value == "" {
+		return attr.ErrInvalidValue{Attr: a, Message: "must not be empty"}
 	}
 
-	*k = ak
+	*k = ElementKind(a.Value)
 
 	return nil
 }
 
+// Registered reports if k was registered with [NewElementKind].
+func (k ElementKind) Registered() bool {
+	_, ok := elementKindList[k]
+	return ok
+}
+
 func (k ElementKind) String() string {
 	return string(k)
 }
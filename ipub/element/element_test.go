@@ -2,6 +2,7 @@ package element_test
 
 import (
 	"encoding/xml"
+	"errors"
 	"testing"
 
 	"forge.capytal.company/capytalcode/project-comicverse/ipub/element"
@@ -13,12 +14,12 @@ func Test(t *testing.T) {
 			Test: "helloworld",
 			Children: []element.Element{
 				&element.Paragraph{
-					DataElement: element.ParagraphKind,
+					DataElement: element.KindParagraph,
 					Text:        "hello world",
 					Test:        "testvalue",
 				},
 				&element.Paragraph{
-					DataElement: element.ParagraphKind,
+					DataElement: element.KindParagraph,
 
 					Text: "hello world 2",
 				},
@@ -45,3 +46,76 @@ func Test(t *testing.T) {
 	t.Logf("%#v", ud.Body.Children[0])
 	t.Logf("%#v", ud.Body.Children[1])
 }
+
+func TestImage(t *testing.T) {
+	s := []byte(`<body><img data-ipub-element="image" src="images/001.png" alt="A cat" ` +
+		`data-ipub-description="A cat sleeps on a pile of comics." data-ipub-decorative="true" xml:lang="en"/></body>`)
+
+	var b element.Body
+	if err := xml.Unmarshal(s, &b); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(b.Children) != 1 {
+		t.Fatalf("expected 1 child, got %d", len(b.Children))
+	}
+
+	i, ok := b.Children[0].(*element.Image)
+	if !ok {
+		t.Fatalf("expected child to be *element.Image, got %T", b.Children[0])
+	}
+
+	expected := element.Image{
+		XMLName:     xml.Name{Local: "img"},
+		DataElement: element.KindImage,
+		Src:         "images/001.png",
+		Alt:         "A cat",
+		Description: "A cat sleeps on a pile of comics.",
+		Decorative:  true,
+		Lang:        "en",
+	}
+	if *i != expected {
+		t.Errorf("expected %#v, got %#v", expected, *i)
+	}
+}
+
+func TestUnknown(t *testing.T) {
+	s := `<body><img data-ipub-element="image" src="images/001.png" alt=""/>` +
+		`<div data-ipub-element="hologram" data-ipub-depth="3"><p>Inner <b>content</b></p></div></body>`
+
+	var b element.Body
+	if err := xml.Unmarshal([]byte(s), &b); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(b.Children) != 2 {
+		t.Fatalf("expected 2 children, got %d", len(b.Children))
+	}
+
+	u, ok := b.Children[1].(*element.Unknown)
+	if !ok {
+		t.Fatalf("expected child to be *element.Unknown, got %T", b.Children[1])
+	}
+	if u.Kind() != "hologram" || u.Kind().Registered() {
+		t.Errorf("expected unregistered kind %q, got %q", "hologram", u.Kind())
+	}
+	if string(u.InnerXML) != `<p>Inner <b>content</b></p>` {
+		t.Errorf("expected inner XML to be kept, got %q", u.InnerXML)
+	}
+
+	by, err := xml.Marshal(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<div data-ipub-element="hologram" data-ipub-depth="3"><p>Inner <b>content</b></p></div>`
+	if string(by) != expected {
+		t.Errorf("expected %q, got %q", expected, by)
+	}
+
+	if err := element.CheckKinds(b.Children); !errors.Is(err, element.ErrUnknownKind) {
+		t.Errorf("expected error %q, got %v", element.ErrUnknownKind, err)
+	}
+	if err := element.CheckKinds(b.Children[:1]); err != nil {
+		t.Errorf("expected no error for registered kinds, got %v", err)
+	}
+}
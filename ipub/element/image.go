@@ -0,0 +1,35 @@
+package element
+
+import (
+	"encoding/xml"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
+)
+
+// Image is a picture in a section, such as a page of the comic, with the data
+// needed to describe it to readers which can't see it.
+type Image struct {
+	XMLName     xml.Name    `xml:"img"`
+	DataElement ElementKind `xml:"data-ipub-element,attr"`
+	Src         attr.URL    `xml:"src,attr"`
+
+	// Alt is a short text alternative of the image.
+	Alt string `xml:"alt,attr"`
+	// Description is a long description of the image, for content which doesn't
+	// fit in the alt text, such as the action of a whole page.
+	Description string `xml:"data-ipub-description,attr,omitempty"`
+	// DescriptionRef is a reference to a long description stored elsewhere, such
+	// as a resource of the package or a fragment of the section.
+	DescriptionRef string `xml:"data-ipub-description-ref,attr,omitempty"`
+	// Decorative marks images that have no meaning and should be ignored by
+	// assistive technologies.
+	Decorative bool `xml:"data-ipub-decorative,attr,omitempty"`
+	// Lang is the language of the alt text and descriptions.
+	Lang attr.Lang `xml:"http://www.w3.org/XML/1998/namespace lang,attr,omitempty"`
+}
+
+var KindImage = NewElementKind("image", Image{})
+
+func (Image) Kind() ElementKind {
+	return KindImage
+}
@@ -0,0 +1,40 @@
+package element
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+)
+
+var ErrUnknownKind = errors.New("element: unknown element kind")
+
+// Unknown is an element of a kind which is not registered, such as one added by
+// a newer version of the format. It keeps the name, attributes and inner XML of
+// the element, so documents can be opened and written back by older versions
+// without losing data.
+//
+// The inner XML is not decoded. Use [CheckKinds] to reject unknown elements.
+type Unknown struct {
+	XMLName     xml.Name
+	DataElement ElementKind `xml:"data-ipub-element,attr"`
+	Attrs       []xml.Attr  `xml:",any,attr"`
+	InnerXML    []byte      `xml:",innerxml"`
+}
+
+// Kind returns the kind of the element, which is not registered.
+func (e Unknown) Kind() ElementKind {
+	return e.DataElement
+}
+
+// CheckKinds returns an error joining [ErrUnknownKind] for each element of ec
+// whose kind is not registered, for validators which don't accept elements
+// from newer versions of the format.
+func CheckKinds(ec ElementChildren) error {
+	errs := []error{}
+	for _, e := range ec {
+		if !e.Kind().Registered() {
+			errs = append(errs, errors.Join(ErrUnknownKind, fmt.Errorf("element kind %q", e.Kind())))
+		}
+	}
+	return errors.Join(errs...)
+}
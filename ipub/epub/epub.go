@@ -0,0 +1,448 @@
+// Package epub exports ipub packages as fixed-layout EPUB 3 publications, so
+// comics can be read on e-book applications.
+//
+// Each [ast.Content] directly inside a section's body is exported as a single
+// pre-paginated page, with its viewport sized to the page's first image. The
+// page progression and spreads of the publication follow the [ast.Layout] of the
+// package and its sections, and the stylesheets of each section are sanitized,
+// see [style.Sanitize], and embedded into its pages.
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	// Image decoders used to find the size of pages.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/render"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/style"
+)
+
+const MimeType = "application/epub+zip"
+
+var (
+	ErrMissingIdentifier = errors.New("epub: publication identifier must not be empty")
+	ErrMissingTitle      = errors.New("epub: publication title must not be empty")
+	ErrMissingResource   = errors.New("epub: image source not found in package resources")
+	ErrUnknownPageSize   = errors.New("epub: unable to find the size of page")
+	ErrDuplicatedHref    = errors.New("epub: file path is used more than once in publication")
+	ErrInvalidHref       = errors.New("epub: file path is not a valid path inside of the publication")
+)
+
+// Write exports pkg as a fixed-layout EPUB 3 publication into w. The metadata of
+// the publication is taken from the package's [ast.Metadata], which must have an
+// identifier and a title. If it has no language, "en" is used, and if it has no
+// modification date, the zero Unix time is used, so the output stays deterministic.
+func Write(w io.Writer, pkg *ast.Package) error {
+	m := pkg.Metadata
+	if m.Identifier == "" {
+		return ErrMissingIdentifier
+	}
+	if m.Title == "" {
+		return ErrMissingTitle
+	}
+	if m.Language == "" {
+		m.Language = "en"
+	}
+	if m.Modified.IsZero() {
+		m.Modified = time.Unix(0, 0)
+	}
+
+	// Rendition properties are always set by the exporter, since all pages are
+	// exported as fixed-layout ones.
+	m.Meta = slices.DeleteFunc(slices.Clone(m.Meta), func(p ast.MetaProperty) bool {
+		return strings.HasPrefix(p.Property, "rendition:")
+	})
+	m.Meta = append(m.Meta,
+		ast.MetaProperty{Property: "rendition:layout", Value: "pre-paginated"},
+		ast.MetaProperty{Property: "rendition:orientation", Value: "auto"},
+		ast.MetaProperty{Property: "rendition:spread", Value: renditionSpread(pkg.Layout)},
+	)
+	if pkg.Layout.Progression == ast.ProgressionVertical {
+		m.Meta = append(m.Meta, ast.MetaProperty{Property: "rendition:flow", Value: "scrolled-continuous"})
+	}
+
+	var metadata bytes.Buffer
+	enc := xml.NewEncoder(&metadata)
+	enc.Indent("  ", "  ")
+	if err := enc.EncodeElement(m, xml.StartElement{Name: xml.Name{Local: "metadata"}}); err != nil {
+		return fmt.Errorf("epub: failed to encode metadata: %w", err)
+	}
+
+	resources := make(map[string]ast.Resource, len(pkg.Resources))
+	for _, r := range pkg.Resources {
+		resources[r.Href] = r
+	}
+
+	pub := publication{
+		Title:    m.Title,
+		Language: m.Language,
+		Metadata: metadata.String(),
+	}
+	switch pkg.Layout.Progression {
+	case ast.ProgressionLeftToRight, ast.ProgressionRightToLeft:
+		pub.Progression = string(pkg.Layout.Progression)
+	}
+
+	hrefs := map[string]bool{containerFile: true, packageFile: true, navFile: true, styleFile: true}
+
+	// Href of the page holding each node, used to resolve the targets of the
+	// package's navigation entries. Sections and bodies point to their first page.
+	pages := map[ast.Node]string{}
+
+	for si, s := range pkg.Sections() {
+		title := s.Title
+		if title == "" {
+			title = fmt.Sprintf("Section %d", si+1)
+		}
+		navItem := navItem{Title: title}
+
+		if s.Body() == nil {
+			continue
+		}
+
+		properties := layoutProperties(pkg.Layout, ast.ResolveLayout(s))
+
+		// Each page is its own document, so the stylesheets of the section are
+		// scoped to the body of the page.
+		css, err := style.Section(s, pkg.Resources, "body")
+		if err != nil {
+			return fmt.Errorf("epub: failed to read stylesheets of section %d: %w", si+1, err)
+		}
+
+		for c := s.Body().FirstChild(); c != nil; c = c.NextSibling() {
+			content, ok := c.(*ast.Content)
+			if !ok {
+				continue
+			}
+
+			p, err := newPage(len(pub.Pages)+1, content, resources)
+			if err != nil {
+				return err
+			}
+			if !ipub.ValidHref(p.Href) {
+				return errors.Join(ErrInvalidHref, fmt.Errorf("page href %q", p.Href))
+			}
+			if hrefs[p.Href] {
+				return errors.Join(ErrDuplicatedHref, fmt.Errorf("page href %q", p.Href))
+			}
+			hrefs[p.Href] = true
+
+			p.Properties = properties
+			p.Style = css
+			if content.Spread() {
+				p.Properties = append(p.Properties, "rendition:page-spread-center")
+			}
+
+			if navItem.Href == "" {
+				navItem.Href = p.Href
+				pages[s] = p.Href
+				pages[s.Body()] = p.Href
+			}
+			pages[content] = p.Href
+			pub.Pages = append(pub.Pages, p)
+		}
+
+		if navItem.Href != "" {
+			pub.Nav = append(pub.Nav, navItem)
+		}
+	}
+
+	// The package's table of contents replaces the one generated from the
+	// section titles.
+	if toc := pkg.Nav(ast.NavTOC); toc != nil {
+		pub.Nav = newNavItems(pkg, toc.Entries(), pages)
+	}
+	if l := pkg.Nav(ast.NavLandmarks); l != nil {
+		pub.Landmarks = newNavItems(pkg, l.Entries(), pages)
+	}
+
+	for i, r := range pkg.Resources {
+		if !ipub.ValidHref(r.Href) {
+			return errors.Join(ErrInvalidHref, fmt.Errorf("resource href %q", r.Href))
+		}
+		if hrefs[r.Href] {
+			return errors.Join(ErrDuplicatedHref, fmt.Errorf("resource href %q", r.Href))
+		}
+		hrefs[r.Href] = true
+
+		pub.Resources = append(pub.Resources, resourceItem{
+			ID:        fmt.Sprintf("resource-%04d", i+1),
+			Href:      r.Href,
+			MediaType: r.MediaType,
+			Cover:     len(pub.Pages) > 0 && pub.Pages[0].Image == r.Href,
+		})
+	}
+
+	return pub.write(w, pkg.Resources)
+}
+
+const (
+	mimetypeFile  = "mimetype"
+	containerFile = "META-INF/container.xml"
+	rootDir       = "OEBPS"
+	packageFile   = "package.opf"
+	navFile       = "nav.xhtml"
+	styleFile     = "style.css"
+)
+
+type publication struct {
+	Title       string
+	Language    string
+	Metadata    string // Encoded metadata element of the package document
+	Progression string // Page progression direction of the spine, if not the default one
+	Pages       []page
+	Nav         []navItem
+	Landmarks   []navItem
+	Resources   []resourceItem
+}
+
+type page struct {
+	ID     string
+	Href   string
+	Number int
+	Width  int
+	Height int
+	Image  string // Href of the image used to size the page
+	Body   string
+	Style  string // Sanitized stylesheets of the page's section
+
+	// Properties of the page's spine item, which override the rendition
+	// properties of the publication.
+	Properties []string
+}
+
+// renditionSpread returns the value of the rendition:spread property for the
+// spread mode of l. Vertical pages are never shown as spreads.
+func renditionSpread(l ast.Layout) string {
+	switch {
+	case l.Progression == ast.ProgressionVertical || l.Spread == ast.SpreadSingle:
+		return "none"
+	case l.Spread == ast.SpreadDouble:
+		return "landscape"
+	default:
+		return "auto"
+	}
+}
+
+// layoutProperties returns the spine item properties of the pages of a section
+// with the given layout, which override the layout of the package.
+//
+// EPUB publications have a single page progression direction, so the one of the
+// package is used for all sections, except the vertical one, which is set as the
+// flow of the section.
+func layoutProperties(pkg, section ast.Layout) []string {
+	ps := []string{}
+
+	vertical := section.Progression == ast.ProgressionVertical
+	if vertical != (pkg.Progression == ast.ProgressionVertical) {
+		if vertical {
+			ps = append(ps, "rendition:flow-scrolled-continuous")
+		} else {
+			ps = append(ps, "rendition:flow-paginated")
+		}
+	}
+
+	if s := renditionSpread(section); s != renditionSpread(pkg) {
+		ps = append(ps, "rendition:spread-"+s)
+	}
+
+	return ps
+}
+
+type navItem struct {
+	Title    string
+	Href     string
+	Type     string // The epub:type of landmarks
+	Children []navItem
+}
+
+// newNavItems converts the navigation entries into items linking to the page which
+// holds their target. Entries which don't point to any exported page are left
+// out, with their children taking their place.
+func newNavItems(pkg *ast.Package, entries []*ast.NavEntry, pages map[ast.Node]string) []navItem {
+	items := []navItem{}
+	for _, e := range entries {
+		children := newNavItems(pkg, e.Entries(), pages)
+
+		var href string
+		for n := e.Target(pkg); n != nil && href == ""; n = n.Parent() {
+			href = pages[n]
+		}
+		if href == "" {
+			items = append(items, children...)
+			continue
+		}
+
+		items = append(items, navItem{
+			Title:    e.Title(),
+			Href:     href,
+			Type:     string(e.Landmark()),
+			Children: children,
+		})
+	}
+	return items
+}
+
+type resourceItem struct {
+	ID        string
+	Href      string
+	MediaType string
+	Cover     bool
+}
+
+func newPage(n int, content *ast.Content, resources map[string]ast.Resource) (page, error) {
+	p := page{
+		ID:     fmt.Sprintf("page-%04d", n),
+		Href:   fmt.Sprintf("page-%04d.xhtml", n),
+		Number: n,
+	}
+
+	img, ok := ast.FindFirst(content, ast.KindImage).(*ast.Image)
+	if !ok {
+		return page{}, errors.Join(ErrUnknownPageSize, fmt.Errorf("page %d has no image", n))
+	}
+
+	r, ok := resources[img.Source()]
+	if !ok || r.Open == nil {
+		return page{}, errors.Join(ErrMissingResource, fmt.Errorf("page %d image %q", n, img.Source()))
+	}
+
+	// The size of the page is read when the image is copied into the publication,
+	// so it is only opened once.
+	p.Image = r.Href
+
+	var b bytes.Buffer
+	if err := render.HTML(&b, content); err != nil {
+		return page{}, fmt.Errorf("epub: failed to render page %d: %w", n, err)
+	}
+	p.Body = b.String()
+
+	return p, nil
+}
+
+func (pub publication) write(w io.Writer, resources []ast.Resource) error {
+	z := zip.NewWriter(w)
+
+	m, err := z.CreateHeader(&zip.FileHeader{Name: mimetypeFile, Method: zip.Store})
+	if err != nil {
+		return fmt.Errorf("epub: failed to create mimetype file: %w", err)
+	}
+	if _, err := io.WriteString(m, MimeType); err != nil {
+		return fmt.Errorf("epub: failed to write mimetype file: %w", err)
+	}
+
+	if err := writeTemplate(z, containerFile, "container", pub); err != nil {
+		return err
+	}
+
+	// Resources are written before the pages, which are sized by their images.
+	images := make(map[string]bool, len(pub.Pages))
+	for _, p := range pub.Pages {
+		images[p.Image] = true
+	}
+	sizes := make(map[string]image.Config, len(images))
+	for _, r := range resources {
+		cfg, err := copyResource(z, r, images[r.Href])
+		if err != nil {
+			return fmt.Errorf("epub: failed to write resource %q: %w", r.Href, err)
+		}
+		sizes[r.Href] = cfg
+	}
+	for i, p := range pub.Pages {
+		cfg := sizes[p.Image]
+		if cfg.Width == 0 || cfg.Height == 0 {
+			return errors.Join(ErrUnknownPageSize, fmt.Errorf("page %d image %q", p.Number, p.Image))
+		}
+		pub.Pages[i].Width, pub.Pages[i].Height = cfg.Width, cfg.Height
+	}
+
+	if err := writeTemplate(z, path.Join(rootDir, packageFile), "package", pub); err != nil {
+		return err
+	}
+	if err := writeTemplate(z, path.Join(rootDir, navFile), "nav", pub); err != nil {
+		return err
+	}
+	if err := writeTemplate(z, path.Join(rootDir, styleFile), "style", pub); err != nil {
+		return err
+	}
+
+	for _, p := range pub.Pages {
+		data := struct {
+			publication
+			Page page
+		}{pub, p}
+		if err := writeTemplate(z, path.Join(rootDir, p.Href), "page", data); err != nil {
+			return err
+		}
+	}
+
+	if err := z.Close(); err != nil {
+		return fmt.Errorf("epub: failed to close zip container: %w", err)
+	}
+
+	return nil
+}
+
+func writeTemplate(z *zip.Writer, name, template string, data any) error {
+	f, err := z.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+	if err != nil {
+		return fmt.Errorf("epub: failed to create %q: %w", name, err)
+	}
+	if err := templates.ExecuteTemplate(f, template, data); err != nil {
+		return fmt.Errorf("epub: failed to write %q: %w", name, err)
+	}
+	return nil
+}
+
+// copyResource writes r into the publication. If size is true, the size of r is
+// decoded from the bytes being copied and returned, or the zero value if r is not
+// an image which can be decoded.
+func copyResource(z *zip.Writer, r ast.Resource, size bool) (image.Config, error) {
+	f, err := z.CreateHeader(&zip.FileHeader{Name: path.Join(rootDir, r.Href), Method: zip.Deflate})
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	rc, err := r.Open()
+	if err != nil {
+		return image.Config{}, err
+	}
+	defer rc.Close()
+
+	var cfg image.Config
+	if size {
+		// Every byte read by the decoder is also written to the file, so the
+		// rest of the image is copied after it.
+		cfg, _, _ = image.DecodeConfig(io.TeeReader(rc, f))
+	}
+
+	_, err = io.Copy(f, rc)
+	return cfg, err
+}
+
+var templates = template.Must(template.New("epub").Funcs(template.FuncMap{
+	"join": strings.Join,
+	"xml": func(s string) (string, error) {
+		var b bytes.Buffer
+		err := xml.EscapeText(&b, []byte(s))
+		return b.String(), err
+	},
+}).Parse(templatesText))
@@ -0,0 +1,442 @@
+package epub_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/epub"
+	"forge.capytal.company/loreddev/x/tinyssert"
+)
+
+func TestWrite(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	pkg := &ast.Package{
+		Metadata: ast.Metadata{
+			Identifier: "urn:uuid:0195f3a4-7b2c-7000-8000-000000000000",
+			Title:      "Test & Comic",
+			Creators:   []ast.Creator{{Name: "Jane Doe", Role: ast.RoleAuthor}},
+			Series:     "Tests",
+			Modified:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		Resources: []ast.Resource{
+			newImageResource(t, "images/001.png", 100, 150),
+			newImageResource(t, "images/002.png", 200, 300),
+		},
+	}
+	pkg.AppendChild(pkg, newSection("Chapter 1", "images/001.png", "images/002.png"))
+
+	var b bytes.Buffer
+	err := epub.Write(&b, pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	z, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal("mimetype", z.File[0].Name)
+	assert.Equal(zip.Store, z.File[0].Method)
+	assert.Equal(epub.MimeType, readFile(t, z, "mimetype"))
+
+	var opf struct {
+		UniqueIdentifier string `xml:"unique-identifier,attr"`
+		Metadata         struct {
+			Identifier struct {
+				ID    string `xml:"id,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"http://purl.org/dc/elements/1.1/ identifier"`
+			Title   string `xml:"title"`
+			Creator string `xml:"creator"`
+			Meta    []struct {
+				Property string `xml:"property,attr"`
+				Value    string `xml:",chardata"`
+			} `xml:"meta"`
+		} `xml:"metadata"`
+		Manifest []struct {
+			ID         string `xml:"id,attr"`
+			Href       string `xml:"href,attr"`
+			Properties string `xml:"properties,attr"`
+		} `xml:"manifest>item"`
+		Spine []struct {
+			IDRef string `xml:"idref,attr"`
+		} `xml:"spine>itemref"`
+	}
+	if err := xml.Unmarshal([]byte(readFile(t, z, "OEBPS/package.opf")), &opf); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal("Test & Comic", opf.Metadata.Title)
+	assert.Equal("Jane Doe", opf.Metadata.Creator)
+	assert.Equal(opf.UniqueIdentifier, opf.Metadata.Identifier.ID)
+	assert.Equal("urn:uuid:0195f3a4-7b2c-7000-8000-000000000000", opf.Metadata.Identifier.Value)
+
+	meta := map[string]string{}
+	for _, m := range opf.Metadata.Meta {
+		meta[m.Property] = m.Value
+	}
+	assert.Equal("pre-paginated", meta["rendition:layout"])
+	assert.Equal("2025-01-01T00:00:00Z", meta["dcterms:modified"])
+	assert.Equal("Tests", meta["belongs-to-collection"])
+
+	assert.Equal(2, len(opf.Spine))
+	assert.Equal("page-0001", opf.Spine[0].IDRef)
+	assert.Equal("page-0002", opf.Spine[1].IDRef)
+
+	items := map[string]string{}
+	for _, i := range opf.Manifest {
+		items[i.Href] = i.Properties
+	}
+	assert.Equal("nav", items["nav.xhtml"])
+	assert.Equal("cover-image", items["images/001.png"])
+	assert.Equal("", items["images/002.png"])
+
+	p1 := readFile(t, z, "OEBPS/page-0001.xhtml")
+	if !strings.Contains(p1, `content="width=100, height=150"`) {
+		t.Errorf("page 1 has incorrect viewport: %s", p1)
+	}
+	if !strings.Contains(p1, `src="images/001.png"`) {
+		t.Errorf("page 1 does not reference its image: %s", p1)
+	}
+	if err := xml.Unmarshal([]byte(p1), new(struct{})); err != nil {
+		t.Errorf("page 1 is not valid XHTML: %s", err)
+	}
+
+	p2 := readFile(t, z, "OEBPS/page-0002.xhtml")
+	if !strings.Contains(p2, `content="width=200, height=300"`) {
+		t.Errorf("page 2 has incorrect viewport: %s", p2)
+	}
+
+	nav := readFile(t, z, "OEBPS/nav.xhtml")
+	if !strings.Contains(nav, `<a href="page-0001.xhtml">Chapter 1</a>`) {
+		t.Errorf("nav does not link to the first page of the section: %s", nav)
+	}
+
+	assert.Equal(readResource(t, pkg.Resources[1]), readFile(t, z, "OEBPS/images/002.png"))
+}
+
+func TestWriteNav(t *testing.T) {
+	pkg := &ast.Package{
+		Metadata:  ast.Metadata{Identifier: "id", Title: "title"},
+		Resources: []ast.Resource{newImageResource(t, "images/001.png", 10, 10)},
+	}
+
+	s1 := newSection("Chapter 1", "images/001.png", "images/001.png")
+	s1.SetHref("sections/001.xhtml")
+	pkg.AppendChild(pkg, s1)
+
+	toc := &ast.Nav{}
+	toc.SetType(ast.NavTOC)
+	vol := newNavEntry("Volume 1", "sections/001.xhtml", "", "")
+	vol.AppendChild(vol, newNavEntry("Second page", "sections/001.xhtml", "body[0]/content[1]", ""))
+	vol.AppendChild(vol, newNavEntry("Missing", "sections/404.xhtml", "", ""))
+	toc.AppendChild(toc, vol)
+	pkg.AppendChild(pkg, toc)
+
+	landmarks := &ast.Nav{}
+	landmarks.SetType(ast.NavLandmarks)
+	landmarks.AppendChild(landmarks, newNavEntry("Cover", "sections/001.xhtml", "", ast.LandmarkCover))
+	pkg.AppendChild(pkg, landmarks)
+
+	var b bytes.Buffer
+	if err := epub.Write(&b, pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	z, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nav := readFile(t, z, "OEBPS/nav.xhtml")
+	for _, want := range []string{
+		`<li><a href="page-0001.xhtml">Volume 1</a><ol>`,
+		`<li><a href="page-0002.xhtml">Second page</a></li>`,
+		`<a epub:type="cover" href="page-0001.xhtml">Cover</a>`,
+	} {
+		if !strings.Contains(nav, want) {
+			t.Errorf("nav does not contain %q: %s", want, nav)
+		}
+	}
+	if strings.Contains(nav, "Chapter 1") || strings.Contains(nav, "Missing") {
+		t.Errorf("nav contains entries not in the package table of contents: %s", nav)
+	}
+	if err := xml.Unmarshal([]byte(nav), new(struct{})); err != nil {
+		t.Errorf("nav is not valid XHTML: %s", err)
+	}
+}
+
+func TestWriteMissingResource(t *testing.T) {
+	pkg := &ast.Package{Metadata: ast.Metadata{Identifier: "id", Title: "title"}}
+	pkg.AppendChild(pkg, newSection("Chapter 1", "images/001.png"))
+
+	err := epub.Write(io.Discard, pkg)
+	if !errors.Is(err, epub.ErrMissingResource) {
+		t.Errorf("expected error %q, got %v", epub.ErrMissingResource, err)
+	}
+}
+
+func TestWriteInvalidHref(t *testing.T) {
+	for _, href := range []string{"../001.png", "images/../../001.png", "/001.png", "images//001.png", `images\001.png`} {
+		t.Run(href, func(t *testing.T) {
+			pkg := &ast.Package{Metadata: ast.Metadata{Identifier: "id", Title: "title"}}
+			pkg.AppendChild(pkg, newSection("Chapter 1", "images/001.png"))
+			pkg.Resources = []ast.Resource{
+				newImageResource(t, "images/001.png", 100, 150),
+				newImageResource(t, href, 100, 150),
+			}
+
+			err := epub.Write(io.Discard, pkg)
+			if !errors.Is(err, epub.ErrInvalidHref) {
+				t.Errorf("expected error %q, got %v", epub.ErrInvalidHref, err)
+			}
+		})
+	}
+}
+
+func newNavEntry(title, section, path string, landmark ast.Landmark) *ast.NavEntry {
+	e := &ast.NavEntry{}
+	e.SetTitle(title)
+	e.SetSection(section)
+	e.SetPath(path)
+	e.SetLandmark(landmark)
+	return e
+}
+
+func newSection(title string, images ...string) *ast.Section {
+	b := &ast.Body{}
+	for _, src := range images {
+		c := &ast.Content{}
+		i := &ast.Image{}
+		i.SetSource(src)
+		c.AppendChild(c, i)
+		b.AppendChild(b, c)
+	}
+	s := &ast.Section{Title: title}
+	s.SetBody(b)
+	return s
+}
+
+func newImageResource(t *testing.T, href string, width, height int) ast.Resource {
+	var b bytes.Buffer
+	if err := png.Encode(&b, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return ast.Resource{
+		Href:      href,
+		MediaType: "image/png",
+		Open: func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(b.Bytes())), nil
+		},
+	}
+}
+
+func TestWriteWebP(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	opened := map[string]int{}
+	count := func(r ast.Resource) ast.Resource {
+		open := r.Open
+		r.Open = func() (io.ReadCloser, error) {
+			opened[r.Href]++
+			return open()
+		}
+		return r
+	}
+
+	pkg := &ast.Package{
+		Metadata: ast.Metadata{Identifier: "urn:uuid:0195f3a4-7b2c-7000-8000-000000000000", Title: "WebP"},
+		Resources: []ast.Resource{
+			count(newWebPResource("images/001.webp", 120, 180)),
+			count(newImageResource(t, "images/002.png", 100, 150)),
+		},
+	}
+	pkg.AppendChild(pkg, newSection("Chapter 1", "images/001.webp", "images/002.png"))
+
+	var b bytes.Buffer
+	if err := epub.Write(&b, pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	// Images are only opened once, to be copied into the publication.
+	assert.Equal(map[string]int{"images/001.webp": 1, "images/002.png": 1}, opened)
+
+	z, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(true, strings.Contains(readFile(t, z, "OEBPS/page-0001.xhtml"), `content="width=120, height=180"`))
+	assert.Equal(true, strings.Contains(readFile(t, z, "OEBPS/page-0002.xhtml"), `content="width=100, height=150"`))
+	assert.Equal(readResource(t, pkg.Resources[0]), readFile(t, z, "OEBPS/images/001.webp"))
+	assert.Equal(readResource(t, pkg.Resources[1]), readFile(t, z, "OEBPS/images/002.png"))
+}
+
+// newWebPResource returns a lossless WebP image with only its header, which is
+// enough for its size to be decoded.
+func newWebPResource(href string, width, height int) ast.Resource {
+	data := binary.LittleEndian.AppendUint32([]byte{0x2f}, uint32(width-1)|uint32(height-1)<<14)
+	data = append(data, 0) // Padding to an even size
+
+	chunk := binary.LittleEndian.AppendUint32([]byte("VP8L"), uint32(len(data)-1))
+	chunk = append(chunk, data...)
+
+	b := binary.LittleEndian.AppendUint32([]byte("RIFF"), uint32(len("WEBP")+len(chunk)))
+	b = append(b, "WEBP"...)
+	b = append(b, chunk...)
+
+	return ast.Resource{
+		Href:      href,
+		MediaType: "image/webp",
+		Open: func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(b)), nil
+		},
+	}
+}
+
+func readResource(t *testing.T, r ast.Resource) string {
+	rc, err := r.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func readFile(t *testing.T, z *zip.Reader, name string) string {
+	f, err := z.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	b, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestWriteLayout(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	pkg := &ast.Package{
+		Metadata: ast.Metadata{Identifier: "urn:uuid:0195f3a4-7b2c-7000-8000-000000000000", Title: "Manga"},
+		Resources: []ast.Resource{
+			newImageResource(t, "images/001.png", 100, 150),
+			newImageResource(t, "images/002.png", 200, 150),
+			newImageResource(t, "images/003.png", 100, 600),
+		},
+		Layout: ast.Layout{Progression: ast.ProgressionRightToLeft, Spread: ast.SpreadDouble},
+	}
+
+	s1 := newSection("Chapter 1", "images/001.png", "images/002.png")
+	s1.Body().LastChild().(*ast.Content).SetSpread(true)
+	pkg.AppendChild(pkg, s1)
+
+	s2 := newSection("Extra", "images/003.png")
+	s2.Layout.Progression = ast.ProgressionVertical
+	pkg.AppendChild(pkg, s2)
+
+	var b bytes.Buffer
+	if err := epub.Write(&b, pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	z, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var opf struct {
+		Meta []struct {
+			Property string `xml:"property,attr"`
+			Value    string `xml:",chardata"`
+		} `xml:"metadata>meta"`
+		Spine struct {
+			Progression string `xml:"page-progression-direction,attr"`
+			Items       []struct {
+				Properties string `xml:"properties,attr"`
+			} `xml:"itemref"`
+		} `xml:"spine"`
+	}
+	if err := xml.Unmarshal([]byte(readFile(t, z, "OEBPS/package.opf")), &opf); err != nil {
+		t.Fatal(err)
+	}
+
+	meta := map[string]string{}
+	for _, m := range opf.Meta {
+		meta[m.Property] = m.Value
+	}
+	assert.Equal("landscape", meta["rendition:spread"])
+
+	assert.Equal("rtl", opf.Spine.Progression)
+	assert.Equal(3, len(opf.Spine.Items))
+	assert.Equal("", opf.Spine.Items[0].Properties)
+	assert.Equal("rendition:page-spread-center", opf.Spine.Items[1].Properties)
+	assert.Equal("rendition:flow-scrolled-continuous rendition:spread-none", opf.Spine.Items[2].Properties)
+}
+
+func TestWriteStylesheets(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	pkg := &ast.Package{
+		Metadata: ast.Metadata{Identifier: "urn:uuid:0195f3a4-7b2c-7000-8000-000000000000", Title: "Styled"},
+		Resources: []ast.Resource{
+			newImageResource(t, "images/001.png", 100, 150),
+			{
+				Href:      "styles/house.css",
+				MediaType: "text/css",
+				Open: func() (io.ReadCloser, error) {
+					return io.NopCloser(strings.NewReader(`.caption{font-family:"A&B";position:absolute}`)), nil
+				},
+			},
+		},
+	}
+
+	s := newSection("Chapter 1", "images/001.png")
+	s.Stylesheets = []string{"styles/house.css"}
+	s.Body().FirstChild().(*ast.Content).SetClasses([]string{"caption"})
+	pkg.AppendChild(pkg, s)
+
+	var b bytes.Buffer
+	if err := epub.Write(&b, pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	z, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page := readFile(t, z, "OEBPS/page-0001.xhtml")
+	assert.Equal(true, strings.Contains(page,
+		`<style>body [data-class~=&#34;caption&#34;]{font-family:&#34;A&amp;B&#34;}&#xA;</style>`))
+	assert.Equal(true, strings.Contains(page, `<section class="ipub-content" data-class="caption">`))
+
+	var doc struct{}
+	if err := xml.Unmarshal([]byte(page), &doc); err != nil {
+		t.Fatalf("page is not valid XML: %v", err)
+	}
+
+	pkg.Resources = pkg.Resources[:1]
+	if err := epub.Write(io.Discard, pkg); err == nil {
+		t.Error("expected error for stylesheet missing from the package")
+	}
+}
@@ -0,0 +1,116 @@
+package epub
+
+const templatesText = `
+{{- define "container" -}}
+<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/package.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+{{end -}}
+
+{{- define "package" -}}
+<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="pub-id"
+  prefix="rendition: http://www.idpf.org/vocab/rendition/#">
+  {{.Metadata}}
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="style" href="style.css" media-type="text/css"/>
+    {{- range .Pages}}
+    <item id="{{.ID}}" href="{{xml .Href}}" media-type="application/xhtml+xml"/>
+    {{- end}}
+    {{- range .Resources}}
+    <item id="{{.ID}}" href="{{xml .Href}}" media-type="{{xml .MediaType}}"{{if .Cover}} properties="cover-image"{{end}}/>
+    {{- end}}
+  </manifest>
+  <spine{{with .Progression}} page-progression-direction="{{.}}"{{end}}>
+    {{- range .Pages}}
+    <itemref idref="{{.ID}}"{{with .Properties}} properties="{{join . " "}}"{{end}}/>
+    {{- end}}
+  </spine>
+</package>
+{{end -}}
+
+{{- define "nav" -}}
+<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" lang="{{xml .Language}}" xml:lang="{{xml .Language}}">
+<head>
+  <meta charset="UTF-8"/>
+  <title>{{xml .Title}}</title>
+</head>
+<body>
+  <nav epub:type="toc" id="toc">
+    <h1>{{xml .Title}}</h1>
+    {{- template "nav-items" .Nav}}
+  </nav>
+  {{- if .Landmarks}}
+  <nav epub:type="landmarks" hidden="hidden">
+    <ol>
+      {{- range .Landmarks}}
+      <li><a{{if .Type}} epub:type="{{xml .Type}}"{{end}} href="{{xml .Href}}">{{xml .Title}}</a></li>
+      {{- end}}
+    </ol>
+  </nav>
+  {{- end}}
+  <nav epub:type="page-list" hidden="hidden">
+    <ol>
+      {{- range .Pages}}
+      <li><a href="{{xml .Href}}">{{.Number}}</a></li>
+      {{- end}}
+    </ol>
+  </nav>
+</body>
+</html>
+{{end -}}
+
+{{- define "nav-items" -}}
+<ol>
+  {{- range .}}
+  <li><a href="{{xml .Href}}">{{xml .Title}}</a>{{if .Children}}{{template "nav-items" .Children}}{{end}}</li>
+  {{- end}}
+</ol>
+{{- end -}}
+
+{{- define "style" -}}
+html, body {
+  margin: 0;
+  padding: 0;
+  overflow: hidden;
+}
+.ipub-content {
+  position: relative;
+  width: 100%;
+  height: 100%;
+}
+.ipub-image {
+  position: absolute;
+  top: 0;
+  left: 0;
+  width: 100%;
+  height: 100%;
+}
+{{end -}}
+
+{{- define "page" -}}
+<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" lang="{{xml .Language}}" xml:lang="{{xml .Language}}">
+<head>
+  <meta charset="UTF-8"/>
+  <meta name="viewport" content="width={{.Page.Width}}, height={{.Page.Height}}"/>
+  <title>{{xml .Title}} - {{.Page.Number}}</title>
+  <link rel="stylesheet" type="text/css" href="style.css"/>
+  <style>html, body { width: {{.Page.Width}}px; height: {{.Page.Height}}px; }</style>
+  {{- with .Page.Style}}
+  <style>{{xml .}}</style>
+  {{- end}}
+</head>
+<body>
+{{.Page.Body}}
+</body>
+</html>
+{{end -}}
+`
@@ -0,0 +1,277 @@
+// Package extract pulls the plain text out of ipub trees, in reading order, for
+// uses such as full-text search, translation memories, screen readers and word
+// counts.
+//
+// Each piece of text is returned as an [Entry], with a ref back to the node it
+// was taken from, so results can be linked to their place in the package.
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+)
+
+var ErrNoRef = errors.New("extract: node has no ref")
+
+// Role is what a piece of text is used for in the comic.
+type Role string
+
+const (
+	RoleSpeech      Role = "speech"
+	RoleThought     Role = "thought"
+	RoleCaption     Role = "caption"
+	RoleSoundEffect Role = "sfx"
+	RoleChoice      Role = "choice"
+	RoleAlt         Role = "alt"
+	RoleDescription Role = "description"
+)
+
+// Entry is a piece of text of a section.
+type Entry struct {
+	Role Role
+	Text string
+	// Lang is the language tag of the text, taken from its alternate, image or
+	// section. It is empty if none of them has one.
+	Lang string
+	// Speaker is the speaker of dialogs, if any.
+	Speaker string
+
+	// Node is the node the text was taken from: a dialog, choice or image, or the
+	// alternate of a dialog or choice in the extracted language.
+	Node ast.Node
+	Ref  ast.Ref
+}
+
+// Tokens returns the words of the entry's text, see [Tokenize].
+func (e Entry) Tokens() []Token {
+	return Tokenize(e.Text)
+}
+
+// TokenRef returns the ref of t, a token of the entry's text. The ref has the
+// range of the token, unless the entry's node can't have ranges in its refs,
+// such as images.
+func (e Entry) TokenRef(t Token) ast.Ref {
+	r := e.Ref
+	r.Steps = slices.Clone(r.Steps)
+	if _, ok := ast.RefText(e.Node); ok {
+		rng := t.Range
+		r.Range = &rng
+	}
+	return r
+}
+
+// Package returns the entries of all sections of pkg, in the order of the spine.
+// See [Section] for how text is picked for the given languages.
+func Package(pkg *ast.Package, langs ...string) ([]Entry, error) {
+	es := []Entry{}
+	for _, s := range pkg.Sections() {
+		ses, err := Section(s, langs...)
+		if err != nil {
+			return nil, err
+		}
+		es = append(es, ses...)
+	}
+	return es, nil
+}
+
+// Section returns the entries of s in reading order. The section must have an
+// href, as set by the package reader, so entries can have refs.
+//
+// Pages are read from the description of their image to their panels in their
+// reading order, and dialogs are placed in the first panel which contains their
+// anchor. Dialogs outside of every panel, and choices, are read last, in document
+// order. Decorative images are skipped.
+//
+// Nodes with alternates use the first one found in the [ast.FallbackChain] of
+// langs, or their source alternate, as [ast.ResolveAlternate] does.
+func Section(s *ast.Section, langs ...string) ([]Entry, error) {
+	x := &extractor{section: s, chain: ast.FallbackChain(langs...), entries: []Entry{}}
+	if s.Body() == nil {
+		return x.entries, nil
+	}
+
+	err := ast.Walk(s.Body(), func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if c, ok := n.(*ast.Content); ok {
+			return ast.WalkSkipChildren, x.content(c)
+		}
+		if isTextNode(n) {
+			return ast.WalkSkipChildren, x.node(n)
+		}
+		return ast.WalkContinue, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return x.entries, nil
+}
+
+// Languages returns the language tags used in the tree of n, by its sections,
+// alternates and images, in the order they are first found. Tags are compared
+// case-insensitively.
+func Languages(n ast.Node) []string {
+	langs := []string{}
+	add := func(l string) {
+		if l != "" && !slices.ContainsFunc(langs, func(v string) bool { return strings.EqualFold(v, l) }) {
+			langs = append(langs, l)
+		}
+	}
+	_ = ast.Walk(n, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Section:
+			add(n.Language)
+		case *ast.Alternate:
+			add(n.Lang())
+		case *ast.Image:
+			add(n.Lang())
+		}
+		return ast.WalkContinue, nil
+	})
+	return langs
+}
+
+type extractor struct {
+	section *ast.Section
+	chain   []string
+	entries []Entry
+}
+
+func isTextNode(n ast.Node) bool {
+	switch n.(type) {
+	case ast.Dialog, *ast.Choice, *ast.Image:
+		return true
+	default:
+		return false
+	}
+}
+
+// content extracts the text nodes of c, sorted by the reading order of the panels
+// they are in.
+func (x *extractor) content(c *ast.Content) error {
+	panels := c.Panels()
+
+	type item struct {
+		node ast.Node
+		rank int
+	}
+	items := []item{}
+	_ = ast.Walk(c, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering || !isTextNode(n) {
+			return ast.WalkContinue, nil
+		}
+		items = append(items, item{node: n, rank: panelRank(n, panels)})
+		return ast.WalkSkipChildren, nil
+	})
+	slices.SortStableFunc(items, func(a, b item) int {
+		return a.rank - b.rank
+	})
+
+	for _, i := range items {
+		if err := x.node(i.node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// panelRank returns the position in panels of the panel which n is read in. Images
+// are read before all panels, and nodes outside of every panel after them.
+func panelRank(n ast.Node, panels []*ast.Panel) int {
+	if _, ok := n.(*ast.Image); ok {
+		return -1
+	}
+	if p, ok := ast.FindAncestor(n, ast.KindPanel).(*ast.Panel); ok {
+		if i := slices.Index(panels, p); i != -1 {
+			return i
+		}
+	}
+	if d, ok := n.(ast.Dialog); ok {
+		for i, p := range panels {
+			if panelShape(p).Contains(d.Anchor()) {
+				return i
+			}
+		}
+	}
+	return len(panels)
+}
+
+func panelShape(p *ast.Panel) ast.Shape {
+	if poly := p.Polygon(); poly != nil {
+		return poly
+	}
+	return p.Bounds()
+}
+
+func (x *extractor) node(n ast.Node) error {
+	switch n := n.(type) {
+	case *ast.Image:
+		if n.Decorative() {
+			return nil
+		}
+		lang := n.Lang()
+		if lang == "" {
+			lang = x.section.Language
+		}
+		if err := x.add(Entry{Role: RoleAlt, Text: n.Alt(), Lang: lang, Node: n}); err != nil {
+			return err
+		}
+		return x.add(Entry{Role: RoleDescription, Text: n.Description(), Lang: lang, Node: n})
+
+	case ast.Dialog:
+		e := x.text(n)
+		e.Speaker = n.Speaker()
+		switch n.Kind() {
+		case ast.KindSpeech:
+			e.Role = RoleSpeech
+		case ast.KindThought:
+			e.Role = RoleThought
+		case ast.KindCaption:
+			e.Role = RoleCaption
+		case ast.KindSoundEffect:
+			e.Role = RoleSoundEffect
+		default:
+			e.Role = Role(n.Kind())
+		}
+		return x.add(e)
+
+	case *ast.Choice:
+		e := x.text(n)
+		e.Role = RoleChoice
+		return x.add(e)
+	}
+	return nil
+}
+
+// text returns an entry with the text of n, in the alternate resolved for the
+// languages of the extractor if n has alternates.
+func (x *extractor) text(n ast.Node) Entry {
+	if a := ast.ResolveAlternate(n, x.chain); a != nil {
+		t, _ := ast.RefText(a)
+		return Entry{Text: t, Lang: a.Lang(), Node: a}
+	}
+	t, _ := ast.RefText(n)
+	return Entry{Text: t, Lang: x.section.Language, Node: n}
+}
+
+// add appends e to the entries with the ref of its node, unless its text is empty.
+func (x *extractor) add(e Entry) error {
+	if strings.TrimSpace(e.Text) == "" {
+		return nil
+	}
+	r, err := ast.RefOf(e.Node)
+	if err != nil {
+		return errors.Join(ErrNoRef, fmt.Errorf("unable to get ref of %q: %w", ast.Path(e.Node), err))
+	}
+	e.Ref = r
+	x.entries = append(x.entries, e)
+	return nil
+}
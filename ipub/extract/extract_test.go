@@ -0,0 +1,143 @@
+package extract_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"testing"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/extract"
+	"forge.capytal.company/loreddev/x/tinyssert"
+)
+
+const section = `<html xmlns="http://www.w3.org/1999/xhtml" xml:lang="en">
+	<head>
+		<title>Chapter 1</title>
+	</head>
+	<body data-ipub-element="body">
+		<section data-ipub-element="content">
+			<img data-ipub-element="image" src="images/001.png" alt="Ana hides at the docks." data-ipub-description="Two panels, the docks and a shootout."></img>
+			<div data-ipub-element="panel" data-ipub-order="2" data-ipub-polygon="0,40 60,40 40,100 0,100"></div>
+			<div data-ipub-element="panel" data-ipub-order="1" data-ipub-bounds="0 0 100 40">
+				<p data-ipub-element="caption" data-ipub-anchor="5,5">
+					<span data-ipub-element="text">Meanwhile, at the docks...</span>
+				</p>
+			</div>
+			<p data-ipub-element="sfx" data-ipub-anchor="50,80">
+				<span data-ipub-element="text">BLAM</span>
+			</p>
+			<p data-ipub-element="thought" data-ipub-speaker="leo" data-ipub-anchor="20,60">
+				<span data-ipub-element="alternate" xml:lang="en" data-ipub-source="true">
+					<span data-ipub-element="text">Not again.</span>
+				</span>
+				<span data-ipub-element="alternate" xml:lang="pt-BR">
+					<span data-ipub-element="text">De novo não.</span>
+				</span>
+			</p>
+			<p data-ipub-element="speech" id="ana-shout" data-ipub-speaker="ana" data-ipub-anchor="70,20">
+				<span data-ipub-element="text">Don't </span>
+				<span data-ipub-element="text" data-ipub-emphasis="strong">move</span>
+				<span data-ipub-element="text">!</span>
+			</p>
+		</section>
+		<section data-ipub-element="content">
+			<img data-ipub-element="image" src="images/002.png" data-ipub-decorative="true"></img>
+			<a data-ipub-element="choice" data-ipub-target="chapter-2.xhtml">
+				<span data-ipub-element="text">Follow Leo</span>
+			</a>
+		</section>
+	</body>
+</html>`
+
+func TestSection(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	s := parse(t)
+	es, err := extract.Section(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type entry struct {
+		Role    extract.Role
+		Text    string
+		Lang    string
+		Speaker string
+		Ref     string
+	}
+	got := []entry{}
+	for _, e := range es {
+		got = append(got, entry{e.Role, e.Text, e.Lang, e.Speaker, e.Ref.String()})
+	}
+	assert.Equal([]entry{
+		{extract.RoleAlt, "Ana hides at the docks.", "en", "", "sections/001.xhtml#body[0]/content[0]/image[0]"},
+		{extract.RoleDescription, "Two panels, the docks and a shootout.", "en", "", "sections/001.xhtml#body[0]/content[0]/image[0]"},
+		{extract.RoleCaption, "Meanwhile, at the docks...", "en", "", "sections/001.xhtml#body[0]/content[0]/panel[1]/caption[0]"},
+		{extract.RoleSpeech, "Don't move!", "en", "ana", "sections/001.xhtml#body[0]/content[0]/speech[0;ana-shout]"},
+		{extract.RoleThought, "Not again.", "en", "leo", "sections/001.xhtml#body[0]/content[0]/thought[0]/alternate[0]"},
+		{extract.RoleSoundEffect, "BLAM", "en", "", "sections/001.xhtml#body[0]/content[0]/sfx[0]"},
+		{extract.RoleChoice, "Follow Leo", "en", "", "sections/001.xhtml#body[0]/content[1]/choice[0]"},
+	}, got)
+
+	es, err = extract.Section(s, "pt-BR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(extract.RoleThought, es[4].Role)
+	assert.Equal("De novo não.", es[4].Text)
+	assert.Equal("pt-BR", es[4].Lang)
+	assert.Equal("sections/001.xhtml#body[0]/content[0]/thought[0]/alternate[1]", es[4].Ref.String())
+
+	assert.Equal([]string{"en", "pt-BR"}, extract.Languages(s))
+
+	s.SetHref("")
+	if _, err := extract.Section(s); !errors.Is(err, extract.ErrNoRef) {
+		t.Errorf("expected error %q for section without href, got %v", extract.ErrNoRef, err)
+	}
+}
+
+func TestTokens(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	assert.Equal([]extract.Token{
+		{Value: "don't", Range: ast.TextRange{Start: 0, End: 5}},
+		{Value: "move", Range: ast.TextRange{Start: 6, End: 10}},
+		{Value: "não", Range: ast.TextRange{Start: 13, End: 16}},
+		{Value: "42", Range: ast.TextRange{Start: 18, End: 20}},
+	}, extract.Tokenize("Don't MOVE! 'não' 42'"))
+
+	s := parse(t)
+	pkg := &ast.Package{}
+	pkg.AppendChild(pkg, s)
+
+	es, err := extract.Package(pkg, "pt-BR")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	thought := es[4]
+	ts := thought.Tokens()
+	assert.Equal(3, len(ts))
+	assert.Equal("novo", ts[1].Value)
+
+	r := thought.TokenRef(ts[1])
+	n, err := ast.Resolve(pkg, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, _ := ast.RefText(n)
+	assert.Equal("novo", string([]rune(text)[r.Range.Start:r.Range.End]))
+	assert.Equal(true, thought.Ref.Range == nil)
+
+	alt := es[0]
+	assert.Equal(true, alt.TokenRef(alt.Tokens()[0]).Range == nil)
+}
+
+func parse(t *testing.T) *ast.Section {
+	var s ast.Section
+	if err := xml.Unmarshal([]byte(section), &s); err != nil {
+		t.Fatal(err)
+	}
+	s.SetHref("sections/001.xhtml")
+	return &s
+}
@@ -0,0 +1,51 @@
+package extract
+
+import (
+	"strings"
+	"unicode"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+)
+
+// Token is a word of a text.
+type Token struct {
+	// Value is the word in lower case, as used by search indexes.
+	Value string
+	// Range is the position of the word in the text, counted in runes like the
+	// ranges of refs.
+	Range ast.TextRange
+}
+
+// Tokenize splits s into words, which are runs of letters, digits and marks.
+// Apostrophes between letters, as in "don't", are kept inside the word. The
+// number of tokens is the word count of s.
+func Tokenize(s string) []Token {
+	rs := []rune(s)
+	ts := []Token{}
+
+	start := -1
+	for i := 0; i <= len(rs); i++ {
+		if i < len(rs) && (isWordRune(rs[i]) || (start != -1 && isApostrophe(rs[i]) && i+1 < len(rs) && unicode.IsLetter(rs[i+1]))) {
+			if start == -1 {
+				start = i
+			}
+			continue
+		}
+		if start != -1 {
+			ts = append(ts, Token{
+				Value: strings.ToLower(string(rs[start:i])),
+				Range: ast.TextRange{Start: start, End: i},
+			})
+			start = -1
+		}
+	}
+	return ts
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+func isApostrophe(r rune) bool {
+	return r == '\'' || r == '’'
+}
@@ -0,0 +1,87 @@
+// Package ipub reads and writes ipub packages, zip containers which hold a
+// comic's sections and resources in a single file.
+//
+// A package is structured similarly to an EPUB container:
+//
+//	mimetype             "application/x-ipub+zip", uncompressed and always the first file
+//	package.xml          package document, with the metadata, manifest and spine
+//	sections/*.xhtml     section documents, see [ast.Section]
+//	nav.xhtml            navigation document with the package's [ast.Nav] nodes, if any
+//	...                  resources referenced by the manifest, such as page images
+package ipub
+
+import (
+	"encoding/xml"
+	"errors"
+	"path"
+	"strings"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
+)
+
+const (
+	MimeType        = "application/x-ipub+zip"
+	SectionMimeType = "application/xhtml+xml"
+	Version         = "1.0"
+)
+
+const (
+	mimetypeFile = "mimetype"
+	packageFile  = "package.xml"
+	navFile      = "nav.xhtml"
+)
+
+var (
+	ErrInvalidMimeType     = errors.New("ipub: package does not start with a valid mimetype file")
+	ErrMissingPackageFile  = errors.New("ipub: package document not found")
+	ErrInvalidPackageFile  = errors.New("ipub: package document is invalid")
+	ErrMissingFile         = errors.New("ipub: file referenced by the package document not found")
+	ErrInvalidSection      = errors.New("ipub: section document is invalid")
+	ErrInvalidNav          = errors.New("ipub: navigation document is invalid")
+	ErrDuplicatedHref      = errors.New("ipub: file path is used more than once in package")
+	ErrMissingResourceData = errors.New("ipub: resource has no way to be opened")
+	ErrInvalidHref         = errors.New("ipub: file path is not a valid path inside of the package")
+)
+
+type packageDocument struct {
+	XMLName  xml.Name       `xml:"package"`
+	Version  string         `xml:"version,attr"`
+	Metadata ast.Metadata   `xml:"metadata"`
+	Manifest []manifestItem `xml:"manifest>item"`
+	Spine    spine          `xml:"spine"`
+}
+
+type manifestItem struct {
+	Href       string `xml:"href,attr"`
+	MediaType  string `xml:"media-type,attr"`
+	Properties string `xml:"properties,attr,omitempty"`
+}
+
+// navProperty marks the manifest item of the navigation document.
+const navProperty = "nav"
+
+type navDocument struct {
+	XMLName xml.Name   `xml:"http://www.w3.org/1999/xhtml html"`
+	Navs    []*ast.Nav `xml:"body>nav"`
+}
+
+// spine holds the sections in reading order, and the package's [ast.Layout].
+type spine struct {
+	Progression attr.Enum[ast.Progression] `xml:"progression,attr"`
+	Spread      attr.Enum[ast.SpreadMode]  `xml:"spread-mode,attr"`
+	Items       []spineItem                `xml:"itemref"`
+}
+
+type spineItem struct {
+	Href string `xml:"href,attr"`
+}
+
+// ValidHref reports whether href is a clean path relative to the package root,
+// which doesn't leave the package nor point to a directory. It is the rule used by
+// [Write] and [Read] for the hrefs of sections and resources.
+func ValidHref(href string) bool {
+	return href != "" && href != "." && path.Clean(href) == href &&
+		!path.IsAbs(href) && href != ".." && !strings.HasPrefix(href, "../") &&
+		!strings.ContainsAny(href, "\\\x00")
+}
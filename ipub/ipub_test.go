@@ -0,0 +1,303 @@
+package ipub_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/loreddev/x/tinyssert"
+)
+
+func TestReadWrite(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	pkg := &ast.Package{
+		Metadata: ast.Metadata{
+			Identifier: "urn:uuid:0195f3a4-7b2c-7000-8000-000000000000",
+			Title:      "Test Comic",
+			Creators:   []ast.Creator{{Name: "Jane Doe", Role: ast.RoleAuthor}},
+			Series:     "Tests",
+			Issue:      "1",
+		},
+		Resources: []ast.Resource{
+			newResource("images/001.png", "image/png", "first page"),
+			newResource("images/002.png", "image/png", "second page"),
+		},
+		Layout: ast.Layout{Progression: ast.ProgressionRightToLeft, Spread: ast.SpreadDouble},
+	}
+
+	s1 := &ast.Section{Title: "Chapter 1", Language: "en"}
+	s1.SetBody(newBody())
+	s2 := &ast.Section{Title: "Chapter 2"}
+	s2.SetBody(&ast.Body{})
+	s2.Layout.Progression = ast.ProgressionVertical
+	s2.SetHref("sections/extra.xhtml")
+	pkg.AppendChild(pkg, s1)
+	pkg.AppendChild(pkg, s2)
+
+	toc := &ast.Nav{}
+	toc.SetType(ast.NavTOC)
+	e := &ast.NavEntry{}
+	e.SetTitle("Chapter 2")
+	e.SetSection("sections/extra.xhtml")
+	toc.AppendChild(toc, e)
+	pkg.AppendChild(pkg, toc)
+
+	var b bytes.Buffer
+	if err := ipub.Write(&b, pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	z, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal("mimetype", z.File[0].Name)
+	assert.Equal(zip.Store, z.File[0].Method)
+
+	rpkg, err := ipub.Read(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(pkg.Metadata, rpkg.Metadata)
+	assert.Equal(pkg.Layout, rpkg.Layout)
+
+	ss := rpkg.Sections()
+	assert.Equal(2, len(ss))
+	assert.Equal("Chapter 1", ss[0].Title)
+	assert.Equal("sections/section-001.xhtml", ss[0].Href())
+	assert.Equal("Chapter 2", ss[1].Title)
+	assert.Equal("sections/extra.xhtml", ss[1].Href())
+	assert.NotNil(ss[0].Body())
+	assert.Equal(uint(2), ss[0].Body().ChildCount())
+	assert.Equal("Ana hides.", ss[0].Body().FirstChild().FirstChild().(*ast.Image).Alt())
+
+	// Sections are written without their hrefs, which are kept by the manifest.
+	want, err := xml.Marshal(s1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := xml.Marshal(ss[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(string(want), string(got))
+	assert.Equal(ast.Layout{Progression: ast.ProgressionVertical, Spread: ast.SpreadDouble}, ast.ResolveLayout(ss[1]))
+
+	rtoc := rpkg.Nav(ast.NavTOC)
+	assert.NotNil(rtoc)
+	assert.Equal(1, len(rtoc.Entries()))
+	assert.Equal("Chapter 2", rtoc.Entries()[0].Title())
+	assert.Equal(ast.Node(ss[1]), rtoc.Entries()[0].Target(rpkg))
+
+	assert.Equal(2, len(rpkg.Resources))
+	for i, r := range rpkg.Resources {
+		assert.Equal(pkg.Resources[i].Href, r.Href)
+		assert.Equal(pkg.Resources[i].MediaType, r.MediaType)
+		assert.Equal(readResource(t, pkg.Resources[i]), readResource(t, r))
+	}
+
+	var rb bytes.Buffer
+	if err := ipub.Write(&rb, rpkg); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(b.Bytes(), rb.Bytes()) {
+		t.Error("package is not byte-for-byte stable after a round-trip")
+	}
+}
+
+func TestWriteInvalidHref(t *testing.T) {
+	tests := map[string]struct {
+		href string
+		err  error
+	}{
+		"empty":            {"", ipub.ErrInvalidHref},
+		"parent directory": {"../images/001.png", ipub.ErrInvalidHref},
+		"inner parent":     {"images/../../001.png", ipub.ErrInvalidHref},
+		"absolute":         {"/images/001.png", ipub.ErrInvalidHref},
+		"not clean":        {"images//001.png", ipub.ErrInvalidHref},
+		"directory":        {"images/", ipub.ErrInvalidHref},
+		"backslash":        {`images\001.png`, ipub.ErrInvalidHref},
+		"mimetype":         {"mimetype", ipub.ErrDuplicatedHref},
+		"package document": {"package.xml", ipub.ErrDuplicatedHref},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			pkg := &ast.Package{Resources: []ast.Resource{newResource(test.href, "image/png", "page")}}
+			if err := ipub.Write(io.Discard, pkg); !errors.Is(err, test.err) {
+				t.Errorf("expected error %q, got %v", test.err, err)
+			}
+		})
+	}
+
+	s := &ast.Section{}
+	s.SetBody(&ast.Body{})
+	s.SetHref("../section.xhtml")
+	pkg := &ast.Package{}
+	pkg.AppendChild(pkg, s)
+	if err := ipub.Write(io.Discard, pkg); !errors.Is(err, ipub.ErrInvalidHref) {
+		t.Errorf("expected error %q for section href, got %v", ipub.ErrInvalidHref, err)
+	}
+}
+
+func TestReadInvalidHref(t *testing.T) {
+	tests := map[string]string{
+		"empty":            "",
+		"parent directory": "../images/001.png",
+		"inner parent":     "images/../../001.png",
+		"absolute":         "/images/001.png",
+		"not clean":        "images//001.png",
+		"backslash":        `images\001.png`,
+	}
+
+	for name, href := range tests {
+		t.Run(name, func(t *testing.T) {
+			var b bytes.Buffer
+			z := zip.NewWriter(&b)
+			w, _ := z.Create("mimetype")
+			_, _ = io.WriteString(w, ipub.MimeType)
+			w, _ = z.Create("package.xml")
+			_, _ = fmt.Fprintf(w, `<package version="1.0"><manifest><item href=%q media-type="image/png"/></manifest></package>`, href)
+			if err := z.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := ipub.Read(bytes.NewReader(b.Bytes()), int64(b.Len()))
+			if !errors.Is(err, ipub.ErrInvalidHref) {
+				t.Errorf("expected error %q, got %v", ipub.ErrInvalidHref, err)
+			}
+		})
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	tests := map[string]struct {
+		files map[string]string
+		err   error
+	}{
+		"missing mimetype": {
+			files: map[string]string{"package.xml": `<package version="1.0"></package>`},
+			err:   ipub.ErrInvalidMimeType,
+		},
+		"wrong mimetype": {
+			files: map[string]string{"mimetype": "application/epub+zip"},
+			err:   ipub.ErrInvalidMimeType,
+		},
+		"mimetype with trailing data": {
+			files: map[string]string{"mimetype": ipub.MimeType + "\n"},
+			err:   ipub.ErrInvalidMimeType,
+		},
+		"missing package document": {
+			files: map[string]string{"mimetype": ipub.MimeType},
+			err:   ipub.ErrMissingPackageFile,
+		},
+		"missing section file": {
+			files: map[string]string{
+				"mimetype": ipub.MimeType,
+				"package.xml": `<package version="1.0">
+					<manifest><item href="sections/a.xhtml" media-type="application/xhtml+xml"/></manifest>
+					<spine><itemref href="sections/a.xhtml"/></spine>
+				</package>`,
+			},
+			err: ipub.ErrMissingFile,
+		},
+		"spine not in manifest": {
+			files: map[string]string{
+				"mimetype":    ipub.MimeType,
+				"package.xml": `<package version="1.0"><spine><itemref href="sections/a.xhtml"/></spine></package>`,
+			},
+			err: ipub.ErrInvalidPackageFile,
+		},
+		"invalid progression": {
+			files: map[string]string{
+				"mimetype":    ipub.MimeType,
+				"package.xml": `<package version="1.0"><spine progression="up"></spine></package>`,
+			},
+			err: ipub.ErrInvalidPackageFile,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var b bytes.Buffer
+			z := zip.NewWriter(&b)
+			for _, n := range []string{"mimetype", "package.xml"} {
+				if c, ok := test.files[n]; ok {
+					w, _ := z.Create(n)
+					_, _ = io.WriteString(w, c)
+				}
+			}
+			if err := z.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := ipub.Read(bytes.NewReader(b.Bytes()), int64(b.Len()))
+			if !errors.Is(err, test.err) {
+				t.Errorf("expected error %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+// newBody returns a body with a page which has an image, a panel and a speech, and
+// an empty page.
+func newBody() *ast.Body {
+	b := &ast.Body{}
+
+	c := &ast.Content{}
+	i := &ast.Image{}
+	i.SetSource("images/001.png")
+	i.SetAlt("Ana hides.")
+	c.AppendChild(c, i)
+
+	p := &ast.Panel{}
+	p.SetOrder(1)
+	p.SetBounds(ast.Rect{X: 0, Y: 0, Width: 100, Height: 40.5})
+	c.AppendChild(c, p)
+
+	sp := &ast.Speech{}
+	sp.SetSpeaker("ana")
+	sp.SetAnchor(ast.Point{X: 70, Y: 20})
+	txt := &ast.Text{}
+	txt.SetValue("Get down & stay down!")
+	sp.AppendChild(sp, txt)
+	c.AppendChild(c, sp)
+
+	b.AppendChild(b, c)
+	b.AppendChild(b, &ast.Content{})
+	return b
+}
+
+func newResource(href, mediaType, content string) ast.Resource {
+	return ast.Resource{
+		Href:      href,
+		MediaType: mediaType,
+		Open: func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(content)), nil
+		},
+	}
+}
+
+func readResource(t *testing.T, r ast.Resource) string {
+	rc, err := r.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
@@ -0,0 +1,148 @@
+package ipub
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+)
+
+// Read parses the ipub package in r into a [ast.Package], with each section in the
+// spine as a [ast.Section] child, in order, followed by the [ast.Nav] nodes of the
+// navigation document.
+//
+// Resources are not loaded into memory, their Open function reads directly from r,
+// so r must remain valid while the returned package is in use.
+func Read(r io.ReaderAt, size int64) (*ast.Package, error) {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("ipub: failed to open zip container: %w", err)
+	}
+
+	if len(z.File) == 0 || z.File[0].Name != mimetypeFile {
+		return nil, ErrInvalidMimeType
+	}
+	// The limit is one byte over the expected length, so a longer file is read
+	// far enough to not match, without loading all of it into memory.
+	if m, err := readFile(z.File[0], int64(len(MimeType))+1); err != nil {
+		return nil, errors.Join(ErrInvalidMimeType, err)
+	} else if string(m) != MimeType {
+		return nil, ErrInvalidMimeType
+	}
+
+	files := make(map[string]*zip.File, len(z.File))
+	for _, f := range z.File {
+		files[f.Name] = f
+	}
+
+	pf, ok := files[packageFile]
+	if !ok {
+		return nil, ErrMissingPackageFile
+	}
+
+	var doc packageDocument
+	if err := decodeFile(pf, &doc); err != nil {
+		return nil, errors.Join(ErrInvalidPackageFile, err)
+	}
+
+	inSpine := make(map[string]bool, len(doc.Spine.Items))
+	for _, i := range doc.Spine.Items {
+		inSpine[i.Href] = true
+	}
+
+	pkg := &ast.Package{
+		Metadata: doc.Metadata,
+		Layout:   ast.Layout{Progression: doc.Spine.Progression.Value, Spread: doc.Spine.Spread.Value},
+	}
+	manifest := make(map[string]manifestItem, len(doc.Manifest))
+	var nav *zip.File
+
+	for _, i := range doc.Manifest {
+		if !ValidHref(i.Href) {
+			return nil, errors.Join(ErrInvalidHref, fmt.Errorf("manifest href %q", i.Href))
+		}
+		if _, ok := manifest[i.Href]; ok {
+			return nil, errors.Join(ErrDuplicatedHref, fmt.Errorf("manifest href %q", i.Href))
+		}
+		manifest[i.Href] = i
+
+		f, ok := files[i.Href]
+		if !ok {
+			return nil, errors.Join(ErrMissingFile, fmt.Errorf("manifest href %q", i.Href))
+		}
+
+		if inSpine[i.Href] {
+			continue
+		}
+		if i.Properties == navProperty {
+			if nav != nil {
+				return nil, errors.Join(ErrInvalidPackageFile, errors.New("more than one navigation document"))
+			}
+			nav = f
+			continue
+		}
+
+		pkg.Resources = append(pkg.Resources, ast.Resource{
+			Href:      i.Href,
+			MediaType: i.MediaType,
+			Open: func() (io.ReadCloser, error) {
+				return f.Open()
+			},
+		})
+	}
+
+	for _, i := range doc.Spine.Items {
+		m, ok := manifest[i.Href]
+		if !ok {
+			return nil, errors.Join(ErrInvalidPackageFile, fmt.Errorf("spine href %q is not in manifest", i.Href))
+		}
+		if m.MediaType != SectionMimeType {
+			return nil, errors.Join(ErrInvalidPackageFile,
+				fmt.Errorf("spine href %q has media type %q, expected %q", i.Href, m.MediaType, SectionMimeType))
+		}
+
+		s := &ast.Section{}
+		if err := decodeFile(files[i.Href], s); err != nil {
+			return nil, errors.Join(ErrInvalidSection, fmt.Errorf("section %q: %w", i.Href, err))
+		}
+		s.SetHref(i.Href)
+
+		pkg.AppendChild(pkg, s)
+	}
+
+	if nav != nil {
+		var doc navDocument
+		if err := decodeFile(nav, &doc); err != nil {
+			return nil, errors.Join(ErrInvalidNav, err)
+		}
+		for _, n := range doc.Navs {
+			pkg.AppendChild(pkg, n)
+		}
+	}
+
+	return pkg, nil
+}
+
+// readFile reads at most n bytes of f.
+func readFile(f *zip.File, n int64) ([]byte, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(io.LimitReader(r, n))
+}
+
+func decodeFile(f *zip.File, v any) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return xml.NewDecoder(r).Decode(v)
+}
@@ -0,0 +1,446 @@
+// Package render renders ipub trees into formats that can be displayed by
+// readers, such as HTML.
+package render
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/style"
+)
+
+// HTML writes n and its children as HTML into w. The output is also valid XHTML,
+// so it can be embedded into EPUB content documents.
+//
+// Nodes of unknown kinds are not rendered, but their children are. The classes of
+// nodes are written in the data-class attribute of their elements, which is matched
+// by the stylesheets of their section, see [style.Sanitize]. Stylesheets are only
+// rendered if the section is inside of the package which has them.
+func HTML(w io.Writer, n ast.Node) error {
+	r := &htmlRenderer{
+		w:          w,
+		animations: map[*ast.Animation]string{},
+		spreads:    map[*ast.Content][]*ast.Content{},
+	}
+	return ast.Walk(n, r.walk)
+}
+
+type htmlRenderer struct {
+	w   io.Writer
+	err error
+
+	// Selector of the body being rendered, which scopes the styles of its section
+	// and animations. Empty if the body doesn't have a section with a href.
+	scope string
+
+	animations map[*ast.Animation]string
+	spreads    map[*ast.Content][]*ast.Content // Pages shown at once, by their first and last page
+}
+
+func (r *htmlRenderer) walk(n ast.Node, entering bool) (ast.WalkStatus, error) {
+	switch n := n.(type) {
+	case *ast.Body:
+		if entering {
+			r.body(n)
+		} else {
+			r.write(`</div>`)
+			r.scope = ""
+		}
+	case *ast.Content:
+		r.content(n, entering)
+	case *ast.Image:
+		if entering {
+			r.write(`<img class="ipub-image"%s%s`, urlAttr("src", n.Source()), classAttr(n))
+			for _, a := range AccessibilityAttrs(n) {
+				r.write(` %s="%s"`, a.Name.Local, html.EscapeString(a.Value))
+			}
+			r.write(`/>`)
+		}
+	case *ast.Panel:
+		// Panels are positioned over the page image by their bounding box, so
+		// readers can zoom into them one by one.
+		r.tag(entering, fmt.Sprintf(
+			`<div class="ipub-panel" data-order="%d"%s%s%s style="%s">`,
+			n.Order(), classAttr(n), conditionAttr(n.Condition()), r.animationAttrs(n), shapeStyle(n.Bounds()),
+		), `</div>`)
+	case *ast.Layer:
+		open := fmt.Sprintf(`<div class="ipub-layer" data-layer="%s"%s`, html.EscapeString(n.Name()), classAttr(n))
+		if n.Hidden() {
+			open += ` hidden="hidden"`
+		}
+		r.tag(entering, open+r.animationAttrs(n)+">", `</div>`)
+	case *ast.Animation:
+		if name, ok := r.animations[n]; ok && entering {
+			r.write(`<style>%s</style>`, AnimationCSS(name, r.scope, n))
+		}
+		return ast.WalkSkipChildren, r.err
+	case *ast.Link:
+		r.tag(entering, fmt.Sprintf(
+			`<a class="ipub-link"%s%s style="%s">`, urlAttr("href", n.Href()), classAttr(n), shapeStyle(n.Shape()),
+		), `</a>`)
+	case *ast.Jump:
+		r.tag(entering, fmt.Sprintf(
+			`<a class="ipub-jump" data-section="%s" data-path="%s"%s style="%s">`,
+			html.EscapeString(n.Section()), html.EscapeString(n.Path()), classAttr(n), shapeStyle(n.Shape()),
+		), `</a>`)
+	case *ast.Reveal:
+		r.tag(entering, fmt.Sprintf(
+			`<button type="button" class="ipub-reveal" data-layer="%s"%s style="%s">`,
+			html.EscapeString(n.Layer()), classAttr(n), shapeStyle(n.Shape()),
+		), `</button>`)
+	case *ast.Choice:
+		// The reader keeps the state of the story, showing only the choices and
+		// panels whose condition is true and applying the effects when one is picked.
+		open := fmt.Sprintf(`<a class="ipub-choice" data-target="%s"%s%s`,
+			html.EscapeString(n.Target()), classAttr(n), conditionAttr(n.Condition()))
+		if es := n.Effects(); len(es) > 0 {
+			open += fmt.Sprintf(` data-set="%s"`, html.EscapeString(es.String()))
+		}
+		r.tag(entering, open+">", `</a>`)
+	case *ast.Nav:
+		r.tag(entering, fmt.Sprintf(`<nav class="ipub-nav" data-type="%s"%s><ol>`,
+			html.EscapeString(string(n.Type())), classAttr(n)), `</ol></nav>`)
+	case *ast.NavEntry:
+		if entering {
+			r.write(`<li class="ipub-nav-entry"%s`, classAttr(n))
+			if l := n.Landmark(); l != "" {
+				r.write(` data-landmark="%s"`, html.EscapeString(string(l)))
+			}
+			r.write(`><a data-section="%s" data-path="%s">%s</a>`,
+				html.EscapeString(n.Section()), html.EscapeString(n.Path()), html.EscapeString(n.Title()))
+		}
+		if n.HasChildren() {
+			r.tag(entering, `<ol>`, `</ol>`)
+		}
+		if !entering {
+			r.write(`</li>`)
+		}
+	case *ast.Soundtrack:
+		if entering {
+			r.audio("ipub-soundtrack", n.AudioClip, n.Loop())
+		}
+	case *ast.Sound:
+		if entering {
+			r.audio("ipub-sound", n.AudioClip, false)
+		}
+	case *ast.Alternate:
+		r.tag(entering, fmt.Sprintf(`<span lang="%s"%s>`, html.EscapeString(n.Lang()), classAttr(n)), `</span>`)
+	case ast.Dialog:
+		r.dialog(n, entering)
+	case *ast.Text:
+		if !entering {
+			break
+		}
+		v := html.EscapeString(n.Value())
+		switch n.Emphasis() {
+		case ast.EmphasisStrong:
+			r.write(`<strong%s>%s</strong>`, classAttr(n), v)
+		case ast.EmphasisItalic:
+			r.write(`<em%s>%s</em>`, classAttr(n), v)
+		default:
+			if len(n.Classes()) > 0 {
+				v = fmt.Sprintf(`<span%s>%s</span>`, classAttr(n), v)
+			}
+			r.write("%s", v)
+		}
+	}
+	return ast.WalkContinue, r.err
+}
+
+// body writes the start of the body, with the layout its pages should be shown
+// in by the reader, which are left to right single pages if not set. With
+// [ast.SpreadDouble], pages shown at once are grouped by the renderer, see
+// [ast.Spreads], and the reader places them side by side in the order of the
+// progression.
+//
+// The stylesheets of the section are written at the start of the body. They and
+// the animations of the section are scoped to the body by the section's href, so
+// sections rendered in the same page don't style or animate each other.
+func (r *htmlRenderer) body(n *ast.Body) {
+	l := ast.ResolveLayout(n)
+	r.write(`<div class="ipub-body"%s`, classAttr(n))
+	if l.Progression != ast.ProgressionDefault {
+		r.write(` data-progression="%s"`, l.Progression)
+	}
+	if l.Spread != ast.SpreadAuto {
+		r.write(` data-spread="%s"`, l.Spread)
+	}
+
+	s, pkg := sectionOf(n)
+	if s != nil && s.Href() != "" {
+		r.scope = ".ipub-body" + style.AttrSelector("data-section", s.Href())
+		r.write(` data-section="%s"`, html.EscapeString(s.Href()))
+	}
+	r.write(`>`)
+
+	if s != nil && pkg != nil && len(s.Stylesheets) > 0 {
+		css, err := style.Section(s, pkg.Resources, r.scope)
+		if err != nil && r.err == nil {
+			r.err = errors.Join(fmt.Errorf("render: failed to render stylesheets of section %q", s.Href()), err)
+		}
+		r.write(`<style>%s</style>`, css)
+	}
+
+	if l.Spread != ast.SpreadDouble || l.Progression == ast.ProgressionVertical {
+		return
+	}
+	for _, s := range ast.Spreads(n) {
+		r.spreads[s[0]] = s
+		r.spreads[s[len(s)-1]] = s
+	}
+}
+
+// sectionOf returns the section of the body n and the package of the section, or
+// nil if n is not inside of them.
+func sectionOf(n *ast.Body) (*ast.Section, *ast.Package) {
+	s, ok := n.Parent().(*ast.Section)
+	if !ok {
+		return nil, nil
+	}
+	pkg, _ := s.Parent().(*ast.Package)
+	return s, pkg
+}
+
+func (r *htmlRenderer) content(n *ast.Content, entering bool) {
+	s := r.spreads[n]
+	if entering {
+		if len(s) > 0 && s[0] == n {
+			r.write(`<div class="ipub-spread">`)
+		}
+		r.write(`<section class="ipub-content"%s`, classAttr(n))
+		if n.Spread() {
+			r.write(` data-spread="true"`)
+		}
+		r.write(`>`)
+		return
+	}
+
+	r.write(`</section>`)
+	if len(s) > 0 && s[len(s)-1] == n {
+		r.write(`</div>`)
+	}
+}
+
+// audio writes an audio element which is not played by the browser by itself,
+// it is controlled by the reader's script, using the volume and fade durations
+// (in milliseconds) in the element's data attributes.
+func (r *htmlRenderer) audio(class string, n ast.AudioClip, loop bool) {
+	r.write(`<audio class="%s"%s preload="auto"`, class, urlAttr("src", n.Source()))
+	if loop {
+		r.write(` loop="loop"`)
+	}
+	r.write(` data-volume="%g" data-fade-in="%d" data-fade-out="%d"></audio>`,
+		n.Volume(), n.FadeIn().Milliseconds(), n.FadeOut().Milliseconds())
+}
+
+// animationAttrs names the animations of the target n, returning the attributes
+// which list them by trigger. The reader starts an animation by adding its name to
+// the data-playing attribute of the target when the trigger happens.
+func (r *htmlRenderer) animationAttrs(n ast.Node) string {
+	triggers := map[ast.Trigger][]string{}
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		a, ok := c.(*ast.Animation)
+		if !ok {
+			continue
+		}
+		name := animationName(a, len(r.animations)+1)
+		r.animations[a] = name
+		triggers[a.Trigger()] = append(triggers[a.Trigger()], name)
+	}
+
+	var b strings.Builder
+	for _, t := range []ast.Trigger{ast.TriggerEnter, ast.TriggerTap} {
+		if names := triggers[t]; len(names) > 0 {
+			fmt.Fprintf(&b, ` data-animate-%s="%s"`, t, strings.Join(names, " "))
+		}
+	}
+	return b.String()
+}
+
+// animationName returns the name of the i-th animation rendered, which has the
+// href of the animation's section so names of different sections don't collide
+// when they are rendered in the same page.
+func animationName(a *ast.Animation, i int) string {
+	s, ok := ast.FindAncestor(a, ast.KindSection).(*ast.Section)
+	if !ok || s.Href() == "" {
+		return fmt.Sprintf("ipub-animation-%d", i)
+	}
+
+	// Characters which can't be in identifiers are escaped by their code, and so
+	// is the escape character, so different hrefs always have different names.
+	var b strings.Builder
+	for _, c := range []byte(s.Href()) {
+		if c < 0x80 && c != '-' && !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') && !('0' <= c && c <= '9') {
+			fmt.Fprintf(&b, "_%x", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return fmt.Sprintf("ipub-animation-%s-%d", b.String(), i)
+}
+
+// AnimationCSS returns the CSS keyframes of a, named name, and the rule which
+// plays them on the target once name is in its data-playing attribute. The rule
+// only matches elements inside of the selector scope, if it is not empty.
+//
+// The translation of the keyframes is added to the position of the target, so it
+// is relative to the page like in the ipub model.
+func AnimationCSS(name, scope string, a *ast.Animation) string {
+	var origin ast.Point
+	if p, ok := a.Target().(*ast.Panel); ok {
+		b := p.Bounds()
+		origin = ast.Point{X: b.X, Y: b.Y}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "@keyframes %s{", name)
+	for _, k := range a.Keyframes() {
+		props := []string{}
+		if t, ok := k.Translate(); ok {
+			props = append(props, fmt.Sprintf("left:%g%%;top:%g%%", origin.X+t.X, origin.Y+t.Y))
+		}
+		if v, ok := k.Scale(); ok {
+			props = append(props, fmt.Sprintf("scale:%g", v))
+		}
+		if v, ok := k.Opacity(); ok {
+			props = append(props, fmt.Sprintf("opacity:%g", v))
+		}
+		if e := k.Easing(); e != "" {
+			props = append(props, fmt.Sprintf("animation-timing-function:%s", e))
+		}
+		fmt.Fprintf(&b, "%g%%{%s}", k.Offset(), strings.Join(props, ";"))
+	}
+	b.WriteString("}")
+
+	iterations := "infinite"
+	if i := a.Iterations(); i != ast.InfiniteIterations {
+		iterations = fmt.Sprint(i)
+	}
+
+	// The "both" fill mode keeps the first keyframe before the animation starts
+	// and the last one after it ends, the same as ast.Animation.At.
+	if scope != "" {
+		b.WriteString(scope + " ")
+	}
+	fmt.Fprintf(&b, `[data-playing~="%s"]{animation:%s %dms %s %dms %s both}`,
+		name, name, a.Duration().Milliseconds(), a.Easing(), a.Delay().Milliseconds(), iterations)
+
+	return b.String()
+}
+
+// urlAttr returns the attribute name with the URL u, or an empty string if u is
+// not allowed in documents, such as "javascript:" URLs. See [attr.URL.Allowed].
+func urlAttr(name, u string) string {
+	if !attr.URL(u).Allowed() {
+		return ""
+	}
+	return fmt.Sprintf(` %s="%s"`, name, html.EscapeString(u))
+}
+
+// classAttr returns the attribute with the classes of n, or an empty string if it
+// has none. The class attribute is not used, so the classes of nodes can't apply
+// the styles of the page the reader is embedded in.
+func classAttr(n ast.Node) string {
+	cs := n.Classes()
+	if len(cs) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(` data-class="%s"`, html.EscapeString(strings.Join(cs, " ")))
+}
+
+// conditionAttr returns the attribute with the condition for the element to be
+// shown by the reader, or an empty string if it is always shown.
+func conditionAttr(c ast.Condition) string {
+	if len(c) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(` data-if="%s"`, html.EscapeString(c.String()))
+}
+
+// shapeStyle returns the CSS to position an element over the bounding box of s,
+// relative to the page.
+func shapeStyle(s ast.Shape) string {
+	if s == nil {
+		return ""
+	}
+	b := s.Bounds()
+	if _, ok := s.(ast.Point); ok {
+		return fmt.Sprintf("left:%g%%;top:%g%%", b.X, b.Y)
+	}
+	return fmt.Sprintf("left:%g%%;top:%g%%;width:%g%%;height:%g%%", b.X, b.Y, b.Width, b.Height)
+}
+
+// AccessibilityAttrs returns the HTML attributes which describe the image to
+// assistive technologies, always including an alt attribute:
+//
+//   - Decorative images have an empty alt and the "presentation" role, so they
+//     are skipped by screen readers.
+//   - The language of the image is set with lang.
+//   - Long descriptions are set with aria-description, and references to them
+//     with aria-details if they are a fragment of the document, or longdesc
+//     otherwise. References which are not allowed URLs are left out.
+func AccessibilityAttrs(n *ast.Image) []xml.Attr {
+	if n.Decorative() {
+		return []xml.Attr{
+			{Name: xml.Name{Local: "alt"}, Value: ""},
+			{Name: xml.Name{Local: "role"}, Value: "presentation"},
+		}
+	}
+
+	attrs := []xml.Attr{{Name: xml.Name{Local: "alt"}, Value: n.Alt()}}
+	if l := n.Lang(); l != "" {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "lang"}, Value: l})
+	}
+	if d := n.Description(); d != "" {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "aria-description"}, Value: d})
+	}
+	if ref := n.DescriptionRef(); strings.HasPrefix(ref, "#") {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "aria-details"}, Value: ref[1:]})
+	} else if ref != "" && attr.URL(ref).Allowed() {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "longdesc"}, Value: ref})
+	}
+	return attrs
+}
+
+func (r *htmlRenderer) dialog(n ast.Dialog, entering bool) {
+	if !entering {
+		r.write(`</p>`)
+		return
+	}
+
+	r.write(`<p class="ipub-%s"%s`, n.Kind(), classAttr(n))
+	if s := n.Speaker(); s != "" {
+		r.write(` data-speaker="%s"`, html.EscapeString(s))
+	}
+	if t := n.Tail(); t != ast.TailNone {
+		r.write(` data-tail="%s"`, t)
+	}
+	if s := n.Style(); s != "" {
+		r.write(` data-style="%s"`, html.EscapeString(s))
+	}
+	a := n.Anchor()
+	r.write(` style="left:%g%%;top:%g%%">`, a.X, a.Y)
+}
+
+func (r *htmlRenderer) tag(entering bool, open, close string) {
+	if entering {
+		r.write("%s", open)
+	} else {
+		r.write("%s", close)
+	}
+}
+
+func (r *htmlRenderer) write(format string, a ...any) {
+	if r.err != nil {
+		return
+	}
+	if _, err := fmt.Fprintf(r.w, format, a...); err != nil {
+		r.err = errors.Join(errors.New("render: failed to write to output"), err)
+	}
+}
@@ -0,0 +1,470 @@
+package render_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/render"
+	"forge.capytal.company/loreddev/x/tinyssert"
+)
+
+func TestHTML(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	b := &ast.Body{}
+	c := &ast.Content{}
+	i := &ast.Image{}
+	i.SetSource(`images/"001".png`)
+	c.AppendChild(c, i)
+	p := &ast.Panel{}
+	p.SetOrder(1)
+	p.SetBounds(ast.Rect{X: 0, Y: 0, Width: 100, Height: 40.5})
+	c.AppendChild(c, p)
+	sp := &ast.Speech{}
+	sp.SetSpeaker(`"ana"`)
+	sp.SetAnchor(ast.Point{X: 70, Y: 20.5})
+	sp.SetTail(ast.TailSouthWest)
+	t1, t2 := &ast.Text{}, &ast.Text{}
+	t1.SetValue("Get ")
+	t2.SetValue("<down>")
+	t2.SetEmphasis(ast.EmphasisStrong)
+	sp.AppendChild(sp, t1)
+	sp.AppendChild(sp, t2)
+	c.AppendChild(c, sp)
+	b.AppendChild(b, c)
+
+	var w bytes.Buffer
+	s := &ast.Section{}
+	s.SetBody(b)
+	if err := render.HTML(&w, s); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(
+		`<div class="ipub-body"><section class="ipub-content">`+
+			`<img class="ipub-image" src="images/&#34;001&#34;.png" alt=""/>`+
+			`<div class="ipub-panel" data-order="1" style="left:0%;top:0%;width:100%;height:40.5%"></div>`+
+			`<p class="ipub-speech" data-speaker="&#34;ana&#34;" data-tail="sw" style="left:70%;top:20.5%">`+
+			`Get <strong>&lt;down&gt;</strong></p>`+
+			`</section></div>`,
+		w.String(),
+	)
+}
+
+func TestAccessibilityAttrs(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	tests := []struct {
+		image    func(i *ast.Image)
+		expected string
+	}{
+		{
+			image:    func(i *ast.Image) {},
+			expected: `<img class="ipub-image" src="001.png" alt=""/>`,
+		},
+		{
+			image: func(i *ast.Image) {
+				i.SetAlt("Ana & Leo")
+				i.SetLang("en")
+				i.SetDescription("Ana hides behind crates.")
+				i.SetDescriptionRef("#page-1-description")
+			},
+			expected: `<img class="ipub-image" src="001.png" alt="Ana &amp; Leo" lang="en" ` +
+				`aria-description="Ana hides behind crates." aria-details="page-1-description"/>`,
+		},
+		{
+			image: func(i *ast.Image) {
+				i.SetAlt("Ana")
+				i.SetDescriptionRef("descriptions/001.html")
+			},
+			expected: `<img class="ipub-image" src="001.png" alt="Ana" longdesc="descriptions/001.html"/>`,
+		},
+		{
+			image: func(i *ast.Image) {
+				i.SetAlt("Border")
+				i.SetDescription("A border.")
+				i.SetDecorative(true)
+			},
+			expected: `<img class="ipub-image" src="001.png" alt="" role="presentation"/>`,
+		},
+		{
+			image: func(i *ast.Image) {
+				i.SetSource("javascript:alert(1)")
+				i.SetAlt("Ana")
+				i.SetDescriptionRef("javascript:alert(2)")
+			},
+			expected: `<img class="ipub-image" alt="Ana"/>`,
+		},
+	}
+
+	for _, test := range tests {
+		i := &ast.Image{}
+		i.SetSource("001.png")
+		test.image(i)
+
+		var w bytes.Buffer
+		if err := render.HTML(&w, i); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(test.expected, w.String())
+	}
+}
+
+func TestHTMLInteractions(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	c := &ast.Content{}
+
+	l := &ast.Link{}
+	l.SetHref("https://example.com/?a=1&b=2")
+	l.SetShape(ast.Point{X: 10, Y: 20})
+	c.AppendChild(c, l)
+
+	unsafe := &ast.Link{}
+	unsafe.SetHref("javascript:alert(document.cookie)")
+	unsafe.SetShape(ast.Point{X: 30, Y: 20})
+	c.AppendChild(c, unsafe)
+
+	j := &ast.Jump{}
+	j.SetSection("sections/002.xhtml")
+	j.SetPath("body[0]/content[0]/panel[1]")
+	j.SetShape(ast.Rect{X: 0, Y: 90, Width: 100, Height: 10})
+	c.AppendChild(c, j)
+
+	r := &ast.Reveal{}
+	r.SetLayer("secret")
+	r.SetShape(ast.Polygon{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 0, Y: 10}})
+	c.AppendChild(c, r)
+
+	layer := &ast.Layer{}
+	layer.SetName("secret")
+	layer.SetHidden(true)
+	c.AppendChild(c, layer)
+
+	var w bytes.Buffer
+	if err := render.HTML(&w, c); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(
+		`<section class="ipub-content">`+
+			`<a class="ipub-link" href="https://example.com/?a=1&amp;b=2" style="left:10%;top:20%"></a>`+
+			`<a class="ipub-link" style="left:30%;top:20%"></a>`+
+			`<a class="ipub-jump" data-section="sections/002.xhtml" data-path="body[0]/content[0]/panel[1]" `+
+			`style="left:0%;top:90%;width:100%;height:10%"></a>`+
+			`<button type="button" class="ipub-reveal" data-layer="secret" `+
+			`style="left:0%;top:0%;width:10%;height:10%"></button>`+
+			`<div class="ipub-layer" data-layer="secret" hidden="hidden"></div>`+
+			`</section>`,
+		w.String(),
+	)
+}
+
+func TestHTMLAudio(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	b := &ast.Body{}
+
+	st := &ast.Soundtrack{}
+	st.SetSource("audio/rain.ogg")
+	st.SetLoop(true)
+	st.SetVolume(0.5)
+	st.SetFadeIn(2 * time.Second)
+	b.AppendChild(b, st)
+
+	s := &ast.Sound{}
+	s.SetSource("audio/thunder.ogg")
+	b.AppendChild(b, s)
+
+	unsafe := &ast.Sound{}
+	unsafe.SetSource("javascript:alert(1)")
+	b.AppendChild(b, unsafe)
+
+	var w bytes.Buffer
+	if err := render.HTML(&w, b); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(
+		`<div class="ipub-body">`+
+			`<audio class="ipub-soundtrack" src="audio/rain.ogg" preload="auto" loop="loop" `+
+			`data-volume="0.5" data-fade-in="2000" data-fade-out="0"></audio>`+
+			`<audio class="ipub-sound" src="audio/thunder.ogg" preload="auto" `+
+			`data-volume="1" data-fade-in="0" data-fade-out="0"></audio>`+
+			`<audio class="ipub-sound" preload="auto" `+
+			`data-volume="1" data-fade-in="0" data-fade-out="0"></audio>`+
+			`</div>`,
+		w.String(),
+	)
+}
+
+func TestHTMLChoices(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	c := &ast.Content{}
+
+	p := &ast.Panel{}
+	p.SetOrder(1)
+	p.SetBounds(ast.Rect{X: 0, Y: 0, Width: 100, Height: 50})
+	p.SetCondition(ast.Condition{{Var: "met_ana", Op: ast.OpNotEqual}})
+	c.AppendChild(c, p)
+
+	ch := &ast.Choice{}
+	ch.SetTarget("sections/003.xhtml")
+	ch.SetCondition(ast.Condition{{Var: "coins", Op: ast.OpGreaterEqual, Value: 3}})
+	ch.SetEffects(ast.Effects{{Var: "coins", Op: "-=", Value: 3}})
+	text := &ast.Text{}
+	text.SetValue("Pay")
+	ch.AppendChild(ch, text)
+	c.AppendChild(c, ch)
+
+	var w bytes.Buffer
+	if err := render.HTML(&w, c); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(
+		`<section class="ipub-content">`+
+			`<div class="ipub-panel" data-order="1" data-if="met_ana" `+
+			`style="left:0%;top:0%;width:100%;height:50%"></div>`+
+			`<a class="ipub-choice" data-target="sections/003.xhtml" data-if="coins &gt;= 3" `+
+			`data-set="coins -= 3">Pay</a>`+
+			`</section>`,
+		w.String(),
+	)
+}
+
+func TestHTMLNav(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	nav := &ast.Nav{}
+	nav.SetType(ast.NavTOC)
+
+	vol := &ast.NavEntry{}
+	vol.SetTitle("Volume 1")
+	vol.SetSection("sections/001.xhtml")
+	vol.SetLandmark(ast.LandmarkStart)
+	nav.AppendChild(nav, vol)
+
+	ch := &ast.NavEntry{}
+	ch.SetTitle("Tom & Jerry")
+	ch.SetSection("sections/002.xhtml")
+	ch.SetPath("body[0]/content[1]")
+	vol.AppendChild(vol, ch)
+
+	var w bytes.Buffer
+	if err := render.HTML(&w, nav); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(
+		`<nav class="ipub-nav" data-type="toc"><ol>`+
+			`<li class="ipub-nav-entry" data-landmark="bodymatter">`+
+			`<a data-section="sections/001.xhtml" data-path="">Volume 1</a><ol>`+
+			`<li class="ipub-nav-entry">`+
+			`<a data-section="sections/002.xhtml" data-path="body[0]/content[1]">Tom &amp; Jerry</a></li>`+
+			`</ol></li>`+
+			`</ol></nav>`,
+		w.String(),
+	)
+}
+
+func TestHTMLAnimations(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	p := &ast.Panel{}
+	p.SetOrder(1)
+	p.SetBounds(ast.Rect{X: 10, Y: 20, Width: 50, Height: 50})
+
+	a := &ast.Animation{}
+	a.SetDuration(1500 * time.Millisecond)
+	a.SetEasing(ast.EasingEaseOut)
+	p.AppendChild(p, a)
+
+	from := &ast.Keyframe{}
+	from.SetOffset(0)
+	from.SetTranslate(ast.Point{X: -10, Y: 0})
+	from.SetOpacity(0)
+	a.AppendChild(a, from)
+
+	to := &ast.Keyframe{}
+	to.SetOffset(100)
+	to.SetTranslate(ast.Point{X: 0, Y: 0})
+	to.SetOpacity(1)
+	a.AppendChild(a, to)
+
+	tap := &ast.Animation{}
+	tap.SetTrigger(ast.TriggerTap)
+	tap.SetDuration(500 * time.Millisecond)
+	tap.SetDelay(100 * time.Millisecond)
+	tap.SetIterations(ast.InfiniteIterations)
+	p.AppendChild(p, tap)
+
+	k := &ast.Keyframe{}
+	k.SetOffset(50)
+	k.SetScale(1.2)
+	k.SetEasing(ast.EasingEaseInOut)
+	tap.AppendChild(tap, k)
+
+	var w bytes.Buffer
+	if err := render.HTML(&w, p); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(
+		`<div class="ipub-panel" data-order="1" data-animate-enter="ipub-animation-1" data-animate-tap="ipub-animation-2" `+
+			`style="left:10%;top:20%;width:50%;height:50%">`+
+			`<style>@keyframes ipub-animation-1{0%{left:0%;top:20%;opacity:0}100%{left:10%;top:20%;opacity:1}}`+
+			`[data-playing~="ipub-animation-1"]{animation:ipub-animation-1 1500ms ease-out 0ms 1 both}</style>`+
+			`<style>@keyframes ipub-animation-2{50%{scale:1.2;animation-timing-function:ease-in-out}}`+
+			`[data-playing~="ipub-animation-2"]{animation:ipub-animation-2 500ms linear 100ms infinite both}</style>`+
+			`</div>`,
+		w.String(),
+	)
+}
+
+func TestHTMLAnimationsSections(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	var w bytes.Buffer
+	for _, href := range []string{"sections/001.xhtml", "sections/a_b.xhtml"} {
+		p := &ast.Panel{}
+		a := &ast.Animation{}
+		a.SetDuration(time.Second)
+		p.AppendChild(p, a)
+
+		c := &ast.Content{}
+		c.AppendChild(c, p)
+		b := &ast.Body{}
+		b.AppendChild(b, c)
+
+		s := &ast.Section{}
+		s.SetHref(href)
+		s.SetBody(b)
+
+		if err := render.HTML(&w, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assert.Equal(
+		`<div class="ipub-body" data-section="sections/001.xhtml"><section class="ipub-content">`+
+			`<div class="ipub-panel" data-order="0" data-animate-enter="ipub-animation-sections_2f001_2exhtml-1" `+
+			`style="left:0%;top:0%;width:0%;height:0%">`+
+			`<style>@keyframes ipub-animation-sections_2f001_2exhtml-1{}`+
+			`.ipub-body[data-section="sections/001.xhtml"] [data-playing~="ipub-animation-sections_2f001_2exhtml-1"]`+
+			`{animation:ipub-animation-sections_2f001_2exhtml-1 1000ms linear 0ms 1 both}</style>`+
+			`</div></section></div>`+
+			`<div class="ipub-body" data-section="sections/a_b.xhtml"><section class="ipub-content">`+
+			`<div class="ipub-panel" data-order="0" data-animate-enter="ipub-animation-sections_2fa_5fb_2exhtml-1" `+
+			`style="left:0%;top:0%;width:0%;height:0%">`+
+			`<style>@keyframes ipub-animation-sections_2fa_5fb_2exhtml-1{}`+
+			`.ipub-body[data-section="sections/a_b.xhtml"] [data-playing~="ipub-animation-sections_2fa_5fb_2exhtml-1"]`+
+			`{animation:ipub-animation-sections_2fa_5fb_2exhtml-1 1000ms linear 0ms 1 both}</style>`+
+			`</div></section></div>`,
+		w.String(),
+	)
+}
+
+func TestHTMLSpreads(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	b := &ast.Body{}
+	for _, spread := range []bool{false, false, false, true} {
+		c := &ast.Content{}
+		c.SetSpread(spread)
+		b.AppendChild(b, c)
+	}
+
+	s := &ast.Section{Layout: ast.Layout{Spread: ast.SpreadDouble}}
+	s.SetBody(b)
+	pkg := &ast.Package{Layout: ast.Layout{Progression: ast.ProgressionRightToLeft}}
+	pkg.AppendChild(pkg, s)
+
+	var w bytes.Buffer
+	if err := render.HTML(&w, s); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(
+		`<div class="ipub-body" data-progression="rtl" data-spread="double">`+
+			`<div class="ipub-spread"><section class="ipub-content"></section><section class="ipub-content"></section></div>`+
+			`<div class="ipub-spread"><section class="ipub-content"></section></div>`+
+			`<div class="ipub-spread"><section class="ipub-content" data-spread="true"></section></div>`+
+			`</div>`,
+		w.String(),
+	)
+
+	s.Layout.Progression = ast.ProgressionVertical
+
+	w.Reset()
+	if err := render.HTML(&w, s); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(
+		`<div class="ipub-body" data-progression="vertical" data-spread="double">`+
+			`<section class="ipub-content"></section><section class="ipub-content"></section>`+
+			`<section class="ipub-content"></section><section class="ipub-content" data-spread="true"></section>`+
+			`</div>`,
+		w.String(),
+	)
+}
+
+func TestHTMLStylesheets(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	b := &ast.Body{}
+	sp := &ast.Speech{}
+	sp.SetClasses([]string{"balloon", "shout"})
+	sp.SetAnchor(ast.Point{X: 10, Y: 10})
+	b.AppendChild(b, sp)
+
+	s := &ast.Section{Stylesheets: []string{"styles/house.css"}}
+	s.SetHref("sections/001.xhtml")
+	s.SetBody(b)
+
+	var w bytes.Buffer
+	if err := render.HTML(&w, s); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stylesheets are not rendered without the package's resources.
+	assert.Equal(
+		`<div class="ipub-body" data-section="sections/001.xhtml">`+
+			`<p class="ipub-speech" data-class="balloon shout" style="left:10%;top:10%"></p>`+
+			`</div>`,
+		w.String(),
+	)
+
+	pkg := &ast.Package{Resources: []ast.Resource{{
+		Href:      "styles/house.css",
+		MediaType: "text/css",
+		Open: func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(`.shout{font-weight:bold;position:fixed}`)), nil
+		},
+	}}}
+	pkg.AppendChild(pkg, s)
+
+	w.Reset()
+	if err := render.HTML(&w, s); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(
+		`<div class="ipub-body" data-section="sections/001.xhtml">`+
+			`<style>.ipub-body[data-section="sections/001.xhtml"] [data-class~="shout"]{font-weight:bold}`+"\n"+`</style>`+
+			`<p class="ipub-speech" data-class="balloon shout" style="left:10%;top:10%"></p>`+
+			`</div>`,
+		w.String(),
+	)
+
+	s.Stylesheets = []string{"styles/missing.css"}
+	if err := render.HTML(&w, s); err == nil {
+		t.Error("expected error for stylesheet missing from the package")
+	}
+}
@@ -0,0 +1,555 @@
+// Package style sanitizes the stylesheets of ipub packages, so the house styles
+// of a series, such as balloon fonts and caption colors, can be shown by readers
+// without letting them style anything outside of the section which links them.
+//
+// Only a safe subset of CSS is kept by [Sanitize]: style rules and font faces,
+// with properties which change how text and boxes look, but not where they are
+// placed nor what they load. All selectors are scoped to the element of the
+// section, and class selectors match the classes of nodes, see [ast.Node.Classes].
+package style
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+)
+
+// MediaType is the media type of stylesheet resources.
+const MediaType = "text/css"
+
+var ErrMissingStylesheet = errors.New("style: stylesheet not found in package resources")
+
+// ErrUnsafe is a part of a stylesheet which was removed by [Sanitize].
+type ErrUnsafe struct {
+	Line    int // Line of the removed part in the stylesheet, starting at 1
+	Message string
+}
+
+func (err ErrUnsafe) Error() string {
+	return fmt.Sprintf("style: %d: %s", err.Line, err.Message)
+}
+
+// Option changes how stylesheets are sanitized.
+type Option func(*sanitizer)
+
+// WithBase sets the href of the stylesheet inside its package, so the URLs of its
+// fonts are resolved to hrefs relative to the package root, the same as the ones
+// of other nodes. Without it, URLs are resolved as if the stylesheet were at the
+// package root.
+func WithBase(href string) Option {
+	return func(s *sanitizer) {
+		s.base = path.Dir(href)
+	}
+}
+
+// Sanitize returns the safe rules of the stylesheet src, scoped to the elements
+// inside of the one matched by the selector scope.
+//
+// Everything else is removed, and reported in the returned error, which joins an
+// [ErrUnsafe] for each removed part. The returned CSS is always safe to use, even
+// if the error is not nil.
+//
+// Selectors are prefixed by scope, and :scope matches the element of scope itself,
+// such as to set the gutter color of a section, and can't be followed by sibling
+// combinators (~ and +), which would match elements outside of it. Class selectors
+// match the classes of nodes, except the ones starting with "ipub-", which are the
+// classes of the elements rendered by ipub/render.
+func Sanitize(src, scope string, opts ...Option) (string, error) {
+	s := &sanitizer{src: stripComments(src), scope: scope, base: "."}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.sanitize()
+	return s.out.String(), errors.Join(s.errs...)
+}
+
+// Section returns the sanitized rules of all stylesheets of s, in order, scoped
+// to scope. The stylesheets are read from resources, and parts of them which are
+// not safe are removed without errors, they are reported by ipub/validate.
+func Section(s *ast.Section, resources []ast.Resource, scope string) (string, error) {
+	var b strings.Builder
+	for _, href := range s.Stylesheets {
+		i := slices.IndexFunc(resources, func(r ast.Resource) bool {
+			return r.Href == href
+		})
+		if i == -1 || resources[i].Open == nil {
+			return "", errors.Join(ErrMissingStylesheet, fmt.Errorf("stylesheet %q", href))
+		}
+
+		src, err := read(resources[i])
+		if err != nil {
+			return "", fmt.Errorf("style: failed to read stylesheet %q: %w", href, err)
+		}
+
+		css, _ := Sanitize(src, scope, WithBase(href))
+		b.WriteString(css)
+	}
+	return b.String(), nil
+}
+
+func read(r ast.Resource) (string, error) {
+	rc, err := r.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(rc)
+	return string(b), err
+}
+
+// AttrSelector returns a selector matching elements whose attribute name has the
+// given value, escaping it so it can be used as a scope and inside of HTML style
+// elements.
+func AttrSelector(name, value string) string {
+	var b strings.Builder
+	b.WriteString("[" + name + `="`)
+	for _, r := range value {
+		if r < 0x80 && !isNameRune(r) && r != ' ' && r != '.' && r != '/' {
+			fmt.Fprintf(&b, `\%x `, r)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	b.WriteString(`"]`)
+	return b.String()
+}
+
+// Properties which can be used in style rules. They change how text and boxes
+// look, but not where boxes are placed, and none of them accept URLs.
+var properties = []string{
+	"color", "opacity",
+	"background-color",
+
+	"font", "font-family", "font-size", "font-style", "font-weight",
+	"font-variant", "font-stretch", "font-kerning", "font-feature-settings",
+
+	"letter-spacing", "word-spacing", "line-height",
+	"text-align", "text-transform", "text-indent", "white-space",
+	"text-decoration", "text-decoration-line", "text-decoration-color", "text-decoration-style",
+	"-webkit-text-stroke", "-webkit-text-stroke-color", "-webkit-text-stroke-width", "paint-order",
+
+	"border", "border-color", "border-style", "border-width", "border-radius",
+	"border-top", "border-right", "border-bottom", "border-left",
+
+	"padding", "padding-top", "padding-right", "padding-bottom", "padding-left",
+}
+
+// Descriptors which can be used in font faces.
+var fontFaceDescriptors = []string{
+	"font-family", "src", "font-style", "font-weight", "font-stretch", "font-display", "unicode-range",
+}
+
+// Functions which can be used in values, besides url, local and format in the
+// sources of font faces.
+var functions = []string{"rgb", "rgba", "hsl", "hsla", "calc", "min", "max", "clamp"}
+
+type sanitizer struct {
+	src   string
+	pos   int
+	scope string
+	base  string
+
+	out  strings.Builder
+	errs []error
+}
+
+func (s *sanitizer) sanitize() {
+	for {
+		s.skipSpace()
+		if s.pos >= len(s.src) {
+			return
+		}
+
+		start := s.pos
+		if s.src[s.pos] == '@' {
+			s.atRule(start)
+			continue
+		}
+
+		prelude, ok := s.until("{")
+		if !ok {
+			s.remove(start, "rule has no declaration block")
+			s.skip()
+			continue
+		}
+		block, ok := s.block()
+		if !ok {
+			s.remove(start, "rule's declaration block is not closed")
+			return
+		} else if strings.Contains(block, "{") {
+			s.remove(start, "rule has nested blocks")
+			continue
+		}
+
+		sels, err := s.selectors(prelude)
+		if err != nil {
+			s.remove(start, "%s", err)
+			continue
+		}
+		decls := s.declarations(start, block, properties, false)
+		if len(decls) > 0 {
+			s.out.WriteString(strings.Join(sels, ",") + "{" + strings.Join(decls, ";") + "}\n")
+		}
+	}
+}
+
+// atRule sanitizes the at-rule at start. Only font faces are allowed, since they
+// can't change the style of elements by themselves.
+func (s *sanitizer) atRule(start int) {
+	prelude, ok := s.until("{;")
+	if !ok || s.src[s.pos-1] == ';' {
+		s.remove(start, "at-rule %q is not allowed", strings.Fields(prelude + " ")[0])
+		return
+	}
+	block, ok := s.block()
+	if !ok {
+		s.remove(start, "at-rule's block is not closed")
+		return
+	}
+
+	if name := strings.ToLower(strings.TrimSpace(prelude)); name != "@font-face" {
+		s.remove(start, "at-rule %q is not allowed", strings.Fields(name)[0])
+		return
+	} else if strings.Contains(block, "{") {
+		s.remove(start, "at-rule has nested blocks")
+		return
+	}
+
+	decls := s.declarations(start, block, fontFaceDescriptors, true)
+	if len(decls) > 0 {
+		s.out.WriteString("@font-face{" + strings.Join(decls, ";") + "}\n")
+	}
+}
+
+// selectors returns the selectors of a rule's prelude, scoped.
+func (s *sanitizer) selectors(prelude string) ([]string, error) {
+	sels := []string{}
+	for _, sel := range splitTopLevel(prelude, ',') {
+		sel = strings.Join(strings.Fields(sel), " ")
+		if sel == "" {
+			return nil, errors.New("rule has an empty selector")
+		}
+		if i := strings.IndexFunc(sel, func(r rune) bool {
+			return r < 0x80 && !isNameRune(r) && !strings.ContainsRune(` .#:>+~*[]="'|^$(),`, r)
+		}); i != -1 {
+			return nil, fmt.Errorf("selector %q has the character %q, which is not allowed", sel, sel[i])
+		}
+
+		sel, err := scopeSelector(classSelectors(sel), s.scope)
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+	}
+	return sels, nil
+}
+
+// classSelectors replaces the class selectors of sel by ones matching the classes
+// of nodes, which are rendered in the data-class attribute so they can't be used
+// to apply the classes of the site.
+func classSelectors(sel string) string {
+	var b strings.Builder
+	var quote byte
+	depth := 0
+	for i := 0; i < len(sel); i++ {
+		c := sel[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == '.' && depth == 0:
+			j := i + 1
+			for j < len(sel) && isNameRune(rune(sel[j])) {
+				j++
+			}
+			name := sel[i+1 : j]
+			if !strings.HasPrefix(name, "ipub-") {
+				b.WriteString(`[data-class~="` + name + `"]`)
+				i = j - 1
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// scopeSelector prefixes sel with scope, or replaces :scope by it. Sibling
+// combinators after :scope are not allowed, since they match elements outside of
+// the scope.
+func scopeSelector(sel, scope string) (string, error) {
+	rest, ok := strings.CutPrefix(sel, ":scope")
+	if !ok || (rest != "" && isNameRune(rune(rest[0]))) {
+		return scope + " " + sel, nil
+	}
+	if c := combinator(rest); c == '~' || c == '+' {
+		return "", fmt.Errorf("selector %q matches siblings of the scope", sel)
+	}
+	return scope + rest, nil
+}
+
+// combinator returns the first combinator of sel which is not inside of strings,
+// brackets or parentheses, or 0 if it has none. Descendant combinators are
+// returned as a space.
+func combinator(sel string) byte {
+	var quote byte
+	depth := 0
+	for i := 0; i < len(sel); i++ {
+		c := sel[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '(':
+			depth++
+		case c == ']' || c == ')':
+			depth--
+		case depth == 0 && (c == '>' || c == '~' || c == '+'):
+			return c
+		case depth == 0 && c == ' ':
+			// Spaces around other combinators are not descendant combinators.
+			if rest := strings.TrimLeft(sel[i:], " "); rest != "" && strings.IndexByte(">~+", rest[0]) != -1 {
+				return rest[0]
+			}
+			return c
+		}
+	}
+	return 0
+}
+
+var (
+	functionRegexp = regexp.MustCompile(`([-\w]+)\s*\(`)
+	urlRegexp      = regexp.MustCompile(`(?i)url\(\s*(?:"([^"]*)"|'([^']*)'|([^)'"\s]*))\s*\)`)
+)
+
+// declarations returns the allowed declarations of a block, as "name:value". If
+// fonts is true, the URLs of font sources are allowed.
+func (s *sanitizer) declarations(start int, block string, allowed []string, fonts bool) []string {
+	decls := []string{}
+	for _, d := range splitTopLevel(block, ';') {
+		if strings.TrimSpace(d) == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(d, ":")
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.Join(strings.Fields(value), " ")
+		if !ok || name == "" || value == "" {
+			s.remove(start, "declaration %q is malformed", strings.TrimSpace(d))
+			continue
+		}
+		if !slices.Contains(allowed, name) {
+			s.remove(start, "property %q is not allowed", name)
+			continue
+		}
+
+		v, err := s.value(value, fonts && name == "src")
+		if err != nil {
+			s.remove(start, "value of property %q %s", name, err)
+			continue
+		}
+		decls = append(decls, name+":"+v)
+	}
+	return decls
+}
+
+// value checks the value of a declaration, returning it with the URLs of fonts
+// resolved if urls is true.
+func (s *sanitizer) value(v string, urls bool) (string, error) {
+	if i := strings.IndexAny(v, "\\<>{}@!;"); i != -1 {
+		return "", fmt.Errorf("has the character %q, which is not allowed", v[i])
+	}
+
+	var err error
+	if urls {
+		v = urlRegexp.ReplaceAllStringFunc(v, func(u string) string {
+			m := urlRegexp.FindStringSubmatch(u)
+			href, uerr := s.resolve(m[1] + m[2] + m[3])
+			if uerr != nil {
+				err = uerr
+			}
+			return `url("` + href + `")`
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	for _, m := range functionRegexp.FindAllStringSubmatch(v, -1) {
+		f := strings.ToLower(m[1])
+		if urls && (f == "url" || f == "local" || f == "format") {
+			continue
+		}
+		if !slices.Contains(functions, f) {
+			return "", fmt.Errorf("uses the function %q, which is not allowed", f)
+		}
+	}
+
+	return v, nil
+}
+
+// resolve returns the href of a font URL relative to the package root. Only URLs
+// of files inside the package are allowed.
+func (s *sanitizer) resolve(u string) (string, error) {
+	if u == "" || strings.ContainsAny(u, `:"'`) || strings.HasPrefix(u, "/") {
+		return "", fmt.Errorf("has the URL %q, which is not a file of the package", u)
+	}
+	href := path.Join(s.base, u)
+	if href == ".." || strings.HasPrefix(href, "../") {
+		return "", fmt.Errorf("has the URL %q, which is not a file of the package", u)
+	}
+	return href, nil
+}
+
+// remove reports the part of the stylesheet at start as removed.
+func (s *sanitizer) remove(start int, format string, a ...any) {
+	s.errs = append(s.errs, ErrUnsafe{
+		Line:    strings.Count(s.src[:start], "\n") + 1,
+		Message: fmt.Sprintf(format, a...),
+	})
+}
+
+func (s *sanitizer) skipSpace() {
+	for s.pos < len(s.src) && strings.ContainsRune(" \t\r\n\f", rune(s.src[s.pos])) {
+		s.pos++
+	}
+}
+
+// until reads the source until one of the stop characters which is not inside of
+// a string, returning what was read before it. The stop character is consumed.
+func (s *sanitizer) until(stop string) (string, bool) {
+	start := s.pos
+	var quote byte
+	for ; s.pos < len(s.src); s.pos++ {
+		c := s.src[s.pos]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case strings.IndexByte(stop, c) != -1:
+			s.pos++
+			return s.src[start : s.pos-1], true
+		case c == '}':
+			// A block closed before it started, such as an extra brace.
+			s.pos++
+			return s.src[start : s.pos-1], false
+		}
+	}
+	return s.src[start:], false
+}
+
+// block reads the source until the end of the block which was just opened,
+// returning its content. Nested blocks are returned as part of it.
+func (s *sanitizer) block() (string, bool) {
+	start := s.pos
+	depth := 1
+	var quote byte
+	for ; s.pos < len(s.src); s.pos++ {
+		c := s.src[s.pos]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				s.pos++
+				return s.src[start : s.pos-1], true
+			}
+		}
+	}
+	return s.src[start:], false
+}
+
+// skip skips a block, if the last read stopped at the start of one.
+func (s *sanitizer) skip() {
+	if s.pos > 0 && s.pos <= len(s.src) && s.src[s.pos-1] == '{' {
+		_, _ = s.block()
+	}
+}
+
+// splitTopLevel splits v by sep, except where it is inside of strings, brackets
+// or parentheses.
+func splitTopLevel(v string, sep byte) []string {
+	parts := []string{}
+	var quote byte
+	depth, start := 0, 0
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			depth--
+		case c == sep && depth == 0:
+			parts = append(parts, v[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, v[start:])
+}
+
+// stripComments replaces the comments of src by spaces, keeping their new lines
+// so lines of errors stay the same. Strings are kept as they are, even if they
+// have "/*" in them.
+func stripComments(src string) string {
+	var b strings.Builder
+	var quote byte
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '/' && strings.HasPrefix(src[i+1:], "*"):
+			end := len(src)
+			if j := strings.Index(src[i+2:], "*/"); j != -1 {
+				end = i + 2 + j + 2
+			}
+			b.WriteString(" " + strings.Repeat("\n", strings.Count(src[i:end], "\n")))
+			i = end - 1
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func isNameRune(r rune) bool {
+	return r == '-' || r == '_' || r >= 0x80 ||
+		('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9')
+}
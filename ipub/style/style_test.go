@@ -0,0 +1,166 @@
+package style_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/style"
+	"forge.capytal.company/loreddev/x/tinyssert"
+)
+
+const house = `/* House style of the series */
+@font-face {
+	font-family: "Balloon";
+	src: url("../fonts/balloon.woff2") format("woff2"), local("Comic Neue");
+}
+
+:scope { background-color: #111; }
+
+.balloon, p.caption > span {
+	font-family: "Balloon", sans-serif;
+	font-size: clamp(12px, 2vw, 16px);
+	color: rgb(20 20 20);
+	padding: 4px 8px;
+}
+
+.ipub-panel { border: 2px solid black; }
+`
+
+func TestSanitize(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	css, err := style.Sanitize(house, "#chapter", style.WithBase("styles/house.css"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(`@font-face{font-family:"Balloon";src:url("fonts/balloon.woff2") format("woff2"), local("Comic Neue")}`+"\n"+
+		`#chapter{background-color:#111}`+"\n"+
+		`#chapter [data-class~="balloon"],#chapter p[data-class~="caption"] > span{`+
+		`font-family:"Balloon", sans-serif;font-size:clamp(12px, 2vw, 16px);color:rgb(20 20 20);padding:4px 8px}`+"\n"+
+		`#chapter .ipub-panel{border:2px solid black}`+"\n", css)
+}
+
+func TestSanitizeUnsafe(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	src := `@import url("https://example.com/site.css");
+body { color: red; position: fixed; }
+.caption {
+	color: blue !important;
+	background: url(https://example.com/track.png);
+	width: expression(alert(1));
+}
+@media print { .caption { color: black; } }
+a{b{color:red}}
+.balloon { color: </style><script>; }
+@font-face { font-family: "X"; src: url("/etc/fonts/x.woff"); }
+@font-face { font-family: "Y"; src: url("../../y.woff"); }
+`
+
+	css, err := style.Sanitize(src, ".scope")
+	assert.Equal(`.scope body{color:red}`+"\n"+
+		`@font-face{font-family:"X"}`+"\n"+
+		`@font-face{font-family:"Y"}`+"\n", css)
+
+	j, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("expected error to be joined errors, got %T", err)
+	}
+
+	lines := []int{}
+	for _, err := range j.Unwrap() {
+		var uerr style.ErrUnsafe
+		if !errors.As(err, &uerr) {
+			t.Fatalf("expected error to be style.ErrUnsafe, got %T", err)
+		}
+		if uerr.Message == "" {
+			t.Error("expected error to have a message")
+		}
+		lines = append(lines, uerr.Line)
+	}
+	assert.Equal([]int{1, 2, 3, 3, 3, 8, 9, 10, 11, 12}, lines)
+}
+
+func TestSection(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	open := func(s string) func() (io.ReadCloser, error) {
+		return func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(s)), nil
+		}
+	}
+	resources := []ast.Resource{
+		{Href: "styles/house.css", MediaType: style.MediaType, Open: open(`.balloon{font-family:"Balloon"}`)},
+		{Href: "styles/chapter.css", MediaType: style.MediaType, Open: open(`:scope{color:white;margin:0}`)},
+	}
+
+	s := &ast.Section{Stylesheets: []string{"styles/house.css", "styles/chapter.css"}}
+
+	scope := "body" + style.AttrSelector("data-section", `sections/"1".xhtml`)
+	css, err := style.Section(s, resources, scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(`body[data-section="sections/\22 1\22 .xhtml"] [data-class~="balloon"]{font-family:"Balloon"}`+"\n"+
+		`body[data-section="sections/\22 1\22 .xhtml"]{color:white}`+"\n", css)
+
+	s.Stylesheets = append(s.Stylesheets, "styles/missing.css")
+	if _, err := style.Section(s, resources, scope); !errors.Is(err, style.ErrMissingStylesheet) {
+		t.Errorf("expected error %q, got %v", style.ErrMissingStylesheet, err)
+	}
+}
+
+func TestSanitizeScope(t *testing.T) {
+	tests := map[string]struct {
+		src  string
+		want string
+	}{
+		"scope":              {`:scope{color:red}`, `#s{color:red}`},
+		"pseudo-class":       {`:scope:hover{color:red}`, `#s:hover{color:red}`},
+		"descendant":         {`:scope  p{color:red}`, `#s p{color:red}`},
+		"child":              {`:scope>p{color:red}`, `#s>p{color:red}`},
+		"child with spaces":  {`:scope > p ~ span{color:red}`, `#s > p ~ span{color:red}`},
+		"nth-child":          {`:scope > p:nth-child(2n+1){color:red}`, `#s > p:nth-child(2n+1){color:red}`},
+		"general sibling":    {`:scope ~ *{color:red}`, ``},
+		"adjacent sibling":   {`:scope+div{color:red}`, ``},
+		"sibling of pseudo":  {`:scope:hover ~ p{color:red}`, ``},
+		"sibling with class": {`:scope[data-x="a b"]+p{color:red}`, ``},
+		"not leading":        {`p ~ :scope{color:red}`, `#s p ~ :scope{color:red}`},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			css, err := style.Sanitize(test.src, "#s")
+			if test.want == "" {
+				if css != "" || err == nil {
+					t.Errorf("expected selector to be removed, got %q", css)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if css != test.want+"\n" {
+				t.Errorf("expected %q, got %q", test.want+"\n", css)
+			}
+		})
+	}
+}
+
+func TestSanitizeCommentsInStrings(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	src := `@font-face { font-family: "A /* B"; src: local("C */ D"); }
+/* comment with "quotes" */ .caption { color: red; }`
+
+	css, err := style.Sanitize(src, "#s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(`@font-face{font-family:"A /* B";src:local("C */ D")}`+"\n"+
+		`#s [data-class~="caption"]{color:red}`+"\n", css)
+}
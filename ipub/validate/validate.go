@@ -0,0 +1,598 @@
+// Package validate checks ipub packages and sections for problems, reporting all
+// of them in a single pass as diagnostics positioned in the source documents,
+// instead of stopping at the first error like decoding does.
+package validate
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/branch"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/style"
+)
+
+// Severity is how serious the problem reported by a [Diagnostic] is.
+type Severity int
+
+const (
+	// SeverityError is used for problems which make the document invalid, such as
+	// references to missing resources.
+	SeverityError Severity = iota + 1
+	// SeverityWarning is used for problems which don't make the document invalid,
+	// but that affect how it can be read, such as missing alt text.
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// Rule IDs of each check made by the validator.
+const (
+	RuleInvalidDocument = "invalid-document"
+	RuleMissingKind     = "missing-kind"
+	RuleUnknownKind     = "unknown-kind"
+	RuleImageSource     = "image-source"
+	RuleAudioSource     = "audio-source"
+	RuleAltText         = "alt-text"
+	RulePanelBounds     = "panel-bounds"
+	RuleShapeBounds     = "shape-bounds"
+	RuleDanglingLink    = "dangling-link"
+	RuleAnimation       = "animation-target"
+	RuleUnreachable     = "unreachable-section"
+	RuleDeadEnd         = "dead-end"
+	RuleDuplicateID     = "duplicate-id"
+	RuleStylesheet      = "stylesheet"
+	RuleUnsafeStyle     = "unsafe-style"
+	RuleUnsafeURL       = "unsafe-url"
+)
+
+// Diagnostic is a problem found in a document.
+type Diagnostic struct {
+	Severity Severity
+	Rule     string
+
+	// Href is the path of the section's document inside the package, if known.
+	Href string
+	// Path is the location of the node in the tree, see [ast.Path].
+	Path string
+	// Position is the location of the node in the section's document. It is not
+	// valid if the node was not decoded from a document.
+	Position ast.Position
+
+	Message string
+}
+
+// String formats the diagnostic as "href:line:column: severity: message [rule]",
+// omitting the location which is not known.
+func (d Diagnostic) String() string {
+	var b strings.Builder
+
+	loc := d.Href
+	if d.Position.IsValid() {
+		loc = fmt.Sprintf("%s:%s", loc, d.Position)
+	}
+	if loc != "" {
+		b.WriteString(loc)
+		b.WriteString(": ")
+	}
+
+	fmt.Fprintf(&b, "%s: %s [%s]", d.Severity, d.Message, d.Rule)
+	if d.Path != "" {
+		fmt.Fprintf(&b, " (%s)", d.Path)
+	}
+
+	return b.String()
+}
+
+// HasErrors reports whether any of the diagnostics has [SeverityError].
+func HasErrors(ds []Diagnostic) bool {
+	return slices.ContainsFunc(ds, func(d Diagnostic) bool {
+		return d.Severity == SeverityError
+	})
+}
+
+// Option changes how documents are checked.
+type Option func(*validator)
+
+// WithStrict reports nodes of unknown kinds as errors. By default they are only
+// warnings, since decoding keeps them as [ast.Unknown] nodes, so documents written
+// by newer versions of the format can still be read and written back.
+func WithStrict() Option {
+	return func(v *validator) { v.strict = true }
+}
+
+// Package checks all sections and navs of pkg. Links are checked against the package's
+// resources, and the paths of branching stories are resolved to find unreachable
+// sections and dead ends. Positions are only reported if the sections were decoded
+// from documents, as done by [ipub.Read].
+//
+// The stylesheet resources of the package are also checked, with the parts which
+// are removed when sanitizing them, see [style.Sanitize], reported as warnings
+// positioned in the stylesheet.
+func Package(pkg *ast.Package, opts ...Option) []Diagnostic {
+	v := newValidator(pkg.Resources, opts...)
+	v.pkg = pkg
+	v.tree(pkg)
+	v.branches()
+	for _, r := range pkg.Resources {
+		if r.MediaType == style.MediaType {
+			v.stylesheet(r)
+		}
+	}
+	return v.result()
+}
+
+// Section decodes and checks the section document in r. Unlike decoding, elements
+// with missing kinds are all reported, instead of just the first one. The content
+// of elements with unknown kinds is not checked, since it is not decoded.
+//
+// href is used to identify the document in the diagnostics. If resources is not
+// nil, links are checked against it. The returned error is only non-nil if r
+// could not be read.
+func Section(r io.Reader, href string, resources []ast.Resource, opts ...Option) ([]Diagnostic, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("validate: failed to read section: %w", err)
+	}
+
+	v := newValidator(resources, opts...)
+	v.href = href
+	v.scanned = true
+
+	if !v.scan(src) {
+		return v.result(), nil
+	}
+
+	d := xml.NewDecoder(bytes.NewReader(src))
+
+	s := &ast.Section{}
+	if err := d.Decode(s); err != nil {
+		line, col := d.InputPos()
+		v.report(SeverityError, RuleInvalidDocument, "section", ast.Position{Line: line, Column: col},
+			"unable to decode section: %s", err)
+		return v.result(), nil
+	}
+	s.SetHref(href)
+
+	v.tree(s)
+
+	return v.result(), nil
+}
+
+type validator struct {
+	href      string
+	resources map[string]bool // nil if links should not be checked
+	styles    map[string]bool // Hrefs of the stylesheet resources
+	pkg       *ast.Package    // nil if jumps to other sections should not be checked
+	strict    bool
+	// scanned is set if the kinds of the elements were already checked by scan.
+	scanned bool
+
+	diagnostics []Diagnostic
+}
+
+func newValidator(resources []ast.Resource, opts ...Option) *validator {
+	v := &validator{}
+	for _, opt := range opts {
+		opt(v)
+	}
+	if resources != nil {
+		v.resources = make(map[string]bool, len(resources))
+		v.styles = map[string]bool{}
+		for _, r := range resources {
+			v.resources[r.Href] = true
+			v.styles[r.Href] = r.MediaType == style.MediaType
+		}
+	}
+	return v
+}
+
+func (v *validator) report(s Severity, rule, path string, pos ast.Position, format string, a ...any) {
+	v.diagnostics = append(v.diagnostics, Diagnostic{
+		Severity: s,
+		Rule:     rule,
+		Href:     v.href,
+		Path:     path,
+		Position: pos,
+		Message:  fmt.Sprintf(format, a...),
+	})
+}
+
+func (v *validator) reportNode(s Severity, rule string, n ast.Node, format string, a ...any) {
+	href := v.href
+	sec, ok := n.(*ast.Section)
+	if !ok {
+		sec, ok = ast.FindAncestor(n, ast.KindSection).(*ast.Section)
+	}
+	if ok && sec.Href() != "" {
+		href = sec.Href()
+	}
+
+	v.diagnostics = append(v.diagnostics, Diagnostic{
+		Severity: s,
+		Rule:     rule,
+		Href:     href,
+		Path:     ast.Path(n),
+		Position: n.Position(),
+		Message:  fmt.Sprintf(format, a...),
+	})
+}
+
+// result returns the diagnostics sorted by their location. Diagnostics without a
+// position keep the order they were reported in.
+func (v *validator) result() []Diagnostic {
+	slices.SortStableFunc(v.diagnostics, func(a, b Diagnostic) int {
+		if c := cmp.Compare(a.Href, b.Href); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.Position.Line, b.Position.Line); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Position.Column, b.Position.Column)
+	})
+	return v.diagnostics
+}
+
+// scan reads the tokens of the section's document, checking the kind of every
+// element inside of the body, since decoding stops at the first invalid one. It
+// returns false if the document can't be decoded.
+func (v *validator) scan(src []byte) bool {
+	type element struct {
+		path   string
+		counts map[ast.NodeKind]int
+	}
+
+	d := xml.NewDecoder(bytes.NewReader(src))
+	stack := []element{}
+	inBody := false
+	ok := true
+
+	for {
+		line, col := d.InputPos()
+		pos := ast.Position{Line: line, Column: col}
+
+		t, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			return ok
+		} else if err != nil {
+			line, col := d.InputPos()
+			v.report(SeverityError, RuleInvalidDocument, "", ast.Position{Line: line, Column: col},
+				"malformed XML: %s", err)
+			return false
+		}
+
+		switch t := t.(type) {
+		case xml.StartElement:
+			if len(stack) == 0 {
+				stack = append(stack, element{path: string(ast.KindSection), counts: map[ast.NodeKind]int{}})
+				continue
+			}
+
+			if !inBody && len(stack) == 1 && t.Name.Local == "body" {
+				inBody = true
+			}
+			if !inBody {
+				stack = append(stack, element{counts: map[ast.NodeKind]int{}})
+				continue
+			}
+
+			p := stack[len(stack)-1]
+
+			k := ast.NodeKind("")
+			if i := slices.IndexFunc(t.Attr, func(a xml.Attr) bool {
+				return a.Name == ast.NodeKindAttrName
+			}); i != -1 {
+				k = ast.NodeKind(t.Attr[i].Value)
+			}
+			path := fmt.Sprintf("%s/%s[%d]", p.path, k, p.counts[k])
+			p.counts[k]++
+
+			if k == "" {
+				v.report(SeverityError, RuleMissingKind, path, pos,
+					"element %q has no %q attribute", attr.FmtXMLName(t.Name), attr.FmtXMLName(ast.NodeKindAttrName))
+				ok = false
+			} else if !k.Registered() {
+				v.report(v.unknownSeverity(), RuleUnknownKind, path, pos,
+					"element %q has unknown node kind %q", attr.FmtXMLName(t.Name), k)
+				ok = ok && !v.strict
+
+				// The content of unknown nodes is not decoded, so it isn't checked.
+				if err := d.Skip(); err != nil {
+					line, col := d.InputPos()
+					v.report(SeverityError, RuleInvalidDocument, "", ast.Position{Line: line, Column: col},
+						"malformed XML: %s", err)
+					return false
+				}
+				continue
+			}
+
+			stack = append(stack, element{path: path, counts: map[ast.NodeKind]int{}})
+
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+			if len(stack) == 1 {
+				inBody = false
+			}
+		}
+	}
+}
+
+// unknownSeverity returns the severity of nodes of unknown kinds.
+func (v *validator) unknownSeverity() Severity {
+	if v.strict {
+		return SeverityError
+	}
+	return SeverityWarning
+}
+
+func (v *validator) tree(root ast.Node) {
+	ids := map[string]bool{}
+	_ = ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		if _, ok := n.(*ast.Section); ok {
+			ids = map[string]bool{}
+		}
+		if id := n.ID(); id != "" {
+			if ids[id] {
+				v.reportNode(SeverityError, RuleDuplicateID, n, "ID %q is used by more than one node in the section", id)
+			}
+			ids[id] = true
+		}
+
+		switch n := n.(type) {
+		case *ast.Section:
+			v.section(n)
+		case *ast.Image:
+			v.image(n)
+		case *ast.Panel:
+			v.panel(n)
+		case ast.Interaction:
+			v.interaction(n)
+		case *ast.Choice:
+			v.choice(n)
+		case *ast.NavEntry:
+			v.navEntry(n)
+		case *ast.Animation:
+			if n.Target() == nil {
+				v.reportNode(SeverityError, RuleAnimation, n, "animation is not inside of a panel or layer")
+			}
+		case *ast.Soundtrack:
+			v.audio(n, n.AudioClip)
+		case *ast.Sound:
+			v.audio(n, n.AudioClip)
+		case *ast.Unknown:
+			if !v.scanned {
+				v.reportNode(v.unknownSeverity(), RuleUnknownKind, n,
+					"element %q has unknown node kind %q", attr.FmtXMLName(n.Name()), n.Kind())
+			}
+		}
+
+		return ast.WalkContinue, nil
+	})
+}
+
+// section checks if the stylesheets of n are stylesheet resources of the package.
+func (v *validator) section(n *ast.Section) {
+	if v.resources == nil {
+		return
+	}
+	for _, href := range n.Stylesheets {
+		if !v.resources[href] {
+			v.reportNode(SeverityError, RuleStylesheet, n, "stylesheet %q is not a resource of the package", href)
+		} else if !v.styles[href] {
+			v.reportNode(SeverityError, RuleStylesheet, n, "stylesheet %q is not a resource of type %q", href, style.MediaType)
+		}
+	}
+}
+
+// stylesheet reports the parts of the stylesheet r which are not safe, and so
+// are removed when it is rendered.
+func (v *validator) stylesheet(r ast.Resource) {
+	if r.Open == nil {
+		return
+	}
+
+	src, err := readResource(r)
+	if err != nil {
+		v.diagnostics = append(v.diagnostics, Diagnostic{
+			Severity: SeverityError,
+			Rule:     RuleStylesheet,
+			Href:     r.Href,
+			Message:  fmt.Sprintf("unable to read stylesheet: %s", err),
+		})
+		return
+	}
+
+	// The scope doesn't change what is removed.
+	_, err = style.Sanitize(string(src), ":root", style.WithBase(r.Href))
+	if err == nil {
+		return
+	}
+	errs := []error{err}
+	if j, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = j.Unwrap()
+	}
+	for _, err := range errs {
+		var uerr style.ErrUnsafe
+		if !errors.As(err, &uerr) {
+			continue
+		}
+		v.diagnostics = append(v.diagnostics, Diagnostic{
+			Severity: SeverityWarning,
+			Rule:     RuleUnsafeStyle,
+			Href:     r.Href,
+			Position: ast.Position{Line: uerr.Line},
+			Message:  fmt.Sprintf("%s, it is removed when rendered", uerr.Message),
+		})
+	}
+}
+
+func readResource(r ast.Resource) ([]byte, error) {
+	rc, err := r.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func (v *validator) image(n *ast.Image) {
+	if n.Source() == "" {
+		v.reportNode(SeverityError, RuleImageSource, n, "image has no source")
+	} else {
+		v.link(n, n.Source())
+	}
+
+	if n.Alt() == "" && !n.Decorative() {
+		v.reportNode(SeverityWarning, RuleAltText, n, "image has no alt text and is not marked as decorative")
+	}
+}
+
+func (v *validator) audio(n ast.Node, clip ast.AudioClip) {
+	if clip.Source() == "" {
+		v.reportNode(SeverityError, RuleAudioSource, n, "audio has no source")
+	} else {
+		v.link(n, clip.Source())
+	}
+}
+
+func (v *validator) panel(n *ast.Panel) {
+	b := n.Bounds()
+	if b.Empty() {
+		v.reportNode(SeverityError, RulePanelBounds, n, "panel has no area")
+	} else if !b.In(ast.PageRect) {
+		v.reportNode(SeverityError, RulePanelBounds, n,
+			"panel bounds (%g, %g, %g, %g) are outside of the page", b.X, b.Y, b.Width, b.Height)
+	}
+}
+
+func (v *validator) interaction(n ast.Interaction) {
+	if s := n.Shape(); s == nil {
+		v.reportNode(SeverityError, RuleShapeBounds, n, "interaction has no shape")
+	} else if b := s.Bounds(); !b.In(ast.PageRect) {
+		v.reportNode(SeverityError, RuleShapeBounds, n,
+			"interaction bounds (%g, %g, %g, %g) are outside of the page", b.X, b.Y, b.Width, b.Height)
+	}
+
+	switch n := n.(type) {
+	case *ast.Link:
+		if n.Href() == "" {
+			v.reportNode(SeverityError, RuleDanglingLink, n, "link has no href")
+		} else {
+			v.link(n, n.Href())
+		}
+
+	case *ast.Jump:
+		// Without the package, only jumps inside the same section can be checked.
+		if v.pkg == nil && n.Section() != "" && n.Section() != v.href {
+			return
+		}
+		if n.Target(v.pkg) == nil {
+			v.reportNode(SeverityError, RuleDanglingLink, n,
+				"jump target %q in section %q does not exist", n.Path(), n.Section())
+		}
+
+	case *ast.Reveal:
+		if n.Target() == nil {
+			v.reportNode(SeverityError, RuleDanglingLink, n, "layer %q does not exist in the section", n.Layer())
+		}
+	}
+}
+
+func (v *validator) choice(n *ast.Choice) {
+	if n.Target() == "" {
+		v.reportNode(SeverityError, RuleDanglingLink, n, "choice has no target")
+		return
+	}
+	// Without the package, there are no other sections to check the target against.
+	if v.pkg == nil || n.Target() == v.href {
+		return
+	}
+	if !slices.ContainsFunc(v.pkg.Sections(), func(s *ast.Section) bool {
+		return s.Href() == n.Target()
+	}) {
+		v.reportNode(SeverityError, RuleDanglingLink, n, "choice target %q is not a section of the package", n.Target())
+	}
+}
+
+func (v *validator) navEntry(n *ast.NavEntry) {
+	if n.Section() == "" {
+		v.reportNode(SeverityError, RuleDanglingLink, n, "nav entry %q has no target section", n.Title())
+		return
+	}
+	// Navs are only part of packages, without one there is nothing to check.
+	if v.pkg == nil {
+		return
+	}
+	if n.Target(v.pkg) == nil {
+		v.reportNode(SeverityError, RuleDanglingLink, n,
+			"nav entry %q target %q in section %q does not exist", n.Title(), n.Path(), n.Section())
+	}
+}
+
+// branches reports the sections of the package which the reader can't reach or
+// gets stuck in. Stories with too many states to be resolved are not checked.
+// Choices to sections which don't exist are already reported as dangling links,
+// so their sections are not reported as dead ends, see [branch.DeadEnd].
+func (v *validator) branches() {
+	r, err := branch.Resolve(v.pkg)
+	if err != nil {
+		return
+	}
+
+	for _, s := range r.Unreachable {
+		v.reportNode(SeverityWarning, RuleUnreachable, s, "section %q can't be reached by the reader", s.Href())
+	}
+	for _, d := range r.DeadEnds {
+		state := d.State.Key()
+		if state == "" {
+			state = "the initial state"
+		}
+		v.reportNode(SeverityWarning, RuleDeadEnd, d.Section,
+			"section %q has no choices which can be taken with %s and is not marked as an ending", d.Section.Href(), state)
+	}
+}
+
+// link checks if href is allowed in documents, see [attr.URL.Allowed], and if
+// it points to a resource of the package when it is internal. External links,
+// with a scheme or host, and links to fragments of the same document are not
+// checked against the resources.
+func (v *validator) link(n ast.Node, href string) {
+	u, err := url.Parse(href)
+	if err != nil {
+		v.reportNode(SeverityError, RuleDanglingLink, n, "link %q is not a valid URL: %s", href, err)
+		return
+	}
+	if !attr.URL(href).Allowed() {
+		v.reportNode(SeverityError, RuleUnsafeURL, n,
+			"link %q is not a relative path or an http, https or mailto URL", href)
+		return
+	}
+	if v.resources == nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return
+	}
+
+	if !v.resources[u.Path] {
+		v.reportNode(SeverityError, RuleDanglingLink, n, "link %q does not point to a resource of the package", href)
+	}
+}
@@ -0,0 +1,435 @@
+package validate_test
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/validate"
+	"forge.capytal.company/loreddev/x/tinyssert"
+)
+
+func TestSection(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	s := `<html xmlns="http://www.w3.org/1999/xhtml">
+<body data-ipub-element="body">
+	<section data-ipub-element="content">
+		<img data-ipub-element="image" src="images/001.png" alt="A page"/>
+		<img data-ipub-element="image" alt="Another page"/>
+		<div data-ipub-element="panel" data-ipub-order="1" data-ipub-bounds="50 50 60 10"></div>
+		<div data-ipub-element="panel" data-ipub-order="2" data-ipub-polygon="0,0 100,0 100,100"></div>
+	</section>
+	<section data-ipub-element="content">
+		<img data-ipub-element="image" src="images/002.png"/>
+		<img data-ipub-element="image" src="https://example.com/003.png" alt="External"/>
+		<img data-ipub-element="image" src="images/001.png" data-ipub-decorative="true"/>
+		<audio data-ipub-element="sound"></audio>
+	</section>
+</body>
+</html>`
+
+	ds, err := validate.Section(strings.NewReader(s), "sections/001.xhtml", []ast.Resource{
+		{Href: "images/001.png"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal([]validate.Diagnostic{
+		{
+			Severity: validate.SeverityError,
+			Rule:     validate.RuleImageSource,
+			Href:     "sections/001.xhtml",
+			Path:     "section/body[0]/content[0]/image[1]",
+			Position: ast.Position{Line: 5, Column: 3},
+			Message:  "image has no source",
+		},
+		{
+			Severity: validate.SeverityError,
+			Rule:     validate.RulePanelBounds,
+			Href:     "sections/001.xhtml",
+			Path:     "section/body[0]/content[0]/panel[0]",
+			Position: ast.Position{Line: 6, Column: 3},
+			Message:  "panel bounds (50, 50, 60, 10) are outside of the page",
+		},
+		{
+			Severity: validate.SeverityError,
+			Rule:     validate.RuleDanglingLink,
+			Href:     "sections/001.xhtml",
+			Path:     "section/body[0]/content[1]/image[0]",
+			Position: ast.Position{Line: 10, Column: 3},
+			Message:  `link "images/002.png" does not point to a resource of the package`,
+		},
+		{
+			Severity: validate.SeverityWarning,
+			Rule:     validate.RuleAltText,
+			Href:     "sections/001.xhtml",
+			Path:     "section/body[0]/content[1]/image[0]",
+			Position: ast.Position{Line: 10, Column: 3},
+			Message:  "image has no alt text and is not marked as decorative",
+		},
+		{
+			Severity: validate.SeverityError,
+			Rule:     validate.RuleAudioSource,
+			Href:     "sections/001.xhtml",
+			Path:     "section/body[0]/content[1]/sound[0]",
+			Position: ast.Position{Line: 13, Column: 3},
+			Message:  "audio has no source",
+		},
+	}, ds)
+	assert.Equal(true, validate.HasErrors(ds))
+	assert.Equal(
+		`sections/001.xhtml:5:3: error: image has no source [image-source] (section/body[0]/content[0]/image[1])`,
+		ds[0].String(),
+	)
+}
+
+func TestSectionKinds(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	s := `<html xmlns="http://www.w3.org/1999/xhtml">
+<head><title>Chapter</title></head>
+<body data-ipub-element="body">
+	<section data-ipub-element="content">
+		<div data-ipub-element="sticker"></div>
+		<div></div>
+	</section>
+	<section data-ipub-element="gallery"></section>
+</body>
+</html>`
+
+	ds, err := validate.Section(strings.NewReader(s), "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules := []string{}
+	paths := []string{}
+	positions := []ast.Position{}
+	for _, d := range ds {
+		rules = append(rules, d.Rule)
+		paths = append(paths, d.Path)
+		positions = append(positions, d.Position)
+	}
+
+	assert.Equal([]string{
+		validate.RuleUnknownKind,
+		validate.RuleMissingKind,
+		validate.RuleUnknownKind,
+	}, rules)
+	assert.Equal([]string{
+		"section/body[0]/content[0]/sticker[0]",
+		"section/body[0]/content[0]/[0]",
+		"section/body[0]/gallery[0]",
+	}, paths)
+	assert.Equal([]ast.Position{{Line: 5, Column: 3}, {Line: 6, Column: 3}, {Line: 8, Column: 2}}, positions)
+	assert.Equal(validate.SeverityWarning, ds[0].Severity)
+	assert.Equal(validate.SeverityError, ds[1].Severity)
+
+	ds, err = validate.Section(strings.NewReader(s), "", nil, validate.WithStrict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(3, len(ds))
+	assert.Equal(validate.SeverityError, ds[0].Severity)
+	assert.Equal(validate.SeverityError, ds[2].Severity)
+}
+
+func TestSectionUnknownKinds(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	s := `<html xmlns="http://www.w3.org/1999/xhtml">
+<body data-ipub-element="body">
+	<section data-ipub-element="content">
+		<div data-ipub-element="sticker">
+			<img src="stickers/cat.png"/>
+		</div>
+		<img data-ipub-element="image" src="images/001.png"/>
+	</section>
+</body>
+</html>`
+
+	ds, err := validate.Section(strings.NewReader(s), "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules := []string{}
+	for _, d := range ds {
+		rules = append(rules, d.Rule)
+	}
+	assert.Equal([]string{validate.RuleUnknownKind, validate.RuleAltText}, rules)
+	assert.Equal(false, validate.HasErrors(ds))
+
+	ds, err = validate.Section(strings.NewReader(s), "", nil, validate.WithStrict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(1, len(ds))
+	assert.Equal(true, validate.HasErrors(ds))
+
+	var sec ast.Section
+	if err := xml.Unmarshal([]byte(s), &sec); err != nil {
+		t.Fatal(err)
+	}
+	sec.SetHref("sections/001.xhtml")
+	pkg := &ast.Package{}
+	pkg.AppendChild(pkg, &sec)
+
+	ds = validate.Package(pkg, validate.WithStrict())
+	assert.Equal(2, len(ds))
+	assert.Equal(validate.RuleUnknownKind, ds[0].Rule)
+	assert.Equal(validate.SeverityError, ds[0].Severity)
+	assert.Equal("package/section[0]/body[0]/content[0]/sticker[0]", ds[0].Path)
+	assert.Equal(ast.Position{Line: 4, Column: 3}, ds[0].Position)
+}
+
+func TestSectionMalformed(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	ds, err := validate.Section(strings.NewReader(`<html><body data-ipub-element="body"></html>`), "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(1, len(ds))
+	assert.Equal(validate.RuleInvalidDocument, ds[0].Rule)
+	assert.Equal(validate.SeverityError, ds[0].Severity)
+}
+
+func TestPackage(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	i := &ast.Image{}
+	i.SetSource("images/missing.png")
+	i.SetAlt("A page")
+
+	c := &ast.Content{}
+	c.AppendChild(c, i)
+	b := &ast.Body{}
+	b.AppendChild(b, c)
+	s := &ast.Section{}
+	s.SetBody(b)
+	s.SetHref("sections/001.xhtml")
+
+	pkg := &ast.Package{Resources: []ast.Resource{{Href: "images/001.png"}}}
+	pkg.AppendChild(pkg, s)
+
+	ds := validate.Package(pkg)
+	assert.Equal(1, len(ds))
+	assert.Equal(validate.RuleDanglingLink, ds[0].Rule)
+	assert.Equal("sections/001.xhtml", ds[0].Href)
+	assert.Equal("package/section[0]/body[0]/content[0]/image[0]", ds[0].Path)
+	assert.Equal(false, ds[0].Position.IsValid())
+}
+
+func TestPackageLinks(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	c := &ast.Content{}
+	i := &ast.Image{}
+	i.SetSource("data:image/png;base64,AAAA")
+	i.SetDecorative(true)
+	c.AppendChild(c, i)
+	for _, href := range []string{
+		"https://example.com", "images/001.png", "images/missing.png", "javascript:alert(document.cookie)",
+	} {
+		l := &ast.Link{}
+		l.SetHref(href)
+		l.SetShape(ast.Point{X: 10, Y: 10})
+		c.AppendChild(c, l)
+	}
+	b := &ast.Body{}
+	b.AppendChild(b, c)
+	s := &ast.Section{}
+	s.SetBody(b)
+	s.SetHref("sections/001.xhtml")
+
+	pkg := &ast.Package{Resources: []ast.Resource{{Href: "images/001.png"}}}
+	pkg.AppendChild(pkg, s)
+
+	rules := []string{}
+	for _, d := range validate.Package(pkg) {
+		rules = append(rules, d.Path+" "+d.Rule)
+	}
+	assert.Equal([]string{
+		"package/section[0]/body[0]/content[0]/image[0] unsafe-url",
+		"package/section[0]/body[0]/content[0]/link[2] dangling-link",
+		"package/section[0]/body[0]/content[0]/link[3] unsafe-url",
+	}, rules)
+}
+
+func TestPackageNav(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	s := &ast.Section{Title: "Chapter 1"}
+	s.SetHref("sections/001.xhtml")
+	s.SetBody(&ast.Body{})
+
+	pkg := &ast.Package{}
+	pkg.AppendChild(pkg, s)
+
+	nav := ast.GenerateTOC(pkg)
+	missing := &ast.NavEntry{}
+	missing.SetTitle("Chapter 2")
+	missing.SetSection("sections/002.xhtml")
+	nav.AppendChild(nav, missing)
+	pkg.AppendChild(pkg, nav)
+
+	ds := validate.Package(pkg)
+	assert.Equal(1, len(ds))
+	assert.Equal(validate.RuleDanglingLink, ds[0].Rule)
+	assert.Equal("package/nav[0]/nav-entry[1]", ds[0].Path)
+	assert.Equal(`nav entry "Chapter 2" target "" in section "sections/002.xhtml" does not exist`, ds[0].Message)
+}
+
+func TestSectionDuplicateIDs(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	s := `<html xmlns="http://www.w3.org/1999/xhtml">
+<body data-ipub-element="body">
+	<section data-ipub-element="content" id="page-1">
+		<div data-ipub-element="panel" id="panel-1" data-ipub-bounds="0 0 100 50"></div>
+		<div data-ipub-element="panel" id="panel-1" data-ipub-bounds="0 50 100 50"></div>
+	</section>
+</body>
+</html>`
+
+	ds, err := validate.Section(strings.NewReader(s), "sections/001.xhtml", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(1, len(ds))
+	assert.Equal(validate.RuleDuplicateID, ds[0].Rule)
+	assert.Equal("section/body[0]/content[0]/panel[1]", ds[0].Path)
+	assert.Equal(ast.Position{Line: 5, Column: 3}, ds[0].Position)
+}
+
+func TestSectionInteractions(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	s := `<html xmlns="http://www.w3.org/1999/xhtml">
+<body data-ipub-element="body">
+	<section data-ipub-element="content">
+		<div data-ipub-element="panel" data-ipub-bounds="0 0 100 100"></div>
+		<a data-ipub-element="jump" data-ipub-point="1,1" data-ipub-path="body[0]/content[0]/panel[0]"></a>
+		<a data-ipub-element="jump" data-ipub-point="1,1" data-ipub-path="body[0]/content[0]/panel[1]"></a>
+		<a data-ipub-element="jump" data-ipub-point="1,1" data-ipub-section="sections/002.xhtml"></a>
+		<button data-ipub-element="reveal" data-ipub-bounds="90 90 20 20" data-ipub-layer="secret"></button>
+		<a data-ipub-element="link"></a>
+	</section>
+</body>
+</html>`
+
+	ds, err := validate.Section(strings.NewReader(s), "sections/001.xhtml", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := []string{}
+	for _, d := range ds {
+		messages = append(messages, d.Message)
+	}
+
+	assert.Equal([]string{
+		`jump target "body[0]/content[0]/panel[1]" in section "" does not exist`,
+		"interaction bounds (90, 90, 20, 20) are outside of the page",
+		`layer "secret" does not exist in the section`,
+		"interaction has no shape",
+		"link has no href",
+	}, messages)
+}
+
+func TestPackageBranches(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	pkg := &ast.Package{}
+	for _, href := range []string{"sections/001.xhtml", "sections/002.xhtml", "sections/003.xhtml"} {
+		s := &ast.Section{}
+		s.SetHref(href)
+		s.SetBody(&ast.Body{})
+		pkg.AppendChild(pkg, s)
+	}
+	ss := pkg.Sections()
+
+	c := &ast.Choice{}
+	c.SetTarget("sections/004.xhtml")
+	ss[0].Body().AppendChild(ss[0].Body(), c)
+	ss[1].Ending = true
+
+	messages := []string{}
+	for _, d := range validate.Package(pkg) {
+		messages = append(messages, d.String())
+	}
+
+	assert.Equal([]string{
+		`sections/001.xhtml: error: choice target "sections/004.xhtml" is not a section of the package [dangling-link] ` +
+			`(package/section[0]/body[0]/choice[0])`,
+		`sections/002.xhtml: warning: section "sections/002.xhtml" can't be reached by the reader ` +
+			`[unreachable-section] (package/section[1])`,
+		`sections/003.xhtml: warning: section "sections/003.xhtml" can't be reached by the reader ` +
+			`[unreachable-section] (package/section[2])`,
+	}, messages)
+}
+
+func TestSectionAnimations(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	s := `<html xmlns="http://www.w3.org/1999/xhtml">
+<body data-ipub-element="body">
+	<section data-ipub-element="content">
+		<div data-ipub-element="panel" data-ipub-bounds="0 0 100 100">
+			<div data-ipub-element="animation" data-ipub-duration="1s"></div>
+		</div>
+		<div data-ipub-element="animation" data-ipub-duration="1s"></div>
+	</section>
+</body>
+</html>`
+
+	ds, err := validate.Section(strings.NewReader(s), "sections/001.xhtml", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(1, len(ds))
+	assert.Equal(validate.RuleAnimation, ds[0].Rule)
+	assert.Equal("section/body[0]/content[0]/animation[0]", ds[0].Path)
+	assert.Equal(7, ds[0].Position.Line)
+}
+
+func TestPackageStylesheets(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	s := &ast.Section{Stylesheets: []string{"styles/house.css", "images/001.png", "styles/missing.css"}}
+	s.SetHref("sections/001.xhtml")
+
+	css := "/* House style */\n.balloon {\n\tfont-family: serif;\n\tposition: fixed;\n}\n@import url(\"x.css\");\n"
+	pkg := &ast.Package{Resources: []ast.Resource{
+		{Href: "images/001.png", MediaType: "image/png"},
+		{Href: "styles/house.css", MediaType: "text/css", Open: func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(css)), nil
+		}},
+	}}
+	pkg.AppendChild(pkg, s)
+
+	ds := validate.Package(pkg)
+	assert.Equal(4, len(ds))
+
+	assert.Equal(validate.RuleStylesheet, ds[0].Rule)
+	assert.Equal(validate.SeverityError, ds[0].Severity)
+	assert.Equal("sections/001.xhtml", ds[0].Href)
+	assert.Equal(`stylesheet "images/001.png" is not a resource of type "text/css"`, ds[0].Message)
+	assert.Equal(validate.RuleStylesheet, ds[1].Rule)
+	assert.Equal(`stylesheet "styles/missing.css" is not a resource of the package`, ds[1].Message)
+
+	assert.Equal(validate.RuleUnsafeStyle, ds[2].Rule)
+	assert.Equal(validate.SeverityWarning, ds[2].Severity)
+	assert.Equal("styles/house.css", ds[2].Href)
+	assert.Equal(2, ds[2].Position.Line)
+	assert.Equal(validate.RuleUnsafeStyle, ds[3].Rule)
+	assert.Equal(6, ds[3].Position.Line)
+}
@@ -0,0 +1,162 @@
+package ipub
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/element/attr"
+)
+
+// Write encodes pkg as an ipub package into w.
+//
+// The output is deterministic: the same tree always produces the same bytes, so
+// a package read with [Read] and written back does not change between round-trips.
+// Sections without a [ast.Section.Href] are given one based on their position
+// in the spine. All [ast.Nav] children of pkg are written to the navigation
+// document.
+//
+// The hrefs of sections and resources must be clean relative paths inside of the
+// package, and must not be the ones of the mimetype file nor the package document,
+// otherwise [ErrInvalidHref] or [ErrDuplicatedHref] is returned.
+func Write(w io.Writer, pkg *ast.Package) error {
+	z := zip.NewWriter(w)
+
+	m, err := z.CreateHeader(&zip.FileHeader{Name: mimetypeFile, Method: zip.Store})
+	if err != nil {
+		return fmt.Errorf("ipub: failed to create mimetype file: %w", err)
+	}
+	if _, err := io.WriteString(m, MimeType); err != nil {
+		return fmt.Errorf("ipub: failed to write mimetype file: %w", err)
+	}
+
+	sections := pkg.Sections()
+
+	doc := packageDocument{
+		Version:  Version,
+		Metadata: pkg.Metadata,
+		Manifest: make([]manifestItem, 0, len(sections)+len(pkg.Resources)),
+		Spine: spine{
+			Progression: attr.Enum[ast.Progression]{Value: pkg.Layout.Progression},
+			Spread:      attr.Enum[ast.SpreadMode]{Value: pkg.Layout.Spread},
+			Items:       make([]spineItem, 0, len(sections)),
+		},
+	}
+	hrefs := map[string]bool{mimetypeFile: true, packageFile: true}
+
+	sectionHrefs := make([]string, len(sections))
+	for i, s := range sections {
+		href := s.Href()
+		if href == "" {
+			href = fmt.Sprintf("sections/section-%03d.xhtml", i+1)
+		}
+		if !ValidHref(href) {
+			return errors.Join(ErrInvalidHref, fmt.Errorf("section href %q", href))
+		}
+		if hrefs[href] {
+			return errors.Join(ErrDuplicatedHref, fmt.Errorf("section href %q", href))
+		}
+		hrefs[href] = true
+		sectionHrefs[i] = href
+
+		doc.Manifest = append(doc.Manifest, manifestItem{Href: href, MediaType: SectionMimeType})
+		doc.Spine.Items = append(doc.Spine.Items, spineItem{Href: href})
+	}
+
+	var navs navDocument
+	for c := pkg.FirstChild(); c != nil; c = c.NextSibling() {
+		if n, ok := c.(*ast.Nav); ok {
+			navs.Navs = append(navs.Navs, n)
+		}
+	}
+	if len(navs.Navs) > 0 {
+		if hrefs[navFile] {
+			return errors.Join(ErrDuplicatedHref, fmt.Errorf("navigation document href %q", navFile))
+		}
+		hrefs[navFile] = true
+		doc.Manifest = append(doc.Manifest, manifestItem{
+			Href:       navFile,
+			MediaType:  SectionMimeType,
+			Properties: navProperty,
+		})
+	}
+
+	for _, r := range pkg.Resources {
+		if !ValidHref(r.Href) {
+			return errors.Join(ErrInvalidHref, fmt.Errorf("resource href %q", r.Href))
+		}
+		if hrefs[r.Href] {
+			return errors.Join(ErrDuplicatedHref, fmt.Errorf("resource href %q", r.Href))
+		}
+		if r.Open == nil {
+			return errors.Join(ErrMissingResourceData, fmt.Errorf("resource href %q", r.Href))
+		}
+		hrefs[r.Href] = true
+
+		doc.Manifest = append(doc.Manifest, manifestItem{Href: r.Href, MediaType: r.MediaType})
+	}
+
+	if err := encodeFile(z, packageFile, doc); err != nil {
+		return fmt.Errorf("ipub: failed to write package document: %w", err)
+	}
+
+	for i, s := range sections {
+		if err := encodeFile(z, sectionHrefs[i], s); err != nil {
+			return fmt.Errorf("ipub: failed to write section %q: %w", sectionHrefs[i], err)
+		}
+	}
+
+	if len(navs.Navs) > 0 {
+		if err := encodeFile(z, navFile, navs); err != nil {
+			return fmt.Errorf("ipub: failed to write navigation document: %w", err)
+		}
+	}
+
+	for _, r := range pkg.Resources {
+		if err := copyResource(z, r); err != nil {
+			return fmt.Errorf("ipub: failed to write resource %q: %w", r.Href, err)
+		}
+	}
+
+	if err := z.Close(); err != nil {
+		return fmt.Errorf("ipub: failed to close zip container: %w", err)
+	}
+
+	return nil
+}
+
+func encodeFile(z *zip.Writer, name string, v any) error {
+	f, err := z.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(f, xml.Header); err != nil {
+		return err
+	}
+
+	e := xml.NewEncoder(f)
+	if err := e.Encode(v); err != nil {
+		return err
+	}
+	return e.Close()
+}
+
+func copyResource(z *zip.Writer, r ast.Resource) error {
+	f, err := z.CreateHeader(&zip.FileHeader{Name: r.Href, Method: zip.Deflate})
+	if err != nil {
+		return err
+	}
+
+	rc, err := r.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	_, err = io.Copy(f, rc)
+	return err
+}
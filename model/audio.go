@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audio is a sound file of a project, such as a soundtrack or a sound effect,
+// which is referenced by the audio nodes of the project's sections.
+type Audio struct {
+	ID          uuid.UUID
+	ProjectID   uuid.UUID
+	Name        string // Name shown to editors, usually the name of the uploaded file
+	ContentType string // MIME type of the audio file, must not be empty
+	DateCreated time.Time
+	DateUpdated time.Time
+}
+
+var _ Model = (*Audio)(nil)
+
+func (a Audio) Validate() error {
+	errs := []error{}
+	if len(a.ID) == 0 {
+		errs = append(errs, ErrZeroValue{Name: "ID"})
+	}
+	if len(a.ProjectID) == 0 {
+		errs = append(errs, ErrZeroValue{Name: "ProjectID"})
+	}
+	if a.Name == "" {
+		errs = append(errs, ErrZeroValue{Name: "Name"})
+	}
+	if a.ContentType == "" {
+		errs = append(errs, ErrZeroValue{Name: "ContentType"})
+	}
+	if a.DateCreated.IsZero() {
+		errs = append(errs, ErrZeroValue{Name: "DateCreated"})
+	}
+	if a.DateUpdated.IsZero() {
+		errs = append(errs, ErrZeroValue{Name: "DateUpdated"})
+	}
+
+	if len(errs) > 0 {
+		return ErrInvalidModel{Name: "Audio", Errors: errs}
+	}
+
+	return nil
+}
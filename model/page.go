@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Page struct {
+	ID          uuid.UUID
+	ProjectID   uuid.UUID
+	Position    int    // Reading order of the page inside the project, starting at 0
+	ContentType string // MIME type of the page's image, must not be empty
+	Spread      bool   // If the page's image is a two-page spread, which is always shown alone
+	DateCreated time.Time
+	DateUpdated time.Time
+}
+
+var _ Model = (*Page)(nil)
+
+func (p Page) Validate() error {
+	errs := []error{}
+	if len(p.ID) == 0 {
+		errs = append(errs, ErrZeroValue{Name: "ID"})
+	}
+	if len(p.ProjectID) == 0 {
+		errs = append(errs, ErrZeroValue{Name: "ProjectID"})
+	}
+	if p.Position < 0 {
+		errs = append(errs, ErrInvalidValue{Name: "Position", Actual: p.Position})
+	}
+	if p.ContentType == "" {
+		errs = append(errs, ErrZeroValue{Name: "ContentType"})
+	}
+	if p.DateCreated.IsZero() {
+		errs = append(errs, ErrZeroValue{Name: "DateCreated"})
+	}
+	if p.DateUpdated.IsZero() {
+		errs = append(errs, ErrZeroValue{Name: "DateUpdated"})
+	}
+
+	if len(errs) > 0 {
+		return ErrInvalidModel{Name: "Page", Errors: errs}
+	}
+
+	return nil
+}
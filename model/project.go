@@ -9,6 +9,12 @@ import (
 type Project struct {
 	ID          uuid.UUID // Must be unique, represented as base64 string in URLs
 	Title       string    // Must not be empty
+	Series      string    // Name of the series which the project is part of
+	Number      string    // Issue number of the project in the series, such as "1" or "1.5"
+	Writer      string    // Credited writers, as displayed to readers
+	Summary     string
+	Direction   Direction // Reading direction of the pages, must be a valid value
+	Spread      Spread    // How many pages are shown at once, must be a valid value
 	DateCreated time.Time
 	DateUpdated time.Time
 }
@@ -23,6 +29,20 @@ func (p Project) Validate() error {
 	if p.Title == "" {
 		errs = append(errs, ErrZeroValue{Name: "Title"})
 	}
+	if !p.Direction.Valid() {
+		errs = append(errs, ErrInvalidValue{
+			Name:     "Direction",
+			Actual:   p.Direction,
+			Expected: []any{DirectionLeftToRight, DirectionRightToLeft, DirectionVertical},
+		})
+	}
+	if !p.Spread.Valid() {
+		errs = append(errs, ErrInvalidValue{
+			Name:     "Spread",
+			Actual:   p.Spread,
+			Expected: []any{SpreadSingle, SpreadDouble},
+		})
+	}
 	if p.DateCreated.IsZero() {
 		errs = append(errs, ErrZeroValue{Name: "DateCreated"})
 	}
@@ -36,3 +56,26 @@ func (p Project) Validate() error {
 
 	return nil
 }
+
+type Direction string
+
+const (
+	DirectionLeftToRight Direction = "ltr"
+	DirectionRightToLeft Direction = "rtl"      // Used mostly by manga
+	DirectionVertical    Direction = "vertical" // Continuous vertical scroll, used mostly by webtoons
+)
+
+func (d Direction) Valid() bool {
+	return d == DirectionLeftToRight || d == DirectionRightToLeft || d == DirectionVertical
+}
+
+type Spread string
+
+const (
+	SpreadSingle Spread = "single"
+	SpreadDouble Spread = "double" // Pages are shown in pairs, except the ones marked as spreads
+)
+
+func (s Spread) Valid() bool {
+	return s == SpreadSingle || s == SpreadDouble
+}
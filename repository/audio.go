@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"forge.capytal.company/capytalcode/project-comicverse/model"
+	"forge.capytal.company/loreddev/x/tinyssert"
+	"github.com/google/uuid"
+)
+
+type Audio struct {
+	baseRepostiory
+}
+
+// Must be initiated after [Project]
+func NewAudio(ctx context.Context, db *sql.DB, log *slog.Logger, assert tinyssert.Assertions) (*Audio, error) {
+	b := newBaseRepostiory(ctx, db, log, assert)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	CREATE TABLE IF NOT EXISTS project_audio (
+		id           TEXT NOT NULL PRIMARY KEY,
+		project_id   TEXT NOT NULL,
+		name         TEXT NOT NULL,
+		content_type TEXT NOT NULL,
+		created_at   TEXT NOT NULL,
+		updated_at   TEXT NOT NULL,
+
+		FOREIGN KEY(project_id)
+			REFERENCES projects (id)
+				ON DELETE CASCADE
+				ON UPDATE RESTRICT
+	)`)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Join(errors.New("unable to create audio tables"), err)
+	}
+
+	return &Audio{baseRepostiory: b}, nil
+}
+
+func (repo Audio) Create(a model.Audio) error {
+	repo.assert.NotNil(repo.db)
+	repo.assert.NotNil(repo.ctx)
+	repo.assert.NotNil(repo.log)
+
+	if err := a.Validate(); err != nil {
+		return errors.Join(ErrInvalidInput, err)
+	}
+
+	tx, err := repo.db.BeginTx(repo.ctx, nil)
+	if err != nil {
+		return errors.Join(ErrDatabaseConn, err)
+	}
+
+	q := `
+	INSERT INTO project_audio (id, project_id, name, content_type, created_at, updated_at)
+	  VALUES (:id, :project_id, :name, :content_type, :created_at, :updated_at)
+	`
+
+	log := repo.log.With(slog.String("id", a.ID.String()),
+		slog.String("project_id", a.ProjectID.String()),
+		slog.String("query", q))
+	log.DebugContext(repo.ctx, "Inserting new audio")
+
+	_, err = tx.ExecContext(repo.ctx, q,
+		sql.Named("id", a.ID),
+		sql.Named("project_id", a.ProjectID),
+		sql.Named("name", a.Name),
+		sql.Named("content_type", a.ContentType),
+		sql.Named("created_at", a.DateCreated.Format(dateFormat)),
+		sql.Named("updated_at", a.DateUpdated.Format(dateFormat)),
+	)
+	if err != nil {
+		log.ErrorContext(repo.ctx, "Failed to insert audio", slog.String("error", err.Error()))
+		return errors.Join(ErrExecuteQuery, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.ErrorContext(repo.ctx, "Failed to commit transaction", slog.String("error", err.Error()))
+		return errors.Join(ErrCommitQuery, err)
+	}
+
+	return nil
+}
+
+// GetByProjectID returns all audio files of the project, sorted by their creation
+// date.
+func (repo Audio) GetByProjectID(projectID uuid.UUID) (audio []model.Audio, err error) {
+	repo.assert.NotNil(repo.db)
+	repo.assert.NotNil(repo.ctx)
+	repo.assert.NotNil(repo.log)
+
+	q := `
+	SELECT id, project_id, name, content_type, created_at, updated_at FROM project_audio
+	WHERE project_id = :project_id
+	ORDER BY created_at ASC
+	`
+
+	log := repo.log.With(slog.String("project_id", projectID.String()), slog.String("query", q))
+	log.DebugContext(repo.ctx, "Getting audio by project ID")
+
+	rows, err := repo.db.QueryContext(repo.ctx, q, sql.Named("project_id", projectID))
+	if err != nil {
+		log.ErrorContext(repo.ctx, "Failed to get audio by project ID", slog.String("error", err.Error()))
+		return nil, errors.Join(ErrExecuteQuery, err)
+	}
+
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			err = errors.Join(ErrCloseConn, cerr)
+		}
+	}()
+
+	as := []model.Audio{}
+
+	for rows.Next() {
+		a, err := repo.scan(rows)
+		if err != nil {
+			log.ErrorContext(repo.ctx, "Failed to scan audio of project", slog.String("error", err.Error()))
+			return nil, err
+		}
+		as = append(as, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.ErrorContext(repo.ctx, "Failed to iterate audio of project", slog.String("error", err.Error()))
+		return nil, errors.Join(ErrExecuteQuery, err)
+	}
+
+	return as, nil
+}
+
+func (repo Audio) scan(row scan) (model.Audio, error) {
+	var a model.Audio
+	var dateCreatedStr, dateUpdatedStr string
+
+	err := row.Scan(&a.ID, &a.ProjectID, &a.Name, &a.ContentType, &dateCreatedStr, &dateUpdatedStr)
+	if err != nil {
+		return model.Audio{}, errors.Join(ErrInvalidOutput, err)
+	}
+
+	a.DateCreated, err = time.Parse(dateFormat, dateCreatedStr)
+	if err != nil {
+		return model.Audio{}, errors.Join(ErrInvalidOutput, err)
+	}
+
+	a.DateUpdated, err = time.Parse(dateFormat, dateUpdatedStr)
+	if err != nil {
+		return model.Audio{}, errors.Join(ErrInvalidOutput, err)
+	}
+
+	if err := a.Validate(); err != nil {
+		return model.Audio{}, errors.Join(ErrInvalidOutput, err)
+	}
+
+	return a, nil
+}
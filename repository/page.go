@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"forge.capytal.company/capytalcode/project-comicverse/model"
+	"forge.capytal.company/loreddev/x/tinyssert"
+	"github.com/google/uuid"
+)
+
+type Page struct {
+	baseRepostiory
+}
+
+// Must be initiated after [Project]
+func NewPage(ctx context.Context, db *sql.DB, log *slog.Logger, assert tinyssert.Assertions) (*Page, error) {
+	b := newBaseRepostiory(ctx, db, log, assert)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	CREATE TABLE IF NOT EXISTS project_pages (
+		id           TEXT    NOT NULL PRIMARY KEY,
+		project_id   TEXT    NOT NULL,
+		position     INTEGER NOT NULL,
+		content_type TEXT    NOT NULL,
+		spread       INTEGER NOT NULL DEFAULT 0,
+		created_at   TEXT    NOT NULL,
+		updated_at   TEXT    NOT NULL,
+
+		FOREIGN KEY(project_id)
+			REFERENCES projects (id)
+				ON DELETE CASCADE
+				ON UPDATE RESTRICT
+	)`)
+	if err != nil {
+		return nil, err
+	}
+
+	err = addColumns(ctx, tx, "project_pages",
+		column{"spread", "INTEGER NOT NULL DEFAULT 0"},
+	)
+	if err != nil {
+		return nil, errors.Join(errors.New("unable to migrate page tables"), err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Join(errors.New("unable to create page tables"), err)
+	}
+
+	return &Page{baseRepostiory: b}, nil
+}
+
+func (repo Page) Create(p model.Page) error {
+	repo.assert.NotNil(repo.db)
+	repo.assert.NotNil(repo.ctx)
+	repo.assert.NotNil(repo.log)
+
+	if err := p.Validate(); err != nil {
+		return errors.Join(ErrInvalidInput, err)
+	}
+
+	tx, err := repo.db.BeginTx(repo.ctx, nil)
+	if err != nil {
+		return errors.Join(ErrDatabaseConn, err)
+	}
+
+	q := `
+	INSERT INTO project_pages (id, project_id, position, content_type, spread, created_at, updated_at)
+	  VALUES (:id, :project_id, :position, :content_type, :spread, :created_at, :updated_at)
+	`
+
+	log := repo.log.With(slog.String("id", p.ID.String()),
+		slog.String("project_id", p.ProjectID.String()),
+		slog.String("query", q))
+	log.DebugContext(repo.ctx, "Inserting new page")
+
+	_, err = tx.ExecContext(repo.ctx, q,
+		sql.Named("id", p.ID),
+		sql.Named("project_id", p.ProjectID),
+		sql.Named("position", p.Position),
+		sql.Named("content_type", p.ContentType),
+		sql.Named("spread", p.Spread),
+		sql.Named("created_at", p.DateCreated.Format(dateFormat)),
+		sql.Named("updated_at", p.DateUpdated.Format(dateFormat)),
+	)
+	if err != nil {
+		log.ErrorContext(repo.ctx, "Failed to insert page", slog.String("error", err.Error()))
+		return errors.Join(ErrExecuteQuery, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.ErrorContext(repo.ctx, "Failed to commit transaction", slog.String("error", err.Error()))
+		return errors.Join(ErrCommitQuery, err)
+	}
+
+	return nil
+}
+
+// GetByProjectID returns all pages of the project, sorted by their reading order.
+func (repo Page) GetByProjectID(projectID uuid.UUID) (pages []model.Page, err error) {
+	repo.assert.NotNil(repo.db)
+	repo.assert.NotNil(repo.ctx)
+	repo.assert.NotNil(repo.log)
+
+	q := `
+	SELECT id, project_id, position, content_type, spread, created_at, updated_at FROM project_pages
+	WHERE project_id = :project_id
+	ORDER BY position ASC
+	`
+
+	log := repo.log.With(slog.String("project_id", projectID.String()), slog.String("query", q))
+	log.DebugContext(repo.ctx, "Getting pages by project ID")
+
+	rows, err := repo.db.QueryContext(repo.ctx, q, sql.Named("project_id", projectID))
+	if err != nil {
+		log.ErrorContext(repo.ctx, "Failed to get pages by project ID", slog.String("error", err.Error()))
+		return nil, errors.Join(ErrExecuteQuery, err)
+	}
+
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			err = errors.Join(ErrCloseConn, cerr)
+		}
+	}()
+
+	ps := []model.Page{}
+
+	for rows.Next() {
+		p, err := repo.scan(rows)
+		if err != nil {
+			log.ErrorContext(repo.ctx, "Failed to scan pages of project", slog.String("error", err.Error()))
+			return nil, err
+		}
+		ps = append(ps, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.ErrorContext(repo.ctx, "Failed to iterate pages of project", slog.String("error", err.Error()))
+		return nil, errors.Join(ErrExecuteQuery, err)
+	}
+
+	return ps, nil
+}
+
+func (repo Page) scan(row scan) (model.Page, error) {
+	var p model.Page
+	var dateCreatedStr, dateUpdatedStr string
+
+	err := row.Scan(&p.ID, &p.ProjectID, &p.Position, &p.ContentType, &p.Spread, &dateCreatedStr, &dateUpdatedStr)
+	if err != nil {
+		return model.Page{}, errors.Join(ErrInvalidOutput, err)
+	}
+
+	p.DateCreated, err = time.Parse(dateFormat, dateCreatedStr)
+	if err != nil {
+		return model.Page{}, errors.Join(ErrInvalidOutput, err)
+	}
+
+	p.DateUpdated, err = time.Parse(dateFormat, dateUpdatedStr)
+	if err != nil {
+		return model.Page{}, errors.Join(ErrInvalidOutput, err)
+	}
+
+	if err := p.Validate(); err != nil {
+		return model.Page{}, errors.Join(ErrInvalidOutput, err)
+	}
+
+	return p, nil
+}
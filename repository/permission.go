@@ -126,7 +126,7 @@ func (repo Permissions) GetByID(project uuid.UUID, user uuid.UUID) (model.Permis
 	log.DebugContext(repo.ctx, "Getting by ID")
 
 	row := repo.db.QueryRowContext(repo.ctx, q,
-		sql.Named("project_id", user),
+		sql.Named("project_id", project),
 		sql.Named("user_id", user))
 
 	var p model.Permissions
@@ -196,6 +196,57 @@ func (repo Permissions) GetByUserID(user uuid.UUID) (permissions map[uuid.UUID]m
 	return ps, nil
 }
 
+// GetByProjectID returns a user_id-to-permissions map containing all users that have
+// permissions on said project.
+func (repo Permissions) GetByProjectID(project uuid.UUID) (permissions map[uuid.UUID]model.Permissions, err error) {
+	repo.assert.NotNil(repo.db)
+	repo.assert.NotNil(repo.ctx)
+	repo.assert.NotNil(repo.log)
+
+	q := `
+	SELECT user_id, permissions_value FROM project_permissions
+	WHERE project_id = :project_id
+	`
+
+	log := repo.log.With(slog.String("project_id", project.String()),
+		slog.String("query", q))
+	log.DebugContext(repo.ctx, "Getting by project ID")
+
+	rows, err := repo.db.QueryContext(repo.ctx, q, sql.Named("project_id", project))
+	if err != nil {
+		log.ErrorContext(repo.ctx, "Failed to get permissions by project ID", slog.String("error", err.Error()))
+		return nil, errors.Join(ErrExecuteQuery, err)
+	}
+
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			err = errors.Join(ErrCloseConn, cerr)
+		}
+	}()
+
+	ps := map[uuid.UUID]model.Permissions{}
+
+	for rows.Next() {
+		var user uuid.UUID
+		var permissions model.Permissions
+
+		err := rows.Scan(&user, &permissions)
+		if err != nil {
+			log.ErrorContext(repo.ctx, "Failed to scan permissions of project id", slog.String("error", err.Error()))
+			return nil, errors.Join(ErrInvalidOutput, err)
+		}
+
+		ps[user] = permissions
+	}
+
+	if err := rows.Err(); err != nil {
+		log.ErrorContext(repo.ctx, "Failed to iterate permissions of project id", slog.String("error", err.Error()))
+		return nil, errors.Join(ErrExecuteQuery, err)
+	}
+
+	return ps, nil
+}
+
 func (repo Permissions) Update(project, user uuid.UUID, permissions model.Permissions) error {
 	repo.assert.NotNil(repo.db)
 	repo.assert.NotNil(repo.ctx)
@@ -30,6 +30,12 @@ func NewProject(ctx context.Context, db *sql.DB, log *slog.Logger, assert tinyss
 	CREATE TABLE IF NOT EXISTS projects (
 		id		   TEXT NOT NULL PRIMARY KEY,
 		title      TEXT NOT NULL,
+		series     TEXT NOT NULL DEFAULT '',
+		number     TEXT NOT NULL DEFAULT '',
+		writer     TEXT NOT NULL DEFAULT '',
+		summary    TEXT NOT NULL DEFAULT '',
+		direction  TEXT NOT NULL DEFAULT 'ltr',
+		spread     TEXT NOT NULL DEFAULT 'single',
 		created_at TEXT NOT NULL,
 		updated_at TEXT NOT NULL
 	)`)
@@ -37,6 +43,18 @@ func NewProject(ctx context.Context, db *sql.DB, log *slog.Logger, assert tinyss
 		return nil, err
 	}
 
+	err = addColumns(ctx, tx, "projects",
+		column{"series", "TEXT NOT NULL DEFAULT ''"},
+		column{"number", "TEXT NOT NULL DEFAULT ''"},
+		column{"writer", "TEXT NOT NULL DEFAULT ''"},
+		column{"summary", "TEXT NOT NULL DEFAULT ''"},
+		column{"direction", "TEXT NOT NULL DEFAULT 'ltr'"},
+		column{"spread", "TEXT NOT NULL DEFAULT 'single'"},
+	)
+	if err != nil {
+		return nil, errors.Join(errors.New("unable to migrate project tables"), err)
+	}
+
 	if err := tx.Commit(); err != nil {
 		return nil, errors.Join(errors.New("unable to create project tables"), err)
 	}
@@ -59,8 +77,8 @@ func (repo Project) Create(p model.Project) error {
 	}
 
 	q := `
-	INSERT INTO projects (id, title, created_at, updated_at)
-	  VALUES (:id, :title, :created_at, :updated_at)
+	INSERT INTO projects (id, title, series, number, writer, summary, direction, spread, created_at, updated_at)
+	  VALUES (:id, :title, :series, :number, :writer, :summary, :direction, :spread, :created_at, :updated_at)
 	`
 
 	log := repo.log.With(slog.String("id", p.ID.String()), slog.String("query", q))
@@ -69,6 +87,12 @@ func (repo Project) Create(p model.Project) error {
 	_, err = tx.ExecContext(repo.ctx, q,
 		sql.Named("id", p.ID),
 		sql.Named("title", p.Title),
+		sql.Named("series", p.Series),
+		sql.Named("number", p.Number),
+		sql.Named("writer", p.Writer),
+		sql.Named("summary", p.Summary),
+		sql.Named("direction", p.Direction),
+		sql.Named("spread", p.Spread),
 		sql.Named("created_at", p.DateCreated.Format(dateFormat)),
 		sql.Named("updated_at", p.DateUpdated.Format(dateFormat)),
 	)
@@ -91,7 +115,7 @@ func (repo Project) GetByID(projectID uuid.UUID) (project model.Project, err err
 	repo.assert.NotNil(repo.log)
 
 	q := `
-	SELECT id, title, created_at, updated_at FROM projects
+	SELECT id, title, series, number, writer, summary, direction, spread, created_at, updated_at FROM projects
 		WHERE id = :id
 	`
 
@@ -101,10 +125,12 @@ func (repo Project) GetByID(projectID uuid.UUID) (project model.Project, err err
 	row := repo.db.QueryRowContext(repo.ctx, q, sql.Named("id", projectID))
 
 	var id uuid.UUID
-	var title string
+	var title, series, number, writer, summary string
+	var direction model.Direction
+	var spread model.Spread
 	var dateCreatedStr, dateUpdatedStr string
 
-	err = row.Scan(&id, &title, &dateCreatedStr, &dateUpdatedStr)
+	err = row.Scan(&id, &title, &series, &number, &writer, &summary, &direction, &spread, &dateCreatedStr, &dateUpdatedStr)
 	if err != nil {
 		log.ErrorContext(repo.ctx, "Failed to scan projects with IDs", slog.String("error", err.Error()))
 		return model.Project{}, errors.Join(ErrInvalidOutput, err)
@@ -125,6 +151,12 @@ func (repo Project) GetByID(projectID uuid.UUID) (project model.Project, err err
 	return model.Project{
 		ID:          id,
 		Title:       title,
+		Series:      series,
+		Number:      number,
+		Writer:      writer,
+		Summary:     summary,
+		Direction:   direction,
+		Spread:      spread,
 		DateCreated: dateCreated,
 		DateUpdated: dateUpdated,
 	}, nil
@@ -147,7 +179,7 @@ func (repo Project) GetByIDs(ids []uuid.UUID) (projects []model.Project, err err
 	}
 
 	q := fmt.Sprintf(`
-	SELECT id, title, created_at, updated_at FROM projects
+	SELECT id, title, series, number, writer, summary, direction, spread, created_at, updated_at FROM projects
 	WHERE %s
 	`, strings.Join(c, " OR "))
 
@@ -171,10 +203,12 @@ func (repo Project) GetByIDs(ids []uuid.UUID) (projects []model.Project, err err
 
 	for rows.Next() {
 		var id uuid.UUID
-		var title string
+		var title, series, number, writer, summary string
+		var direction model.Direction
+		var spread model.Spread
 		var dateCreatedStr, dateUpdatedStr string
 
-		err := rows.Scan(&id, &title, &dateCreatedStr, &dateUpdatedStr)
+		err := rows.Scan(&id, &title, &series, &number, &writer, &summary, &direction, &spread, &dateCreatedStr, &dateUpdatedStr)
 		if err != nil {
 			log.ErrorContext(repo.ctx, "Failed to scan projects with IDs", slog.String("error", err.Error()))
 			return nil, errors.Join(ErrInvalidOutput, err)
@@ -195,6 +229,12 @@ func (repo Project) GetByIDs(ids []uuid.UUID) (projects []model.Project, err err
 		ps = append(ps, model.Project{
 			ID:          id,
 			Title:       title,
+			Series:      series,
+			Number:      number,
+			Writer:      writer,
+			Summary:     summary,
+			Direction:   direction,
+			Spread:      spread,
 			DateCreated: dateCreated,
 			DateUpdated: dateUpdated,
 		})
@@ -224,7 +264,13 @@ func (repo Project) Update(p model.Project) error {
 
 	q := `
 	UPDATE projects 
-	SET title = :title
+	SET title      = :title,
+	    series     = :series,
+	    number     = :number,
+	    writer     = :writer,
+	    summary    = :summary,
+	    direction  = :direction,
+	    spread     = :spread,
 	    updated_at = :updated_at
 	WHERE id = :id
 	`
@@ -234,6 +280,12 @@ func (repo Project) Update(p model.Project) error {
 
 	_, err = tx.ExecContext(repo.ctx, q,
 		sql.Named("title", p.Title),
+		sql.Named("series", p.Series),
+		sql.Named("number", p.Number),
+		sql.Named("writer", p.Writer),
+		sql.Named("summary", p.Summary),
+		sql.Named("direction", p.Direction),
+		sql.Named("spread", p.Spread),
 		sql.Named("updated_at", p.DateUpdated.Format(dateFormat)),
 		sql.Named("id", p.ID),
 	)
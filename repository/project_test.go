@@ -0,0 +1,161 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"forge.capytal.company/capytalcode/project-comicverse/model"
+	"forge.capytal.company/capytalcode/project-comicverse/repository"
+	"forge.capytal.company/loreddev/x/tinyssert"
+	"github.com/google/uuid"
+	_ "github.com/tursodatabase/go-libsql"
+)
+
+var oldProjectID = uuid.MustParse("0195f3a4-7b2c-7000-8000-000000000000")
+
+func TestNewProjectMigration(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	db := newDB(t)
+	createOldProject(t, db)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// Running it twice shouldn't try to add the columns again.
+	var repo *repository.Project
+	for range 2 {
+		var err error
+		if repo, err = repository.NewProject(context.Background(), db, log, assert); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, err := repo.GetByID(oldProjectID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal("Old", p.Title)
+	assert.Equal("", p.Series)
+	assert.Equal("", p.Number)
+	assert.Equal("", p.Writer)
+	assert.Equal("", p.Summary)
+	assert.Equal(model.DirectionLeftToRight, p.Direction)
+	assert.Equal(model.SpreadSingle, p.Spread)
+
+	n := model.Project{
+		ID:          uuid.New(),
+		Title:       "New",
+		Series:      "Tests",
+		Number:      "2",
+		Direction:   model.DirectionRightToLeft,
+		Spread:      model.SpreadDouble,
+		DateCreated: time.Now().Truncate(time.Second),
+		DateUpdated: time.Now().Truncate(time.Second),
+	}
+	if err := repo.Create(n); err != nil {
+		t.Fatal(err)
+	}
+	p, err = repo.GetByID(n.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(n.Series, p.Series)
+	assert.Equal(n.Number, p.Number)
+	assert.Equal(n.Direction, p.Direction)
+	assert.Equal(n.Spread, p.Spread)
+}
+
+func TestNewPageMigration(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	db := newDB(t)
+	createOldProject(t, db)
+
+	// Table as created before pages could be marked as spreads.
+	_, err := db.Exec(`
+	CREATE TABLE project_pages (
+		id           TEXT    NOT NULL PRIMARY KEY,
+		project_id   TEXT    NOT NULL,
+		position     INTEGER NOT NULL,
+		content_type TEXT    NOT NULL,
+		created_at   TEXT    NOT NULL,
+		updated_at   TEXT    NOT NULL,
+
+		FOREIGN KEY(project_id)
+			REFERENCES projects (id)
+				ON DELETE CASCADE
+				ON UPDATE RESTRICT
+	)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`
+	INSERT INTO project_pages (id, project_id, position, content_type, created_at, updated_at)
+	  VALUES ('0195f3a4-7b2c-7000-8000-000000000001', :project_id, 0, 'image/png',
+	    '2025-01-01T00:00:00Z', '2025-01-01T00:00:00Z')
+	`, sql.Named("project_id", oldProjectID.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	if _, err := repository.NewProject(context.Background(), db, log, assert); err != nil {
+		t.Fatal(err)
+	}
+	var repo *repository.Page
+	for range 2 {
+		if repo, err = repository.NewPage(context.Background(), db, log, assert); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pages, err := repo.GetByProjectID(oldProjectID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(1, len(pages))
+	assert.Equal("image/png", pages[0].ContentType)
+	assert.Equal(false, pages[0].Spread)
+}
+
+// createOldProject creates the projects table as it was before the project
+// metadata was added, with a single project.
+func createOldProject(t *testing.T, db *sql.DB) {
+	t.Helper()
+
+	_, err := db.Exec(`
+	CREATE TABLE projects (
+		id         TEXT NOT NULL PRIMARY KEY,
+		title      TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`
+	INSERT INTO projects (id, title, created_at, updated_at)
+	  VALUES (:id, 'Old', '2025-01-01T00:00:00Z', '2025-01-01T00:00:00Z')
+	`, sql.Named("id", oldProjectID.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("libsql", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	return db
+}
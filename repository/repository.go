@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"log/slog"
 	"time"
 
@@ -49,3 +50,49 @@ var dateFormat = time.RFC3339
 type scan interface {
 	Scan(dest ...any) error
 }
+
+// column is the name and definition of a column added to a table after it was
+// first created.
+type column struct {
+	name       string
+	definition string
+}
+
+// addColumns adds the columns which table doesn't have yet, so databases created
+// by older versions have the columns used by the queries. CREATE TABLE IF NOT
+// EXISTS doesn't change tables which already exist, so it is not enough. Columns
+// which already exist are skipped, making it safe to run more than once.
+//
+// Definitions of columns with NOT NULL must have a DEFAULT, used to fill the
+// existing rows.
+func addColumns(ctx context.Context, tx *sql.Tx, table string, columns ...column) error {
+	rows, err := tx.QueryContext(ctx, `SELECT name FROM pragma_table_info(:table)`, sql.Named("table", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	existing := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		existing[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, c := range columns {
+		if existing[c.name] {
+			continue
+		}
+		q := fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, c.name, c.definition)
+		if _, err := tx.ExecContext(ctx, q); err != nil {
+			return fmt.Errorf("unable to add column %q to table %q: %w", c.name, table, err)
+		}
+	}
+
+	return nil
+}
@@ -108,7 +108,7 @@ func (repo *User) GetByID(id uuid.UUID) (model.User, error) {
 		slog.String("query", q))
 	log.DebugContext(repo.ctx, "Querying user")
 
-	row := repo.db.QueryRowContext(repo.ctx, q, sql.Named("username", id))
+	row := repo.db.QueryRowContext(repo.ctx, q, sql.Named("id", id))
 
 	user, err := repo.scan(row)
 	if err != nil {
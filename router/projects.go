@@ -2,11 +2,19 @@ package router
 
 import (
 	"encoding/base64"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"mime"
 	"net/http"
+	"path/filepath"
+	"strings"
 
+	"forge.capytal.company/capytalcode/project-comicverse/internals/cbz"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/epub"
+	"forge.capytal.company/capytalcode/project-comicverse/model"
 	"forge.capytal.company/capytalcode/project-comicverse/service"
 	"forge.capytal.company/capytalcode/project-comicverse/templates"
 	"forge.capytal.company/loreddev/x/smalltrip/exception"
@@ -19,17 +27,20 @@ type projectController struct {
 
 	templates templates.ITemplate
 
+	log    *slog.Logger
 	assert tinyssert.Assertions
 }
 
 func newProjectController(
 	projectService *service.Project,
 	templates templates.ITemplate,
+	logger *slog.Logger,
 	assertions tinyssert.Assertions,
 ) *projectController {
 	return &projectController{
 		projectSvc: projectService,
 		templates:  templates,
+		log:        logger,
 		assert:     assertions,
 	}
 }
@@ -71,21 +82,127 @@ func (ctrl projectController) dashboard(w http.ResponseWriter, r *http.Request)
 }
 
 func (ctrl projectController) getProject(w http.ResponseWriter, r *http.Request) {
-	// TODO: Handle private projects
+	projectID, ok := ctrl.projectID(w, r)
+	if !ok {
+		return
+	}
 
-	shortProjectID := r.PathValue("projectID")
+	if !ctrl.authorize(w, r, projectID, model.PermissionRead) {
+		return
+	}
 
-	id, err := base64.URLEncoding.DecodeString(shortProjectID)
+	project, err := ctrl.projectSvc.GetProject(projectID)
+	if errors.Is(err, service.ErrNotFound) {
+		exception.NotFound().ServeHTTP(w, r)
+		return
+	} else if err != nil {
+		exception.InternalServerError(err).ServeHTTP(w, r)
+		return
+	}
+
+	metadata, err := ctrl.projectSvc.GetMetadata(projectID)
 	if err != nil {
-		exception.BadRequest(err, exception.WithMessage("Incorrect base64 encoding of project ID")).
-			ServeHTTP(w, r)
+		exception.InternalServerError(err).ServeHTTP(w, r)
 		return
 	}
 
-	projectID, err := uuid.ParseBytes(id)
+	pages, err := ctrl.projectSvc.GetPages(projectID)
 	if err != nil {
-		exception.BadRequest(err, exception.WithMessage("Incorrect project ID is not a valid UUID")).
-			ServeHTTP(w, r)
+		exception.InternalServerError(err).ServeHTTP(w, r)
+		return
+	}
+
+	// INFO: Interactions are not stored yet, the field is kept so the template
+	// can list them once they are.
+	type interaction struct {
+		URL  string
+		X, Y int
+	}
+	type page struct {
+		ID           string
+		Spread       bool
+		Interactions map[string]interaction
+	}
+
+	ps := make([]page, len(pages))
+	for i, p := range pages {
+		ps[i] = page{ID: p.ID.String(), Spread: p.Spread}
+	}
+
+	audio, err := ctrl.projectSvc.GetAudio(projectID)
+	if err != nil {
+		exception.InternalServerError(err).ServeHTTP(w, r)
+		return
+	}
+
+	as := make([]struct {
+		Name string
+		Href string
+	}, len(audio))
+	for i, a := range audio {
+		as[i].Name = a.Name
+		as[i].Href = service.AudioHref(a)
+	}
+
+	err = ctrl.templates.ExecuteTemplate(w, "project", struct {
+		ID        string
+		Direction model.Direction
+		Spread    model.Spread
+		Metadata  ast.Metadata
+		Pages     []page
+		Audio     []struct {
+			Name string
+			Href string
+		}
+	}{
+		ID:        base64.URLEncoding.EncodeToString([]byte(project.ID.String())),
+		Direction: project.Direction,
+		Spread:    project.Spread,
+		Metadata:  metadata,
+		Pages:     ps,
+		Audio:     as,
+	})
+	if err != nil {
+		exception.InternalServerError(err).ServeHTTP(w, r)
+	}
+}
+
+func (ctrl projectController) exportEPUB(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := ctrl.projectID(w, r)
+	if !ok {
+		return
+	}
+
+	if !ctrl.authorize(w, r, projectID, model.PermissionRead) {
+		return
+	}
+
+	pkg, err := ctrl.projectSvc.GetPackage(projectID)
+	if errors.Is(err, service.ErrNotFound) {
+		exception.NotFound().ServeHTTP(w, r)
+		return
+	} else if err != nil {
+		exception.InternalServerError(err).ServeHTTP(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", epub.MimeType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": pkg.Metadata.Title + ".epub",
+	}))
+
+	ctrl.stream(w, r, projectID, func(w io.Writer) error {
+		return epub.Write(w, pkg)
+	})
+}
+
+func (ctrl projectController) exportCBZ(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := ctrl.projectID(w, r)
+	if !ok {
+		return
+	}
+
+	if !ctrl.authorize(w, r, projectID, model.PermissionRead) {
 		return
 	}
 
@@ -98,14 +215,93 @@ func (ctrl projectController) getProject(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// TODO: Return project template
-	b, err := json.Marshal(project)
+	w.Header().Set("Content-Type", cbz.MimeType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": project.Title + ".cbz",
+	}))
+
+	ctrl.stream(w, r, projectID, func(w io.Writer) error {
+		return ctrl.projectSvc.ExportCBZ(w, projectID)
+	})
+}
+
+// authorize checks if the user has the permissions perms on the project, writing
+// the error response if they don't. Users which can read the project are responded
+// as forbidden, while projects the user can't read are responded as not found, so
+// their existence is not revealed.
+func (ctrl projectController) authorize(
+	w http.ResponseWriter,
+	r *http.Request,
+	projectID uuid.UUID,
+	perms ...model.Permissions,
+) bool {
+	userCtx := NewUserContext(r.Context())
+
+	userID, ok := userCtx.GetUserID()
+	if !ok {
+		userCtx.Unathorize(w, r)
+		return false
+	}
+
+	ok, err := ctrl.projectSvc.HasPermissions(projectID, userID, perms...)
+	if err != nil {
+		exception.InternalServerError(err).ServeHTTP(w, r)
+		return false
+	}
+	if ok {
+		return true
+	}
+
+	ok, err = ctrl.projectSvc.HasPermissions(projectID, userID, model.PermissionRead)
+	if err != nil {
+		exception.InternalServerError(err).ServeHTTP(w, r)
+	} else if ok {
+		exception.Forbidden().ServeHTTP(w, r)
+	} else {
+		exception.NotFound().ServeHTTP(w, r)
+	}
+
+	return false
+}
+
+// stream writes the response body with write. Errors are only responded if
+// nothing was written yet, since the headers are sent with the first write. After
+// that, they are only logged and the response is left incomplete, so the download
+// fails on the client.
+func (ctrl projectController) stream(
+	w http.ResponseWriter,
+	r *http.Request,
+	projectID uuid.UUID,
+	write func(w io.Writer) error,
+) {
+	sw := &startedWriter{w: w}
+	err := write(sw)
+	if err == nil {
+		return
+	}
 
-	w.Header().Add("Content-Type", "application/json")
-	if _, err := w.Write(b); err != nil {
+	if !sw.started {
+		w.Header().Del("Content-Type")
+		w.Header().Del("Content-Disposition")
 		exception.InternalServerError(err).ServeHTTP(w, r)
 		return
 	}
+
+	ctrl.log.Error("Failed to stream project export",
+		slog.String("project", projectID.String()),
+		slog.String("path", r.URL.Path),
+		slog.String("error", err.Error()))
+}
+
+// startedWriter records if anything was written to w.
+type startedWriter struct {
+	w       io.Writer
+	started bool
+}
+
+func (w *startedWriter) Write(p []byte) (int, error) {
+	w.started = w.started || len(p) > 0
+	return w.w.Write(p)
 }
 
 func (ctrl projectController) createProject(w http.ResponseWriter, r *http.Request) {
@@ -132,3 +328,127 @@ func (ctrl projectController) createProject(w http.ResponseWriter, r *http.Reque
 	path := fmt.Sprintf("/p/%s/", base64.URLEncoding.EncodeToString([]byte(project.ID.String())))
 	http.Redirect(w, r, path, http.StatusSeeOther)
 }
+
+func (ctrl projectController) importCBZ(w http.ResponseWriter, r *http.Request) {
+	userCtx := NewUserContext(r.Context())
+
+	userID, ok := userCtx.GetUserID()
+	if !ok {
+		userCtx.Unathorize(w, r)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxArchiveSize)
+
+	file, header, err := r.FormFile("archive")
+	if err != nil {
+		exception.BadRequest(err, exception.WithMessage(`Missing or invalid "archive" file`)).ServeHTTP(w, r)
+		return
+	}
+	defer file.Close()
+
+	title := strings.TrimSuffix(header.Filename, filepath.Ext(header.Filename))
+
+	project, err := ctrl.projectSvc.ImportCBZ(file, header.Size, title, userID)
+	if errors.Is(err, service.ErrInvalidArchive) {
+		exception.BadRequest(err, exception.WithMessage("File is not a valid CBZ archive")).ServeHTTP(w, r)
+		return
+	} else if err != nil {
+		exception.InternalServerError(err).ServeHTTP(w, r)
+		return
+	}
+
+	path := fmt.Sprintf("/p/%s/", base64.URLEncoding.EncodeToString([]byte(project.ID.String())))
+	http.Redirect(w, r, path, http.StatusSeeOther)
+}
+
+const maxArchiveSize = 1 << 30 // 1 GiB
+
+func (ctrl projectController) setLayout(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := ctrl.projectID(w, r)
+	if !ok {
+		return
+	}
+
+	if !ctrl.authorize(w, r, projectID, model.PermissionEditPages) {
+		return
+	}
+
+	direction := model.Direction(r.FormValue("direction"))
+	spread := model.Spread(r.FormValue("spread"))
+
+	err := ctrl.projectSvc.SetLayout(projectID, direction, spread)
+	if errors.Is(err, service.ErrNotFound) {
+		exception.NotFound().ServeHTTP(w, r)
+		return
+	} else if errors.Is(err, service.ErrInvalidLayout) {
+		exception.BadRequest(err, exception.WithMessage(`Invalid "direction" or "spread"`)).ServeHTTP(w, r)
+		return
+	} else if err != nil {
+		exception.InternalServerError(err).ServeHTTP(w, r)
+		return
+	}
+
+	path := fmt.Sprintf("/p/%s/", r.PathValue("projectID"))
+	http.Redirect(w, r, path, http.StatusSeeOther)
+}
+
+func (ctrl projectController) addAudio(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := ctrl.projectID(w, r)
+	if !ok {
+		return
+	}
+
+	if !ctrl.authorize(w, r, projectID, model.PermissionEditPages) {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxAudioUploadSize)
+
+	file, header, err := r.FormFile("audio")
+	if err != nil {
+		exception.BadRequest(err, exception.WithMessage(`Missing or invalid "audio" file`)).ServeHTTP(w, r)
+		return
+	}
+	defer file.Close()
+
+	_, err = ctrl.projectSvc.AddAudio(projectID, header.Filename, header.Header.Get("Content-Type"), file)
+	if errors.Is(err, service.ErrUnsupportedMediaType) {
+		exception.BadRequest(err, exception.WithMessage("File is not a supported audio type")).ServeHTTP(w, r)
+		return
+	} else if errors.Is(err, service.ErrFileTooLarge) {
+		exception.BadRequest(err, exception.WithMessage("Audio file is too large")).ServeHTTP(w, r)
+		return
+	} else if err != nil {
+		exception.InternalServerError(err).ServeHTTP(w, r)
+		return
+	}
+
+	path := fmt.Sprintf("/p/%s/", r.PathValue("projectID"))
+	http.Redirect(w, r, path, http.StatusSeeOther)
+}
+
+// maxAudioUploadSize leaves room for the rest of the form around the audio file.
+const maxAudioUploadSize = service.MaxAudioSize + 1<<20
+
+// projectID gets the project's UUID from the "projectID" path value, which is encoded
+// as base64. If the value is invalid, it responds with a bad request and returns false.
+func (ctrl projectController) projectID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	shortProjectID := r.PathValue("projectID")
+
+	id, err := base64.URLEncoding.DecodeString(shortProjectID)
+	if err != nil {
+		exception.BadRequest(err, exception.WithMessage("Incorrect base64 encoding of project ID")).
+			ServeHTTP(w, r)
+		return uuid.UUID{}, false
+	}
+
+	projectID, err := uuid.ParseBytes(id)
+	if err != nil {
+		exception.BadRequest(err, exception.WithMessage("Incorrect project ID is not a valid UUID")).
+			ServeHTTP(w, r)
+		return uuid.UUID{}, false
+	}
+
+	return projectID, true
+}
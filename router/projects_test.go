@@ -0,0 +1,380 @@
+package router_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"forge.capytal.company/capytalcode/project-comicverse/model"
+	"forge.capytal.company/capytalcode/project-comicverse/repository"
+	"forge.capytal.company/capytalcode/project-comicverse/router"
+	"forge.capytal.company/capytalcode/project-comicverse/service"
+	"forge.capytal.company/capytalcode/project-comicverse/templates"
+	"forge.capytal.company/loreddev/x/tinyssert"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	_ "github.com/tursodatabase/go-libsql"
+)
+
+func TestGetProject(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	app := newApp(t)
+
+	owner, ownerToken := app.user(t, "owner")
+	_, strangerToken := app.user(t, "stranger")
+
+	project, err := app.projects.Create("Project", owner.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := base64.URLEncoding.EncodeToString([]byte(project.ID.String()))
+
+	w := app.get("/p/"+id+"/", strangerToken)
+	assert.Equal(http.StatusNotFound, w.Code)
+
+	w = app.get("/p/"+id+"/", ownerToken)
+	assert.Equal(http.StatusOK, w.Code)
+	if !strings.Contains(w.Body.String(), `action="/p/`+id+`/layout/"`) {
+		t.Errorf("layout form does not post to the encoded project ID: %s", w.Body)
+	}
+}
+
+func TestGetProjectMetadata(t *testing.T) {
+	app := newApp(t)
+
+	owner, ownerToken := app.user(t, "owner")
+	artist, _ := app.user(t, "artist")
+
+	project, err := app.projects.Create("Project", owner.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.permissions.Create(project.ID, artist.ID, model.PermissionRead|model.PermissionEditPages); err != nil {
+		t.Fatal(err)
+	}
+
+	w := app.get("/p/"+base64.URLEncoding.EncodeToString([]byte(project.ID.String()))+"/", ownerToken)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	for _, want := range []string{"<h1>Project</h1>", "<li>owner (aut)</li>", "<li>artist (art)</li>"} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("project page does not contain %q: %s", want, w.Body)
+		}
+	}
+}
+
+func TestSetLayout(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	app := newApp(t)
+
+	owner, ownerToken := app.user(t, "owner")
+	reader, readerToken := app.user(t, "reader")
+	_, strangerToken := app.user(t, "stranger")
+
+	project, err := app.projects.Create("Project", owner.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.permissions.Create(project.ID, reader.ID, model.PermissionRead); err != nil {
+		t.Fatal(err)
+	}
+
+	path := "/p/" + base64.URLEncoding.EncodeToString([]byte(project.ID.String())) + "/layout/"
+	form := url.Values{"direction": {"rtl"}, "spread": {"double"}}
+
+	tests := map[string]struct {
+		token  string
+		status int
+	}{
+		"anonymous": {"", http.StatusUnauthorized},
+		"stranger":  {strangerToken, http.StatusNotFound},
+		"reader":    {readerToken, http.StatusForbidden},
+		"owner":     {ownerToken, http.StatusSeeOther},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := app.post(path, test.token, form)
+			assert.Equal(test.status, w.Code)
+		})
+	}
+
+	p, err := app.projects.GetProject(project.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(model.DirectionRightToLeft, p.Direction)
+	assert.Equal(model.SpreadDouble, p.Spread)
+}
+
+func TestAddAudio(t *testing.T) {
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+
+	app := newApp(t)
+
+	owner, ownerToken := app.user(t, "owner")
+	reader, readerToken := app.user(t, "reader")
+
+	project, err := app.projects.Create("Project", owner.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.permissions.Create(project.ID, reader.ID, model.PermissionRead); err != nil {
+		t.Fatal(err)
+	}
+
+	path := "/p/" + base64.URLEncoding.EncodeToString([]byte(project.ID.String())) + "/audio/"
+
+	w := app.postFile(path, readerToken, "rain.ogg", "audio/ogg", "OggS")
+	assert.Equal(http.StatusForbidden, w.Code)
+
+	w = app.postFile(path, ownerToken, "rain.txt", "text/plain", "rain")
+	assert.Equal(http.StatusBadRequest, w.Code)
+
+	w = app.postFile(path, ownerToken, "rain.ogg", "audio/ogg", "OggS")
+	assert.Equal(http.StatusSeeOther, w.Code)
+
+	audio, err := app.projects.GetAudio(project.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(1, len(audio))
+	assert.Equal("rain.ogg", audio[0].Name)
+	assert.Equal(1, app.storage.len())
+
+	// Files of audio which failed to be created are not left in the storage.
+	if _, err := app.db.Exec(`DROP TABLE project_audio`); err != nil {
+		t.Fatal(err)
+	}
+	w = app.postFile(path, ownerToken, "thunder.ogg", "audio/ogg", "OggS")
+	assert.Equal(http.StatusInternalServerError, w.Code)
+	assert.Equal(1, app.storage.len())
+}
+
+type app struct {
+	handler     http.Handler
+	db          *sql.DB
+	storage     *storage
+	users       *service.User
+	tokens      *service.Token
+	projects    *service.Project
+	permissions *repository.Permissions
+}
+
+// newApp sets up the router with services backed by a temporary database and an
+// in-memory storage.
+func newApp(t *testing.T) app {
+	t.Helper()
+
+	assert := tinyssert.New(tinyssert.WithTest(t), tinyssert.WithPanic())
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+
+	db, err := sql.Open("libsql", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	userRepo, err := repository.NewUser(ctx, db, log, assert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenRepo, err := repository.NewToken(ctx, db, log, assert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	projectRepo, err := repository.NewProject(ctx, db, log, assert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	permissionRepo, err := repository.NewPermissions(ctx, db, log, assert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pageRepo, err := repository.NewPage(ctx, db, log, assert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	audioRepo, err := repository.NewAudio(ctx, db, log, assert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := &storage{objects: map[string][]byte{}}
+	srv := httptest.NewServer(store)
+	t.Cleanup(srv.Close)
+
+	a := app{
+		db:      db,
+		storage: store,
+		users:   service.NewUser(userRepo, log, assert),
+		tokens: service.NewToken(service.TokenConfig{
+			PrivateKey: privateKey,
+			PublicKey:  publicKey,
+			Repository: tokenRepo,
+			Logger:     log,
+			Assertions: assert,
+		}),
+		projects: service.NewProject(service.ProjectConfig{
+			ProjectRepository:    projectRepo,
+			PermissionRepository: permissionRepo,
+			PageRepository:       pageRepo,
+			AudioRepository:      audioRepo,
+			UserRepository:       userRepo,
+			Storage: s3.New(s3.Options{
+				BaseEndpoint:               aws.String(srv.URL),
+				Credentials:                aws.AnonymousCredentials{},
+				Region:                     "auto",
+				UsePathStyle:               true,
+				RequestChecksumCalculation: aws.RequestChecksumCalculationWhenRequired,
+			}),
+			Bucket:     "comicverse",
+			Context:    ctx,
+			Logger:     log,
+			Assertions: assert,
+		}),
+		permissions: permissionRepo,
+	}
+
+	a.handler, err = router.New(router.Config{
+		UserService:    a.users,
+		TokenService:   a.tokens,
+		ProjectService: a.projects,
+		Templates:      templates.Templates(),
+		Assets:         fstest.MapFS{},
+		Assertions:     assert,
+		Logger:         log,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return a
+}
+
+// user registers a user with the given username and issues a token for them.
+func (a app) user(t *testing.T, username string) (model.User, string) {
+	t.Helper()
+
+	u, err := a.users.Register(username, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := a.tokens.Issue(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return u, token
+}
+
+func (a app) get(path, token string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		r.Header.Set("Authorization", token)
+	}
+
+	w := httptest.NewRecorder()
+	a.handler.ServeHTTP(w, r)
+	return w
+}
+
+// postFile posts a multipart form with the file in its "audio" field.
+func (a app) postFile(path, token, name, contentType, content string) *httptest.ResponseRecorder {
+	var b bytes.Buffer
+	mw := multipart.NewWriter(&b)
+
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="audio"; filename=%q`, name))
+	h.Set("Content-Type", contentType)
+	f, _ := mw.CreatePart(h)
+	_, _ = io.WriteString(f, content)
+	_ = mw.Close()
+
+	r := httptest.NewRequest(http.MethodPost, path, &b)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	if token != "" {
+		r.Header.Set("Authorization", token)
+	}
+
+	w := httptest.NewRecorder()
+	a.handler.ServeHTTP(w, r)
+	return w
+}
+
+func (a app) post(path, token string, form url.Values) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if token != "" {
+		r.Header.Set("Authorization", token)
+	}
+
+	w := httptest.NewRecorder()
+	a.handler.ServeHTTP(w, r)
+	return w
+}
+
+// storage is a S3 server which only supports putting, getting and deleting objects,
+// keeping them in memory by their path.
+type storage struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (s *storage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.objects[r.URL.Path] = b
+	case http.MethodGet:
+		b, ok := s.objects[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(b)
+	case http.MethodDelete:
+		delete(s.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *storage) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.objects)
+}
@@ -111,7 +111,12 @@ func (router *router) setup() http.Handler {
 		Templates:    router.templates,
 		Assert:       router.assert,
 	})
-	projectController := newProjectController(router.projectService, router.templates, router.assert)
+	projectController := newProjectController(
+		router.projectService,
+		router.templates,
+		log.WithGroup("projects"),
+		router.assert,
+	)
 
 	r.Handle("/assets/", http.StripPrefix("/assets/", http.FileServerFS(router.assets)))
 
@@ -136,7 +141,12 @@ func (router *router) setup() http.Handler {
 
 	// TODO: Provide/redirect short project-id paths to long paths with the project title as URL /projects/title-of-the-project-<start of uuid>
 	r.HandleFunc("GET /p/{projectID}/{$}", projectController.getProject)
+	r.HandleFunc("GET /p/{projectID}/export.epub", projectController.exportEPUB)
+	r.HandleFunc("GET /p/{projectID}/export.cbz", projectController.exportCBZ)
+	r.HandleFunc("POST /p/{projectID}/layout/{$}", projectController.setLayout)
+	r.HandleFunc("POST /p/{projectID}/audio/{$}", projectController.addAudio)
 	r.HandleFunc("POST /p/{$}", projectController.createProject)
+	r.HandleFunc("POST /p/import/{$}", projectController.importCBZ)
 
 	return r
 }
@@ -1,39 +1,83 @@
 package service
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"slices"
+	"strings"
 	"time"
 
+	"forge.capytal.company/capytalcode/project-comicverse/internals/cbz"
+	"forge.capytal.company/capytalcode/project-comicverse/ipub/ast"
 	"forge.capytal.company/capytalcode/project-comicverse/model"
 	"forge.capytal.company/capytalcode/project-comicverse/repository"
 	"forge.capytal.company/loreddev/x/tinyssert"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/google/uuid"
 )
 
 type Project struct {
 	projectRepo    *repository.Project
 	permissionRepo *repository.Permissions
+	pageRepo       *repository.Page
+	audioRepo      *repository.Audio
+	userRepo       *repository.User
 
+	storage *s3.Client
+	bucket  string
+
+	ctx    context.Context
 	log    *slog.Logger
 	assert tinyssert.Assertions
 }
 
-func NewProject(
-	project *repository.Project,
-	permissions *repository.Permissions,
-	logger *slog.Logger,
-	assertions tinyssert.Assertions,
-) *Project {
+func NewProject(cfg ProjectConfig) *Project {
+	cfg.Assertions.NotNil(cfg.ProjectRepository)
+	cfg.Assertions.NotNil(cfg.PermissionRepository)
+	cfg.Assertions.NotNil(cfg.PageRepository)
+	cfg.Assertions.NotNil(cfg.AudioRepository)
+	cfg.Assertions.NotNil(cfg.UserRepository)
+	cfg.Assertions.NotNil(cfg.Storage)
+	cfg.Assertions.NotZero(cfg.Bucket)
+	cfg.Assertions.NotNil(cfg.Context)
+	cfg.Assertions.NotNil(cfg.Logger)
+
 	return &Project{
-		projectRepo:    project,
-		permissionRepo: permissions,
+		projectRepo:    cfg.ProjectRepository,
+		permissionRepo: cfg.PermissionRepository,
+		pageRepo:       cfg.PageRepository,
+		audioRepo:      cfg.AudioRepository,
+		userRepo:       cfg.UserRepository,
+
+		storage: cfg.Storage,
+		bucket:  cfg.Bucket,
 
-		log:    logger,
-		assert: assertions,
+		ctx:    cfg.Context,
+		log:    cfg.Logger,
+		assert: cfg.Assertions,
 	}
 }
 
+type ProjectConfig struct {
+	ProjectRepository    *repository.Project
+	PermissionRepository *repository.Permissions
+	PageRepository       *repository.Page
+	AudioRepository      *repository.Audio
+	UserRepository       *repository.User
+
+	Storage *s3.Client
+	Bucket  string
+
+	Context    context.Context
+	Logger     *slog.Logger
+	Assertions tinyssert.Assertions
+}
+
 func (svc Project) Create(title string, ownerUserID ...uuid.UUID) (model.Project, error) {
 	log := svc.log.With(slog.String("title", title))
 	log.Info("Creating project")
@@ -49,11 +93,17 @@ func (svc Project) Create(title string, ownerUserID ...uuid.UUID) (model.Project
 	p := model.Project{
 		ID:          id,
 		Title:       title,
+		Direction:   model.DirectionLeftToRight,
+		Spread:      model.SpreadSingle,
 		DateCreated: now,
 		DateUpdated: now,
 	}
 
-	err = svc.projectRepo.Create(p)
+	return svc.create(p, ownerUserID...)
+}
+
+func (svc Project) create(p model.Project, ownerUserID ...uuid.UUID) (model.Project, error) {
+	err := svc.projectRepo.Create(p)
 	if err != nil {
 		return model.Project{}, fmt.Errorf("service: failed to create project: %w", err)
 	}
@@ -68,6 +118,388 @@ func (svc Project) Create(title string, ownerUserID ...uuid.UUID) (model.Project
 	return p, nil
 }
 
+// ImportCBZ creates a new project from the comic book archive in r, storing each
+// of its images as a page. Metadata from the archive's ComicInfo.xml is used on
+// the project, if there isn't a title in it, fallbackTitle is used instead.
+func (svc Project) ImportCBZ(
+	r io.ReaderAt,
+	size int64,
+	fallbackTitle string,
+	ownerUserID ...uuid.UUID,
+) (model.Project, error) {
+	log := svc.log.With(slog.String("fallback_title", fallbackTitle))
+	log.Info("Importing project from CBZ")
+	defer log.Info("Finished importing project from CBZ")
+
+	archive, err := cbz.NewReader(r, size)
+	if err != nil {
+		return model.Project{}, errors.Join(ErrInvalidArchive, err)
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return model.Project{}, fmt.Errorf("service: failed to generate id: %w", err)
+	}
+
+	now := time.Now()
+
+	p := model.Project{
+		ID:          id,
+		Title:       fallbackTitle,
+		Direction:   model.DirectionLeftToRight,
+		Spread:      model.SpreadSingle,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	if info := archive.Info; info != nil {
+		p.Series = info.Series
+		p.Number = info.Number
+		p.Writer = info.Writer
+		p.Summary = info.Summary
+
+		if info.Manga == cbz.MangaYesAndRightToLeft {
+			p.Direction = model.DirectionRightToLeft
+		}
+
+		switch {
+		case info.Title != "":
+			p.Title = info.Title
+		case info.Series != "" && info.Number != "":
+			p.Title = fmt.Sprintf("%s #%s", info.Series, info.Number)
+		case info.Series != "":
+			p.Title = info.Series
+		}
+	}
+
+	if p.Title == "" {
+		return model.Project{}, errors.Join(ErrInvalidArchive, errors.New("archive has no title"))
+	}
+
+	p, err = svc.create(p, ownerUserID...)
+	if err != nil {
+		return model.Project{}, err
+	}
+
+	log = log.With(slog.String("project", p.ID.String()))
+
+	for i, cp := range archive.Pages {
+		err := svc.importPage(p.ID, i, cp)
+		if err != nil {
+			log.Error("Failed to import page, deleting project",
+				slog.String("page", cp.Name), slog.String("error", err.Error()))
+
+			if derr := svc.delete(p.ID); derr != nil {
+				log.Error("Failed to delete partially imported project", slog.String("error", derr.Error()))
+			}
+
+			return model.Project{}, err
+		}
+	}
+
+	return p, nil
+}
+
+// ExportCBZ streams the project's pages in reading order from the storage into a
+// CBZ archive written to w, with a ComicInfo.xml built from the project's metadata,
+// see [Project.GetMetadata].
+func (svc Project) ExportCBZ(w io.Writer, projectID uuid.UUID) error {
+	log := svc.log.With(slog.String("project", projectID.String()))
+	log.Info("Exporting project to CBZ")
+	defer log.Info("Finished exporting project to CBZ")
+
+	project, err := svc.GetProject(projectID)
+	if err != nil {
+		return err
+	}
+
+	metadata, err := svc.GetMetadata(projectID)
+	if err != nil {
+		return err
+	}
+
+	pages, err := svc.GetPages(projectID)
+	if err != nil {
+		return err
+	}
+
+	info := comicInfo(metadata)
+	info.PageCount = len(pages)
+	if project.Direction == model.DirectionRightToLeft {
+		info.Manga = cbz.MangaYesAndRightToLeft
+	}
+
+	archive, err := cbz.NewWriter(w, info)
+	if err != nil {
+		return fmt.Errorf("service: failed to create archive: %w", err)
+	}
+
+	for _, page := range pages {
+		err := func() error {
+			r, err := svc.openPage(page)
+			if err != nil {
+				return err
+			}
+			defer r.Close()
+
+			if err := archive.AddPage(page.ContentType, r); err != nil {
+				return fmt.Errorf("service: failed to write page to archive: %w", err)
+			}
+			return nil
+		}()
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("service: failed to close archive: %w", err)
+	}
+
+	return nil
+}
+
+// comicInfo maps the metadata of a project to the fields of a ComicInfo.xml file.
+func comicInfo(m ast.Metadata) *cbz.ComicInfo {
+	credits := func(roles ...ast.Role) string {
+		names := []string{}
+		for _, r := range roles {
+			names = append(names, m.CreatorsByRole(r)...)
+		}
+		return strings.Join(names, ", ")
+	}
+
+	return &cbz.ComicInfo{
+		Title:       m.Title,
+		Series:      m.Series,
+		Number:      m.Issue,
+		Summary:     m.Description,
+		Writer:      credits(ast.RoleAuthor),
+		Penciller:   credits(ast.RoleArtist, ast.RoleIllustrator),
+		Colorist:    credits(ast.RoleColorist),
+		Letterer:    credits(ast.RoleLetterer),
+		CoverArtist: credits(ast.RoleCoverArtist),
+		Editor:      credits(ast.RoleEditor),
+		Translator:  credits(ast.RoleTranslator),
+		Publisher:   m.Publisher,
+		LanguageISO: m.Language,
+		AgeRating:   m.AgeRating,
+		GTIN:        m.ISBN,
+	}
+}
+
+// GetMetadata returns the publication metadata of the project, which is used by
+// its package and all of its exports.
+//
+// The project's writers are credited as authors, or if it has none, the members
+// with the author permission. Other members are credited based on what they have
+// permission to edit.
+func (svc Project) GetMetadata(projectID uuid.UUID) (ast.Metadata, error) {
+	project, err := svc.GetProject(projectID)
+	if err != nil {
+		return ast.Metadata{}, err
+	}
+
+	m := ast.Metadata{
+		Identifier:  project.ID.URN(),
+		Title:       project.Title,
+		Description: project.Summary,
+		Series:      project.Series,
+		Issue:       project.Number,
+		Modified:    project.DateUpdated,
+	}
+
+	credits, err := svc.credits(projectID)
+	if err != nil {
+		return ast.Metadata{}, err
+	}
+
+	if project.Writer != "" {
+		for _, name := range strings.Split(project.Writer, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				m.Creators = append(m.Creators, ast.Creator{Name: name, Role: ast.RoleAuthor})
+			}
+		}
+	} else {
+		m.Creators = append(m.Creators, credits[ast.RoleAuthor]...)
+	}
+
+	for _, r := range []ast.Role{ast.RoleArtist, ast.RoleLetterer, ast.RoleTranslator} {
+		m.Creators = append(m.Creators, credits[r]...)
+	}
+
+	return m, nil
+}
+
+// credits returns the usernames of the project's members by the role they are
+// credited as, based on their permissions.
+func (svc Project) credits(projectID uuid.UUID) (map[ast.Role][]ast.Creator, error) {
+	svc.assert.NotNil(svc.userRepo)
+
+	perms, err := svc.permissionRepo.GetByProjectID(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("service: failed to get project permissions: %w", err)
+	}
+
+	credits := map[ast.Role][]ast.Creator{}
+
+	for userID, p := range perms {
+		user, err := svc.userRepo.GetByID(userID)
+		if err != nil {
+			return nil, fmt.Errorf("service: failed to get project member: %w", err)
+		}
+
+		var role ast.Role
+		switch {
+		case p.Has(model.PermissionAuthor):
+			role = ast.RoleAuthor
+		case p.Has(model.PermissionEditPages):
+			role = ast.RoleArtist
+		case p.Has(model.PermissionEditDialogs):
+			role = ast.RoleLetterer
+		case p.Has(model.PermissionEditTranslations):
+			role = ast.RoleTranslator
+		default:
+			continue
+		}
+
+		credits[role] = append(credits[role], ast.Creator{Name: user.Username, Role: role})
+	}
+
+	// Permissions are stored in a map, so the order is not stable
+	for _, cs := range credits {
+		slices.SortFunc(cs, func(a, b ast.Creator) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+	}
+
+	return credits, nil
+}
+
+func (svc Project) importPage(projectID uuid.UUID, position int, cp cbz.Page) error {
+	if cp.Size > maxPageSize {
+		return errors.Join(ErrInvalidArchive, fmt.Errorf("page %q is larger than %d bytes", cp.Name, maxPageSize))
+	}
+
+	f, err := cp.Open()
+	if err != nil {
+		return errors.Join(ErrInvalidArchive, fmt.Errorf("failed to open page %q: %w", cp.Name, err))
+	}
+	defer f.Close()
+
+	// The object storage needs to know the length and be able to seek the body so it can
+	// sign the request, which compressed files in the archive can't provide. The size
+	// declared by the archive can't be trusted, so the read is limited too.
+	data, err := io.ReadAll(io.LimitReader(f, maxPageSize+1))
+	if err != nil {
+		return errors.Join(ErrInvalidArchive, fmt.Errorf("failed to read page %q: %w", cp.Name, err))
+	}
+	if len(data) > maxPageSize {
+		return errors.Join(ErrInvalidArchive, fmt.Errorf("page %q is larger than %d bytes", cp.Name, maxPageSize))
+	}
+
+	_, err = svc.createPage(projectID, position, cp.ContentType, data)
+	return err
+}
+
+func (svc Project) createPage(projectID uuid.UUID, position int, contentType string, data []byte) (model.Page, error) {
+	svc.assert.NotNil(svc.storage)
+	svc.assert.NotNil(svc.ctx)
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return model.Page{}, fmt.Errorf("service: failed to generate id: %w", err)
+	}
+
+	now := time.Now()
+
+	page := model.Page{
+		ID:          id,
+		ProjectID:   projectID,
+		Position:    position,
+		ContentType: contentType,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	if err := page.Validate(); err != nil {
+		return model.Page{}, fmt.Errorf("service: invalid page: %w", err)
+	}
+
+	key := pageKey(projectID, id)
+
+	log := svc.log.With(slog.String("project", projectID.String()), slog.String("key", key))
+	log.Debug("Storing page image")
+
+	_, err = svc.storage.PutObject(svc.ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(svc.bucket),
+		Key:           aws.String(key),
+		Body:          bytes.NewReader(data),
+		ContentLength: aws.Int64(int64(len(data))),
+		ContentType:   aws.String(contentType),
+	})
+	if err != nil {
+		log.Error("Failed to store page image", slog.String("error", err.Error()))
+		return model.Page{}, fmt.Errorf("service: failed to store page image: %w", err)
+	}
+
+	if err := svc.pageRepo.Create(page); err != nil {
+		// Without the page, the image wouldn't be deleted together with the project.
+		_, derr := svc.storage.DeleteObject(svc.ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(svc.bucket),
+			Key:    aws.String(key),
+		})
+		if derr != nil {
+			log.Error("Failed to delete image of page not created", slog.String("error", derr.Error()))
+		}
+		return model.Page{}, fmt.Errorf("service: failed to create page: %w", err)
+	}
+
+	return page, nil
+}
+
+// delete removes the project, the images of all of its pages and its audio files.
+func (svc Project) delete(projectID uuid.UUID) error {
+	svc.assert.NotNil(svc.storage)
+	svc.assert.NotNil(svc.ctx)
+
+	pages, err := svc.GetPages(projectID)
+	if err != nil {
+		return err
+	}
+
+	audio, err := svc.GetAudio(projectID)
+	if err != nil {
+		return err
+	}
+
+	errs := []error{}
+	for _, page := range pages {
+		_, err := svc.storage.DeleteObject(svc.ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(svc.bucket),
+			Key:    aws.String(pageKey(projectID, page.ID)),
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("service: failed to delete page image: %w", err))
+		}
+	}
+	for _, a := range audio {
+		_, err := svc.storage.DeleteObject(svc.ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(svc.bucket),
+			Key:    aws.String(audioKey(projectID, a.ID)),
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("service: failed to delete audio file: %w", err))
+		}
+	}
+
+	if err := svc.projectRepo.DeleteByID(projectID); err != nil {
+		errs = append(errs, fmt.Errorf("service: failed to delete project: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
 func (svc Project) SetAuthor(projectID uuid.UUID, userID uuid.UUID) error {
 	log := svc.log.With(slog.String("project", projectID.String()), slog.String("user", userID.String()))
 	log.Info("Setting project owner")
@@ -90,6 +522,18 @@ func (svc Project) SetAuthor(projectID uuid.UUID, userID uuid.UUID) error {
 	return nil
 }
 
+// HasPermissions reports whether the user has all the permissions perms on the
+// project. Users which are not members of the project have no permissions.
+func (svc Project) HasPermissions(projectID, userID uuid.UUID, perms ...model.Permissions) (bool, error) {
+	p, err := svc.permissionRepo.GetByID(projectID, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("service: failed to get user permissions: %w", err)
+	}
+	return p.Has(perms...), nil
+}
+
 func (svc Project) GetUserProjects(userID uuid.UUID) ([]model.Project, error) {
 	perms, err := svc.permissionRepo.GetByUserID(userID)
 	if err != nil {
@@ -122,3 +566,288 @@ func (svc Project) GetProject(projectID uuid.UUID) (model.Project, error) {
 	}
 	return p, nil
 }
+
+// SetLayout changes the reading direction of the project's pages and how many of them
+// are shown at once, which are used by its package and all of its exports.
+func (svc Project) SetLayout(projectID uuid.UUID, direction model.Direction, spread model.Spread) error {
+	log := svc.log.With(slog.String("project", projectID.String()),
+		slog.String("direction", string(direction)), slog.String("spread", string(spread)))
+	log.Info("Setting project layout")
+	defer log.Info("Finished setting project layout")
+
+	p, err := svc.GetProject(projectID)
+	if err != nil {
+		return err
+	}
+
+	p.Direction = direction
+	p.Spread = spread
+	p.DateUpdated = time.Now()
+
+	if err := p.Validate(); err != nil {
+		return errors.Join(ErrInvalidLayout, err)
+	}
+
+	if err := svc.projectRepo.Update(p); err != nil {
+		return fmt.Errorf("service: failed to update project: %w", err)
+	}
+
+	return nil
+}
+
+// Layout returns the ipub layout of the project's pages.
+func Layout(p model.Project) ast.Layout {
+	l := ast.Layout{}
+
+	switch p.Direction {
+	case model.DirectionLeftToRight:
+		l.Progression = ast.ProgressionLeftToRight
+	case model.DirectionRightToLeft:
+		l.Progression = ast.ProgressionRightToLeft
+	case model.DirectionVertical:
+		l.Progression = ast.ProgressionVertical
+	}
+
+	switch p.Spread {
+	case model.SpreadSingle:
+		l.Spread = ast.SpreadSingle
+	case model.SpreadDouble:
+		l.Spread = ast.SpreadDouble
+	}
+
+	return l
+}
+
+func (svc Project) GetPages(projectID uuid.UUID) ([]model.Page, error) {
+	ps, err := svc.pageRepo.GetByProjectID(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("service: failed to get project pages: %w", err)
+	}
+	return ps, nil
+}
+
+// AddAudio stores the audio file read from r in the project, so it can be used by
+// the audio nodes of its sections. The file is available in the project's package
+// at the href returned by [AudioHref]. Files larger than [MaxAudioSize] are not
+// stored and [ErrFileTooLarge] is returned.
+func (svc Project) AddAudio(projectID uuid.UUID, name, contentType string, r io.Reader) (model.Audio, error) {
+	svc.assert.NotNil(svc.storage)
+	svc.assert.NotNil(svc.ctx)
+
+	if _, ok := audioExtensions[contentType]; !ok {
+		return model.Audio{}, errors.Join(ErrUnsupportedMediaType, fmt.Errorf("audio of type %q", contentType))
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, MaxAudioSize+1))
+	if err != nil {
+		return model.Audio{}, fmt.Errorf("service: failed to read audio file: %w", err)
+	}
+	if len(data) > MaxAudioSize {
+		return model.Audio{}, errors.Join(ErrFileTooLarge, fmt.Errorf("audio %q is larger than %d bytes", name, MaxAudioSize))
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return model.Audio{}, fmt.Errorf("service: failed to generate id: %w", err)
+	}
+
+	now := time.Now()
+
+	audio := model.Audio{
+		ID:          id,
+		ProjectID:   projectID,
+		Name:        name,
+		ContentType: contentType,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	if err := audio.Validate(); err != nil {
+		return model.Audio{}, fmt.Errorf("service: invalid audio: %w", err)
+	}
+
+	key := audioKey(projectID, id)
+
+	log := svc.log.With(slog.String("project", projectID.String()), slog.String("key", key))
+	log.Debug("Storing audio file")
+
+	_, err = svc.storage.PutObject(svc.ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(svc.bucket),
+		Key:           aws.String(key),
+		Body:          bytes.NewReader(data),
+		ContentLength: aws.Int64(int64(len(data))),
+		ContentType:   aws.String(contentType),
+	})
+	if err != nil {
+		log.Error("Failed to store audio file", slog.String("error", err.Error()))
+		return model.Audio{}, fmt.Errorf("service: failed to store audio file: %w", err)
+	}
+
+	if err := svc.audioRepo.Create(audio); err != nil {
+		// Without the audio, the file wouldn't be deleted together with the project.
+		_, derr := svc.storage.DeleteObject(svc.ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(svc.bucket),
+			Key:    aws.String(key),
+		})
+		if derr != nil {
+			log.Error("Failed to delete file of audio not created", slog.String("error", derr.Error()))
+		}
+		return model.Audio{}, fmt.Errorf("service: failed to create audio: %w", err)
+	}
+
+	return audio, nil
+}
+
+func (svc Project) GetAudio(projectID uuid.UUID) ([]model.Audio, error) {
+	as, err := svc.audioRepo.GetByProjectID(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("service: failed to get project audio: %w", err)
+	}
+	return as, nil
+}
+
+// GetPackage builds the ipub tree of the project, with its metadata and layout, all its
+// pages in reading order inside a single section, and its audio files as resources. Files are only
+// downloaded from the storage when the package's resources are opened.
+func (svc Project) GetPackage(projectID uuid.UUID) (*ast.Package, error) {
+	svc.assert.NotNil(svc.storage)
+	svc.assert.NotNil(svc.ctx)
+
+	metadata, err := svc.GetMetadata(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	pages, err := svc.GetPages(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	project, err := svc.GetProject(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &ast.Package{
+		Metadata:  metadata,
+		Resources: make([]ast.Resource, len(pages)),
+		Layout:    Layout(project),
+	}
+	body := &ast.Body{}
+
+	for i, page := range pages {
+		href := pageHref(page)
+
+		pkg.Resources[i] = ast.Resource{
+			Href:      href,
+			MediaType: page.ContentType,
+			Open: func() (io.ReadCloser, error) {
+				return svc.openPage(page)
+			},
+		}
+
+		img := &ast.Image{}
+		img.SetSource(href)
+
+		content := &ast.Content{}
+		content.SetSpread(page.Spread)
+		content.AppendChild(content, img)
+
+		body.AppendChild(body, content)
+	}
+
+	audio, err := svc.GetAudio(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range audio {
+		pkg.Resources = append(pkg.Resources, ast.Resource{
+			Href:      AudioHref(a),
+			MediaType: a.ContentType,
+			Open: func() (io.ReadCloser, error) {
+				return svc.openObject(audioKey(a.ProjectID, a.ID))
+			},
+		})
+	}
+
+	section := &ast.Section{Title: metadata.Title}
+	section.SetHref("sections/section-001.xhtml")
+	section.SetBody(body)
+	pkg.AppendChild(pkg, section)
+	pkg.AppendChild(pkg, ast.GenerateTOC(pkg))
+
+	return pkg, nil
+}
+
+func (svc Project) openPage(page model.Page) (io.ReadCloser, error) {
+	return svc.openObject(pageKey(page.ProjectID, page.ID))
+}
+
+func (svc Project) openObject(key string) (io.ReadCloser, error) {
+	log := svc.log.With(slog.String("key", key))
+	log.Debug("Getting object from storage")
+
+	res, err := svc.storage.GetObject(svc.ctx, &s3.GetObjectInput{
+		Bucket: aws.String(svc.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		log.Error("Failed to get object from storage", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("service: failed to get object %q: %w", key, err)
+	}
+
+	return res.Body, nil
+}
+
+// pageKey is the object key of a page's image in the storage bucket.
+func pageKey(projectID, pageID uuid.UUID) string {
+	return fmt.Sprintf("projects/%s/pages/%s", projectID, pageID)
+}
+
+// pageHref is the path of a page's image inside the project's ipub package.
+func pageHref(page model.Page) string {
+	return fmt.Sprintf("pages/%s%s", page.ID, pageExtensions[page.ContentType])
+}
+
+var pageExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// audioKey is the object key of an audio file in the storage bucket.
+func audioKey(projectID, audioID uuid.UUID) string {
+	return fmt.Sprintf("projects/%s/audio/%s", projectID, audioID)
+}
+
+// AudioHref is the path of an audio file inside the project's ipub package, which
+// should be used as the source of audio nodes.
+func AudioHref(audio model.Audio) string {
+	return fmt.Sprintf("audio/%s%s", audio.ID, audioExtensions[audio.ContentType])
+}
+
+var audioExtensions = map[string]string{
+	"audio/mpeg": ".mp3",
+	"audio/ogg":  ".ogg",
+	"audio/opus": ".opus",
+	"audio/wav":  ".wav",
+	"audio/webm": ".weba",
+	"audio/aac":  ".aac",
+}
+
+// maxPageSize is the maximum size of a page image imported from an archive, which
+// is read into memory before being stored.
+const maxPageSize = 64 << 20 // 64 MiB
+
+// MaxAudioSize is the maximum size of an audio file added to a project, which is
+// read into memory before being stored.
+const MaxAudioSize = 32 << 20 // 32 MiB
+
+var (
+	ErrInvalidArchive       = errors.New("service: archive is invalid")
+	ErrUnsupportedMediaType = errors.New("service: media type is not supported")
+	ErrFileTooLarge         = errors.New("service: file is too large")
+	ErrInvalidLayout        = errors.New("service: layout is invalid")
+)